@@ -5,11 +5,16 @@ import (
 	"errors"
 	"github.com/ildomm/cceab/dao"
 	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/logging"
+	"github.com/ildomm/cceab/metrics"
 	"github.com/ildomm/cceab/server"
 	"github.com/ildomm/cceab/system"
+	"github.com/ildomm/cceab/tracing"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"time"
 )
 
 var (
@@ -23,10 +28,19 @@ func main() {
 	ctx, shutdown := context.WithCancel(context.Background())
 	defer shutdown()
 
+	// Only the flags of the command are accepted, the parsers skipping the flags of the others
+	if err := system.CheckFlags(os.Args[1:], system.APIHandlerFlags); err != nil {
+		log.Fatalf("parsing command line: %s", err)
+	}
+
 	// Define standards
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.SetPrefix("Server: ")
-	log.Printf("starting http server, Version %s, GIT sha %s", semVer, gitSha)
+	loggingOptions, err := system.ParseLogging(os.Args[1:])
+	if err != nil {
+		log.Fatalf("parsing command line: %s", err)
+	}
+	loggingOptions.Component = "api_handler"
+	logging.Setup(loggingOptions)
+	slog.Info("starting http server", "version", semVer, "git_sha", gitSha)
 
 	// Set the timezone to UTC
 	system.SetGlobalTimezoneUTC() //nolint:all
@@ -34,39 +48,150 @@ func main() {
 	// Parse the command line options
 	dBConnURL, err := system.ParseDBConnURL(os.Args[1:])
 	if err != nil {
-		log.Fatalf("parsing command line: %s", err)
+		logging.Fatal("parsing command line", "error", err)
+	}
+	tracingOptions, err := system.ParseTracing(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
 	}
 	httpServerPort, err := system.ParseHTTPPort(os.Args[1:])
 	if err != nil {
-		log.Fatalf("parsing command line: %s", err)
+		logging.Fatal("parsing command line", "error", err)
+	}
+	adminToken, err := system.ParseAdminToken(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	drainTimeout, err := system.ParseDrainTimeout(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	preDrainDelay, err := system.ParsePreDrainDelay(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	signatureOptions, err := system.ParseSignatures(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	rateLimitOptions, err := system.ParseRateLimits(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	if adminToken == "" {
+		slog.Warn("no admin token given, the admin API is disabled")
 	}
 
+	// The validations triggered through the admin API follow the validator configuration
+	cancellationPoliciesConfig, err := system.ParseCancellationPolicies(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	cancellationPolicies, err := dao.ParseCancellationPolicies(cancellationPoliciesConfig)
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	validationBatchSize, err := system.ParseValidationBatchSize(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+
+	// Set up the export of the traces, flushed on exit
+	tracingOptions.ServiceName, tracingOptions.ServiceVersion = "cceab-api-handler", semVer
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		logging.Fatal("error setting up tracing", "error", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("error flushing traces", "error", err)
+		}
+	}()
+
 	// Set up the database connection and run migrations
-	log.Printf("connecting to database")
+	slog.Info("connecting to database")
 	querier, err := database.NewPostgresQuerier(
 		ctx,
 		dBConnURL,
 	)
 	if err != nil {
-		log.Fatalf("error connecting to the database: %s", err)
+		logging.Fatal("error connecting to the database", "error", err)
 	}
 	defer querier.Close()
 
-	// Initialize manager
-	gameResultManager := dao.NewGameResultDAO(querier)
+	// Expose the database connection pool along with the metrics of the server
+	if err := metrics.RegisterDBStats(metrics.Registry, querier.Stats); err != nil {
+		logging.Fatal("error exposing database metrics", "error", err)
+	}
+
+	// Initialize managers, recording spans for them and for their database calls
+	tracingQuerier := database.NewTracingQuerier(querier)
+	gameResultManager := dao.NewGameResultDAO(tracingQuerier)
+	gameResultManager.WithCancellationPolicies(cancellationPolicies)
+	gameResultManager.WithValidationBatchSize(validationBatchSize)
+	userManager := dao.NewUserDAO(tracingQuerier)
+	validationRunManager := dao.NewValidationRunDAO(tracingQuerier)
+	healthManager := dao.NewHealthDAO(tracingQuerier)
+	apiKeyManager := dao.NewTracingAPIKeyDAO(dao.NewAPIKeyDAO(tracingQuerier))
 
 	// Initialize the server
 	server := server.NewServer()
 	server.WithListenAddress(httpServerPort)
-	server.WithGameResultManager(gameResultManager)
+	server.WithGameResultManager(dao.NewTracingGameResultDAO(gameResultManager))
+	server.WithUserManager(dao.NewTracingUserDAO(userManager))
+	server.WithValidationRunManager(dao.NewTracingValidationRunDAO(validationRunManager))
+	server.WithHealthManager(dao.NewTracingHealthDAO(healthManager))
+	server.WithAPIKeyManager(apiKeyManager)
+	server.WithRequestSignatures(signatureOptions.Required, signatureOptions.MaxAge)
+	server.WithRateLimits(rateLimitOptions.User, rateLimitOptions.Source)
+	server.WithMaxInFlight(rateLimitOptions.MaxInFlight)
+	server.WithVersion(semVer, gitSha)
+	server.WithAdminToken(adminToken)
+	server.WithDrainTimeout(drainTimeout)
+	server.WithPreDrainDelay(preDrainDelay)
+
+	// The nonces of the signed requests are only needed until their signature is too old to be accepted
+	go purgeExpiredNonces(ctx, apiKeyManager, signatureOptions.MaxAge)
+
+	slog.Info("starting server",
+		"port", server.ListenAddress(),
+		"require_signatures", signatureOptions.Required,
+		"user_rate_limit", rateLimitOptions.User.Rate,
+		"source_rate_limit", rateLimitOptions.Source.Rate,
+		"max_in_flight_requests", rateLimitOptions.MaxInFlight,
+	)
+
+	go func() {
+		if err := server.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Fatal("could not start server", "port", server.ListenAddress(), "error", err)
+		}
+	}()
+
+	slog.Info("caught signal, draining requests", "signal", system.WaitForSignal().String(), "pre_drain_delay", preDrainDelay.String(), "drain_timeout", drainTimeout.String())
+
+	// The in-flight requests finish before the database connection is closed, deferred above
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("error draining requests", "error", err)
+	}
+	slog.Info("server closed")
+}
 
-	log.Println("Starting server on", server.ListenAddress())
+// purgeExpiredNonces deletes the expired nonces of the signed requests on each interval, until the context is done
+func purgeExpiredNonces(ctx context.Context, apiKeyManager dao.APIKeyDAO, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	if err := server.Run(); err != nil {
-		if !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal("Could not start server on", server.ListenAddress())
-		} else {
-			log.Println("Server closed")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := apiKeyManager.PurgeExpiredNonces(ctx)
+			if err != nil {
+				slog.Error("error purging expired nonces", "error", err)
+				continue
+			}
+			slog.Debug("expired nonces purged", "deleted", deleted)
 		}
 	}
 }
@@ -2,10 +2,17 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"github.com/google/uuid"
 	"github.com/ildomm/cceab/dao"
 	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/logging"
+	"github.com/ildomm/cceab/metrics"
 	"github.com/ildomm/cceab/system"
+	"github.com/ildomm/cceab/tracing"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 )
@@ -14,8 +21,7 @@ var (
 	gitSha = "unknown" // Populated with the last Git commit SHA (short) at build time
 	semVer = "unknown" // Populated with semantic version at build time
 
-	pauseDuration      = 1 * time.Minute
-	totalGamesToCancel = 10
+	totalGamesToCancel = dao.DefaultTotalGamesToCancel
 )
 
 func main() {
@@ -24,10 +30,19 @@ func main() {
 	ctx, shutdown := context.WithCancel(context.Background())
 	defer shutdown()
 
+	// Only the flags of the command are accepted, the parsers skipping the flags of the others
+	if err := system.CheckFlags(os.Args[1:], system.ValidatorFlags); err != nil {
+		log.Fatalf("parsing command line: %s", err)
+	}
+
 	// Define standards
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.SetPrefix("Validator: ")
-	log.Printf("starting validator, Version %s, GIT sha %s", semVer, gitSha)
+	loggingOptions, err := system.ParseLogging(os.Args[1:])
+	if err != nil {
+		log.Fatalf("parsing command line: %s", err)
+	}
+	loggingOptions.Component = "validator"
+	logging.Setup(loggingOptions)
+	slog.Info("starting validator", "version", semVer, "git_sha", gitSha)
 
 	// Set the timezone to UTC
 	system.SetGlobalTimezoneUTC() //nolint:all
@@ -35,45 +50,191 @@ func main() {
 	// Parse the command line options
 	dBConnURL, err := system.ParseDBConnURL(os.Args[1:])
 	if err != nil {
-		log.Fatalf("parsing command line: %s", err)
+		logging.Fatal("parsing command line", "error", err)
+	}
+	tracingOptions, err := system.ParseTracing(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	cancellationPoliciesConfig, err := system.ParseCancellationPolicies(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	cancellationPolicies, err := dao.ParseCancellationPolicies(cancellationPoliciesConfig)
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	validationBatchSize, err := system.ParseValidationBatchSize(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
 	}
+	validationSweepInterval, err := system.ParseValidationSweepInterval(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	dryRunOptions, err := system.ParseDryRun(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+	metricsPort, err := system.ParseMetricsPort(os.Args[1:])
+	if err != nil {
+		logging.Fatal("parsing command line", "error", err)
+	}
+
+	// Set up the export of the traces, flushed on exit
+	tracingOptions.ServiceName, tracingOptions.ServiceVersion = "cceab-validator", semVer
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		logging.Fatal("error setting up tracing", "error", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("error flushing traces", "error", err)
+		}
+	}()
 
 	// Set up the database connection and run migrations
-	log.Printf("connecting to database")
+	slog.Info("connecting to database")
 	querier, err := database.NewPostgresQuerier(
 		ctx,
 		dBConnURL,
 	)
 	if err != nil {
-		log.Fatalf("error connecting to the database: %s", err)
+		logging.Fatal("error connecting to the database", "error", err)
 	}
 	defer querier.Close()
 
-	// Initialize manager
-	gameResultManager := dao.NewGameResultDAO(querier)
+	// Initialize manager, recording spans for it and for its database calls
+	gameResultManager := dao.NewGameResultDAO(database.NewTracingQuerier(querier))
+	gameResultManager.WithCancellationPolicies(cancellationPolicies)
+	gameResultManager.WithValidationBatchSize(validationBatchSize)
+	tracingGameResultManager := dao.NewTracingGameResultDAO(gameResultManager)
+
+	// Evaluate once and report, without validating anything
+	if dryRunOptions.Enabled {
+		gameResultManager.WithDryRun(true)
+		if err := dryRun(ctx, tracingGameResultManager, dryRunOptions); err != nil {
+			logging.Fatal("error on dry run", "error", err)
+		}
+		return
+	}
+
+	// Expose the metrics, along with the database connection pool ones
+	if metricsPort != 0 {
+		if err := metrics.RegisterDBStats(metrics.Registry, querier.Stats); err != nil {
+			logging.Fatal("error exposing database metrics", "error", err)
+		}
+
+		slog.Info("exposing metrics", "port", metricsPort)
+		go func() {
+			if err := metrics.Serve(ctx, metricsPort); err != nil {
+				slog.Error("error exposing metrics", "port", metricsPort, "error", err)
+			}
+		}()
+	}
+
+	// Wake up on the game results recorded, falling back to the sweeps only when not possible
+	pendingValidations, err := querier.ListenPendingValidations(ctx)
+	if err != nil {
+		slog.Warn("error listening to pending validations, sweeping only", "sweep_interval", validationSweepInterval.String(), "error", err)
+	}
 
 	// Run the pipeline
-	go run(ctx, gameResultManager)
+	stopped := make(chan validationSummary, 1)
+	go func() {
+		stopped <- run(ctx, tracingGameResultManager, validationBatchSize, validationSweepInterval, pendingValidations)
+	}()
+
+	slog.Info("caught signal, finishing the current validation", "signal", system.WaitForSignal().String())
+
+	// Stop claiming users, then wait for the user being validated, before the database connection is closed
+	shutdown()
+	summary := <-stopped
+
+	slog.Info("validator stopped",
+		"rounds", summary.rounds,
+		"users_processed", summary.usersProcessed,
+		"results_approved", summary.resultsApproved,
+		"results_canceled", summary.resultsCanceled,
+		"balance_delta", summary.balanceDelta.String(),
+	)
+}
+
+// dryRun evaluates the pending game results of every user, writing the report of what the validation would change
+func dryRun(ctx context.Context, gameResultManager dao.GameResultDAO, options system.DryRunOptions) error {
+	slog.InfoContext(ctx, "starting dry run, nothing will be committed")
+
+	validationRun, err := gameResultManager.ValidateGameResults(ctx, totalGamesToCancel)
+	if err != nil {
+		return err
+	}
+
+	report := os.Stdout
+	if options.ReportPath != "" && options.ReportPath != "-" {
+		report, err = os.Create(options.ReportPath)
+		if err != nil {
+			return fmt.Errorf("creating report: %w", err)
+		}
+		defer report.Close()
+	}
+
+	if err := system.WriteValidationReport(report, options.ReportFormat, *validationRun); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
 
-	log.Printf("caught signal, terminating. %v", system.WaitForSignal().String())
+	slog.InfoContext(ctx, "dry run finished",
+		"users_processed", validationRun.UsersProcessed,
+		"results_to_cancel", validationRun.ResultsCanceled,
+		"results_to_approve", validationRun.ResultsApproved,
+		"balance_delta", validationRun.BalanceDelta.String(),
+	)
+	return nil
+}
+
+// validationSummary tallies the validation runs of the pipeline
+type validationSummary struct {
+	rounds          int
+	usersProcessed  int
+	resultsApproved int
+	resultsCanceled int
+	balanceDelta    entity.Money
+}
+
+// add adds the validation run to the summary
+func (s *validationSummary) add(validationRun entity.ValidationRun) {
+	s.rounds++
+	s.usersProcessed += validationRun.UsersProcessed
+	s.resultsApproved += validationRun.ResultsApproved
+	s.resultsCanceled += validationRun.ResultsCanceled
+	s.balanceDelta += validationRun.BalanceDelta
 }
 
 // Run starts the validation pipeline
-func run(ctx context.Context, gameResultManager dao.GameResultDAO) {
-	log.Printf("starting validating game results")
+// A round runs when pending validations are notified, or once the sweep interval has elapsed without notifications
+// The pause between rounds is skipped while full batches of users are waiting for validation
+// Once the context is cancelled, the current round finishes the user being validated, then the pipeline stops
+// It returns the summary of the rounds run
+func run(ctx context.Context, gameResultManager dao.GameResultDAO, validationBatchSize int, validationSweepInterval time.Duration, pendingValidations <-chan uuid.UUID) validationSummary {
+	slog.InfoContext(ctx, "starting validating game results")
 
+	var summary validationSummary
 	for {
 		select {
 		case <-ctx.Done():
-			log.Printf("stopping pipeline")
-			return
+			slog.InfoContext(ctx, "stopping pipeline")
+			return summary
 		default:
-			err := gameResultManager.ValidateGameResults(ctx, totalGamesToCancel)
+			validationRun, err := gameResultManager.ValidateGameResults(ctx, totalGamesToCancel)
 			if err != nil {
-				log.Printf("error validating game results: %s", err)
+				slog.ErrorContext(ctx, "error validating game results", "error", err)
+			}
+			if validationRun != nil {
+				summary.add(*validationRun)
 			}
 
-			system.SleepWithContext(ctx, pauseDuration)
+			if err != nil || validationRun.UsersProcessed < validationBatchSize {
+				system.SleepUntilNotified(ctx, validationSweepInterval, pendingValidations)
+			}
 		}
 	}
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/test_helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// TestRunStopsOnCancellationMidRound tests the pipeline finishes the round in progress when cancelled, then stops.
+func TestRunStopsOnCancellationMidRound(t *testing.T) {
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// The signal arrives while the first round is validating a user
+	mockDAO.On("ValidateGameResults", mock.Anything, totalGamesToCancel).Run(func(args mock.Arguments) {
+		shutdown()
+	}).Return(&entity.ValidationRun{
+		UsersProcessed:  2,
+		ResultsApproved: 3,
+		ResultsCanceled: 1,
+		BalanceDelta:    -5_00,
+	}, nil).Once()
+
+	stopped := make(chan validationSummary, 1)
+	go func() {
+		stopped <- run(ctx, mockDAO, 2, time.Hour, nil)
+	}()
+
+	select {
+	case summary := <-stopped:
+		assert.Equal(t, 1, summary.rounds)
+		assert.Equal(t, 2, summary.usersProcessed)
+		assert.Equal(t, 3, summary.resultsApproved)
+		assert.Equal(t, 1, summary.resultsCanceled)
+		assert.Equal(t, entity.Money(-5_00), summary.balanceDelta)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pipeline did not stop once cancelled")
+	}
+
+	mockDAO.AssertNumberOfCalls(t, "ValidateGameResults", 1)
+}
+
+// TestRunStopsOnCancellationWhileSleeping tests the pipeline stops right away when cancelled between rounds.
+func TestRunStopsOnCancellationWhileSleeping(t *testing.T) {
+	ctx, shutdown := context.WithCancel(context.Background())
+	defer shutdown()
+
+	mockDAO := test_helpers.NewMockGameResultDAO()
+	mockDAO.On("ValidateGameResults", mock.Anything, totalGamesToCancel).Return(&entity.ValidationRun{}, nil)
+
+	stopped := make(chan validationSummary, 1)
+	go func() {
+		stopped <- run(ctx, mockDAO, 2, time.Hour, nil)
+	}()
+
+	// Let the first round finish, the pipeline then waits for the sweep interval
+	time.Sleep(100 * time.Millisecond)
+	shutdown()
+
+	select {
+	case summary := <-stopped:
+		assert.Equal(t, 1, summary.rounds)
+		assert.Equal(t, 0, summary.usersProcessed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pipeline did not stop once cancelled")
+	}
+}
@@ -0,0 +1,17 @@
+package dao
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+	"time"
+)
+
+type APIKeyDAO interface {
+	CreateAPIKey(ctx context.Context, transactionSource entity.TransactionSource, description string) (*entity.APIKey, string, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+	RotateAPIKey(ctx context.Context, apiKeyId int, gracePeriod time.Duration) (*entity.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, apiKeyId int) (*entity.APIKey, error)
+	RecordNonce(ctx context.Context, apiKeyId int, nonce string, ttl time.Duration) error
+	PurgeExpiredNonces(ctx context.Context) (int64, error)
+}
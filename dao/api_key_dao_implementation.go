@@ -0,0 +1,259 @@
+package dao
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/signing"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// APIKeyPrefix starts every api key, telling them apart from other secrets
+	APIKeyPrefix = "cceab_"
+
+	// DefaultAPIKeyGracePeriod is how long a rotated key is still accepted, by default
+	DefaultAPIKeyGracePeriod = 24 * time.Hour
+
+	// apiKeySecretSize is the number of random bytes of an api key
+	apiKeySecretSize = 32
+
+	// apiKeyDisplayLength is the number of leading characters of the key kept as its prefix
+	apiKeyDisplayLength = len(APIKeyPrefix) + 8
+)
+
+type apiKeyDAO struct {
+	querier database.Querier
+}
+
+// NewAPIKeyDAO creates a new api key DAO
+func NewAPIKeyDAO(querier database.Querier) *apiKeyDAO {
+	return &apiKeyDAO{querier: querier}
+}
+
+// CreateAPIKey creates a new api key for the transaction source, along with its random signing key
+// It returns the created api key, holding the signing key, along with the key itself, which is not stored and can not be read again
+// It returns an error if the transaction source is invalid
+func (dm *apiKeyDAO) CreateAPIKey(ctx context.Context, transactionSource entity.TransactionSource, description string) (*entity.APIKey, string, error) {
+	if entity.ParseTransactionSource(string(transactionSource)) == nil {
+		return nil, "", entity.ErrInvalidTransactionSource
+	}
+
+	var apiKey *entity.APIKey
+	var key string
+
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		var err error
+		apiKey, key, err = dm.insertAPIKey(ctx, *txn, transactionSource, description)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	slog.InfoContext(ctx, "api key created", "api_key_id", apiKey.ID, "api_key_prefix", apiKey.Prefix, "transaction_source", apiKey.TransactionSource)
+	return apiKey, key, nil
+}
+
+// insertAPIKey generates a new key, and records it for the transaction source
+func (dm *apiKeyDAO) insertAPIKey(ctx context.Context, txn sqlx.Tx, transactionSource entity.TransactionSource, description string) (*entity.APIKey, string, error) {
+	key, err := generateAPIKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("generating api key: %w", err)
+	}
+
+	// The signing key is a secret of its own, the api key being sent along with every request
+	signingKey, err := signing.NewKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("generating signing key: %w", err)
+	}
+
+	apiKey := entity.APIKey{
+		Prefix:            key[:apiKeyDisplayLength],
+		KeyHash:           hashAPIKey(key),
+		SigningKey:        hex.EncodeToString(signingKey),
+		TransactionSource: transactionSource,
+		Description:       description,
+		CreatedAt:         time.Now(),
+	}
+
+	apiKey.ID, err = dm.querier.InsertAPIKey(ctx, txn, apiKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("inserting api key: %w", err)
+	}
+
+	return &apiKey, key, nil
+}
+
+// AuthenticateAPIKey finds the api key matching the given key
+// It returns the api key, holding the transaction source it is bound to
+// It returns an error if the key is unknown, expired or revoked
+func (dm *apiKeyDAO) AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, entity.ErrUnauthorized
+	}
+
+	apiKey, err := dm.querier.SelectAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("selecting api key by hash: %w", err)
+	}
+	if apiKey == nil || !apiKey.Active(time.Now()) {
+		return nil, entity.ErrUnauthorized
+	}
+
+	return apiKey, nil
+}
+
+// ListAPIKeys lists all the api keys, including the expired and revoked ones
+// It returns the api keys, oldest first
+func (dm *apiKeyDAO) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	apiKeys, err := dm.querier.SelectAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("selecting api keys: %w", err)
+	}
+
+	return apiKeys, nil
+}
+
+// RotateAPIKey replaces the api key by a new one, bound to the same transaction source, with a new signing key
+// The replaced key is still accepted during the grace period, so the source can switch to the new key without downtime
+// It returns the new api key, holding the signing key, along with the key itself, which is not stored and can not be read again
+// It returns an error if the api key does not exist or is revoked, or if the grace period is negative
+func (dm *apiKeyDAO) RotateAPIKey(ctx context.Context, apiKeyId int, gracePeriod time.Duration) (*entity.APIKey, string, error) {
+	if gracePeriod < 0 {
+		return nil, "", entity.ErrInvalidGracePeriod
+	}
+
+	var rotated *entity.APIKey
+	var apiKey *entity.APIKey
+	var key string
+
+	// Perform the whole operation inside a db transaction
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		// Concurrent rotations of the key wait for each other, and only the first one succeeds
+		var err error
+		rotated, err = dm.lockAPIKey(ctx, *txn, apiKeyId)
+		if err != nil {
+			return err
+		}
+
+		// The grace period never extends the life of the replaced key
+		expiresAt := time.Now().Add(gracePeriod)
+		if !rotated.ExpiresAt.Valid || expiresAt.Before(rotated.ExpiresAt.Time) {
+			rotated.ExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+		}
+
+		err = dm.querier.UpdateAPIKey(ctx, *txn, *rotated)
+		if err != nil {
+			return fmt.Errorf("updating api key: %w", err)
+		}
+
+		apiKey, key, err = dm.insertAPIKey(ctx, *txn, rotated.TransactionSource, rotated.Description)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	slog.InfoContext(ctx, "api key rotated", "api_key_id", rotated.ID, "new_api_key_id", apiKey.ID, "api_key_prefix", apiKey.Prefix, "expires_at", rotated.ExpiresAt.Time)
+	return apiKey, key, nil
+}
+
+// RevokeAPIKey revokes the api key, which is no longer accepted from then on
+// It returns the revoked api key
+// It returns an error if the api key does not exist or is already revoked
+func (dm *apiKeyDAO) RevokeAPIKey(ctx context.Context, apiKeyId int) (*entity.APIKey, error) {
+	var apiKey *entity.APIKey
+
+	// Perform the whole operation inside a db transaction
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		var err error
+		apiKey, err = dm.lockAPIKey(ctx, *txn, apiKeyId)
+		if err != nil {
+			return err
+		}
+
+		apiKey.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		err = dm.querier.UpdateAPIKey(ctx, *txn, *apiKey)
+		if err != nil {
+			return fmt.Errorf("updating api key: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "api key revoked", "api_key_id", apiKey.ID, "api_key_prefix", apiKey.Prefix)
+	return apiKey, nil
+}
+
+// RecordNonce records the nonce of a signed request, so the request can not be replayed while the nonce lives
+// It returns an error if the nonce was already used with the api key
+func (dm *apiKeyDAO) RecordNonce(ctx context.Context, apiKeyId int, nonce string, ttl time.Duration) error {
+	inserted, err := dm.querier.InsertRequestNonce(ctx, apiKeyId, nonce, time.Now().Add(ttl))
+	if err != nil {
+		return fmt.Errorf("inserting request nonce: %w", err)
+	}
+	if !inserted {
+		return entity.ErrNonceReused
+	}
+
+	return nil
+}
+
+// PurgeExpiredNonces deletes the nonces no longer needed to detect the replayed requests
+// It returns the number of nonces deleted
+func (dm *apiKeyDAO) PurgeExpiredNonces(ctx context.Context) (int64, error) {
+	deleted, err := dm.querier.DeleteExpiredRequestNonces(ctx, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("deleting expired request nonces: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// lockAPIKey locks the api key row until the end of the transaction
+// It returns an error if the api key does not exist or is revoked
+func (dm *apiKeyDAO) lockAPIKey(ctx context.Context, txn sqlx.Tx, apiKeyId int) (*entity.APIKey, error) {
+	apiKey, err := dm.querier.LockAPIKeyRow(ctx, txn, apiKeyId)
+	if err != nil {
+		return nil, fmt.Errorf("locking api key row: %w", err)
+	}
+	if apiKey == nil {
+		return nil, entity.ErrAPIKeyNotFound
+	}
+	if apiKey.RevokedAt.Valid {
+		return nil, entity.ErrAPIKeyRevoked
+	}
+
+	return apiKey, nil
+}
+
+// generateAPIKey generates a new random key
+func generateAPIKey() (string, error) {
+	secret := make([]byte, apiKeySecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashAPIKey hashes the key, as stored in the database
+// The keys are random and long enough for a plain SHA-256 to resist brute forcing
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
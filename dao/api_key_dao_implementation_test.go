@@ -0,0 +1,308 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/signing"
+	"github.com/ildomm/cceab/test_helpers"
+)
+
+func TestCreateAPIKeySuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewAPIKeyDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	var inserted entity.APIKey
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("InsertAPIKey", ctx, mock.Anything, mock.AnythingOfType("entity.APIKey")).
+		Run(func(args mock.Arguments) { inserted = args.Get(2).(entity.APIKey) }).
+		Return(7, nil)
+
+	apiKey, key, err := instance.CreateAPIKey(ctx, entity.TransactionSourceGame, "slots")
+
+	require.NoError(t, err)
+	assert.Equal(t, 7, apiKey.ID)
+	assert.Equal(t, entity.TransactionSourceGame, apiKey.TransactionSource)
+	assert.True(t, strings.HasPrefix(key, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(key, apiKey.Prefix))
+
+	// Only the hash of the key is stored
+	assert.Equal(t, hashAPIKey(key), inserted.KeyHash)
+	assert.NotContains(t, inserted.KeyHash, key)
+
+	// The signing key is a random secret, returned along with the key
+	signingKey, err := hex.DecodeString(inserted.SigningKey)
+	require.NoError(t, err)
+	assert.Len(t, signingKey, signing.KeySize)
+	assert.Equal(t, inserted.SigningKey, apiKey.SigningKey)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateAPIKeyUniqueKeys(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewAPIKeyDAO(mockQuerier)
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.Anything)
+	mockQuerier.On("InsertAPIKey", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+
+	firstAPIKey, first, err := instance.CreateAPIKey(context.TODO(), entity.TransactionSourceGame, "")
+	require.NoError(t, err)
+	secondAPIKey, second, err := instance.CreateAPIKey(context.TODO(), entity.TransactionSourceGame, "")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, firstAPIKey.SigningKey, secondAPIKey.SigningKey)
+}
+
+func TestCreateAPIKeyInvalidSource(t *testing.T) {
+	instance := NewAPIKeyDAO(test_helpers.NewMockQuerier())
+
+	_, _, err := instance.CreateAPIKey(context.TODO(), entity.TransactionSource("casino"), "")
+
+	assert.ErrorIs(t, err, entity.ErrInvalidTransactionSource)
+}
+
+func TestAuthenticateAPIKeySuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewAPIKeyDAO(mockQuerier)
+
+	ctx := context.TODO()
+	key := APIKeyPrefix + "secret"
+
+	// A rotated key is accepted until the end of its grace period
+	mockQuerier.On("SelectAPIKeyByHash", ctx, hashAPIKey(key)).Return(&entity.APIKey{
+		ID:                3,
+		TransactionSource: entity.TransactionSourcePayment,
+		ExpiresAt:         sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}, nil)
+
+	apiKey, err := instance.AuthenticateAPIKey(ctx, key)
+
+	require.NoError(t, err)
+	assert.Equal(t, entity.TransactionSourcePayment, apiKey.TransactionSource)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestAuthenticateAPIKeyRejected(t *testing.T) {
+	key := APIKeyPrefix + "secret"
+
+	tests := map[string]*entity.APIKey{
+		"unknown": nil,
+		"expired": {ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Second), Valid: true}},
+		"revoked": {RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}},
+	}
+
+	for name, stored := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockQuerier := test_helpers.NewMockQuerier()
+			if stored == nil {
+				mockQuerier.On("SelectAPIKeyByHash", mock.Anything, hashAPIKey(key)).Return(nil, nil)
+			} else {
+				mockQuerier.On("SelectAPIKeyByHash", mock.Anything, hashAPIKey(key)).Return(stored, nil)
+			}
+
+			_, err := NewAPIKeyDAO(mockQuerier).AuthenticateAPIKey(context.TODO(), key)
+
+			assert.ErrorIs(t, err, entity.ErrUnauthorized)
+		})
+	}
+}
+
+func TestAuthenticateAPIKeyMalformed(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	_, err := NewAPIKeyDAO(mockQuerier).AuthenticateAPIKey(context.TODO(), "game")
+
+	assert.ErrorIs(t, err, entity.ErrUnauthorized)
+	mockQuerier.AssertNotCalled(t, "SelectAPIKeyByHash", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticateAPIKeyDatabaseError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	expectedErr := errors.New("connection refused")
+	mockQuerier.On("SelectAPIKeyByHash", mock.Anything, mock.Anything).Return(nil, expectedErr)
+
+	_, err := NewAPIKeyDAO(mockQuerier).AuthenticateAPIKey(context.TODO(), APIKeyPrefix+"secret")
+
+	assert.ErrorIs(t, err, expectedErr)
+	assert.NotErrorIs(t, err, entity.ErrUnauthorized)
+}
+
+func TestRotateAPIKeySuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewAPIKeyDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	var updated entity.APIKey
+	var inserted entity.APIKey
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockAPIKeyRow", ctx, mock.Anything, 3).Return(&entity.APIKey{
+		ID:                3,
+		TransactionSource: entity.TransactionSourceServer,
+		Description:       "backoffice",
+	}, nil)
+	mockQuerier.On("UpdateAPIKey", ctx, mock.Anything, mock.AnythingOfType("entity.APIKey")).
+		Run(func(args mock.Arguments) { updated = args.Get(2).(entity.APIKey) }).
+		Return(nil)
+	mockQuerier.On("InsertAPIKey", ctx, mock.Anything, mock.AnythingOfType("entity.APIKey")).
+		Run(func(args mock.Arguments) { inserted = args.Get(2).(entity.APIKey) }).
+		Return(4, nil)
+
+	before := time.Now()
+	apiKey, key, err := instance.RotateAPIKey(ctx, 3, time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, apiKey.ID)
+	assert.Equal(t, hashAPIKey(key), inserted.KeyHash)
+	assert.Equal(t, entity.TransactionSourceServer, inserted.TransactionSource)
+	assert.Equal(t, "backoffice", inserted.Description)
+
+	// The replaced key expires at the end of the grace period
+	require.True(t, updated.ExpiresAt.Valid)
+	assert.WithinDuration(t, before.Add(time.Hour), updated.ExpiresAt.Time, time.Second)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestRotateAPIKeyKeepsEarlierExpiry(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	expiresAt := time.Now().Add(time.Minute)
+
+	var updated entity.APIKey
+	mockQuerier.On("WithTransaction", mock.Anything, mock.Anything)
+	mockQuerier.On("LockAPIKeyRow", mock.Anything, mock.Anything, 3).Return(&entity.APIKey{
+		ID:        3,
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	}, nil)
+	mockQuerier.On("UpdateAPIKey", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { updated = args.Get(2).(entity.APIKey) }).
+		Return(nil)
+	mockQuerier.On("InsertAPIKey", mock.Anything, mock.Anything, mock.Anything).Return(4, nil)
+
+	_, _, err := NewAPIKeyDAO(mockQuerier).RotateAPIKey(context.TODO(), 3, time.Hour)
+
+	require.NoError(t, err)
+	assert.Equal(t, expiresAt, updated.ExpiresAt.Time)
+}
+
+func TestRotateAPIKeyErrors(t *testing.T) {
+	tests := map[string]struct {
+		stored      *entity.APIKey
+		gracePeriod time.Duration
+		expectedErr error
+	}{
+		"not found":       {stored: nil, expectedErr: entity.ErrAPIKeyNotFound},
+		"revoked":         {stored: &entity.APIKey{ID: 3, RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}, expectedErr: entity.ErrAPIKeyRevoked},
+		"negative period": {stored: &entity.APIKey{ID: 3}, gracePeriod: -time.Second, expectedErr: entity.ErrInvalidGracePeriod},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockQuerier := test_helpers.NewMockQuerier()
+			mockQuerier.On("WithTransaction", mock.Anything, mock.Anything)
+			if test.stored == nil {
+				mockQuerier.On("LockAPIKeyRow", mock.Anything, mock.Anything, 3).Return(nil, nil)
+			} else {
+				mockQuerier.On("LockAPIKeyRow", mock.Anything, mock.Anything, 3).Return(test.stored, nil)
+			}
+
+			_, _, err := NewAPIKeyDAO(mockQuerier).RotateAPIKey(context.TODO(), 3, test.gracePeriod)
+
+			assert.ErrorIs(t, err, test.expectedErr)
+			mockQuerier.AssertNotCalled(t, "InsertAPIKey", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRevokeAPIKeySuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewAPIKeyDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockAPIKeyRow", ctx, mock.Anything, 3).Return(&entity.APIKey{ID: 3}, nil)
+	mockQuerier.On("UpdateAPIKey", ctx, mock.Anything, mock.MatchedBy(func(apiKey entity.APIKey) bool {
+		return apiKey.ID == 3 && apiKey.RevokedAt.Valid
+	})).Return(nil)
+
+	apiKey, err := instance.RevokeAPIKey(ctx, 3)
+
+	require.NoError(t, err)
+	assert.False(t, apiKey.Active(time.Now()))
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestRevokeAPIKeyNotFound(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.Anything)
+	mockQuerier.On("LockAPIKeyRow", mock.Anything, mock.Anything, 3).Return(nil, nil)
+
+	_, err := NewAPIKeyDAO(mockQuerier).RevokeAPIKey(context.TODO(), 3)
+
+	assert.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+}
+
+func TestListAPIKeysSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	mockQuerier.On("SelectAPIKeys", mock.Anything).Return([]entity.APIKey{{ID: 1}, {ID: 2}}, nil)
+
+	apiKeys, err := NewAPIKeyDAO(mockQuerier).ListAPIKeys(context.TODO())
+
+	require.NoError(t, err)
+	assert.Len(t, apiKeys, 2)
+}
+
+func TestRecordNonce(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewAPIKeyDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	before := time.Now()
+	mockQuerier.On("InsertRequestNonce", ctx, 3, "fresh-nonce", mock.MatchedBy(func(expiresAt time.Time) bool {
+		return !expiresAt.Before(before.Add(10*time.Minute)) && expiresAt.Before(time.Now().Add(10*time.Minute))
+	})).Return(true, nil)
+	mockQuerier.On("InsertRequestNonce", ctx, 3, "used-nonce", mock.Anything).Return(false, nil)
+	mockQuerier.On("InsertRequestNonce", ctx, 3, "failing-nonce", mock.Anything).Return(false, errors.New("connection refused"))
+
+	assert.NoError(t, instance.RecordNonce(ctx, 3, "fresh-nonce", 10*time.Minute))
+	assert.ErrorIs(t, instance.RecordNonce(ctx, 3, "used-nonce", 10*time.Minute), entity.ErrNonceReused)
+
+	err := instance.RecordNonce(ctx, 3, "failing-nonce", 10*time.Minute)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, entity.ErrNonceReused)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestPurgeExpiredNonces(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	mockQuerier.On("DeleteExpiredRequestNonces", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(4), nil)
+
+	deleted, err := NewAPIKeyDAO(mockQuerier).PurgeExpiredNonces(context.TODO())
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+}
@@ -0,0 +1,144 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ildomm/cceab/entity"
+)
+
+// DefaultCancellationPolicyKey selects the policy applied to the transaction sources without their own policy
+const DefaultCancellationPolicyKey = "default"
+
+// CancellationCandidate is a pending game result under validation, along with its context
+type CancellationCandidate struct {
+	// User is the owner of the game result, locked for the validation
+	User entity.User
+	// GameResult is the game result to decide on
+	GameResult entity.GameResult
+	// Previous holds the pending game results of the user recorded before this one, the oldest first
+	// It is a copy, the policy is free to modify it
+	Previous []entity.GameResult
+}
+
+// CancellationPolicy decides which pending game results the validator cancels
+type CancellationPolicy interface {
+	// Name identifies the policy in the configuration
+	Name() string
+	// ShouldCancel tells if the candidate game result must be canceled
+	ShouldCancel(candidate CancellationCandidate) bool
+}
+
+// OddIDCancellationPolicy cancels the game results with an odd ID
+type OddIDCancellationPolicy struct{}
+
+func (p OddIDCancellationPolicy) Name() string {
+	return "odd_id"
+}
+
+func (p OddIDCancellationPolicy) ShouldCancel(candidate CancellationCandidate) bool {
+	return candidate.GameResult.ShouldBeCanceled()
+}
+
+// NoCancellationPolicy never cancels, every game result is accepted
+type NoCancellationPolicy struct{}
+
+func (p NoCancellationPolicy) Name() string {
+	return "none"
+}
+
+func (p NoCancellationPolicy) ShouldCancel(candidate CancellationCandidate) bool {
+	return false
+}
+
+var (
+	registeredPoliciesLock sync.RWMutex
+	registeredPolicies     = map[string]CancellationPolicy{
+		OddIDCancellationPolicy{}.Name(): OddIDCancellationPolicy{},
+		NoCancellationPolicy{}.Name():    NoCancellationPolicy{},
+	}
+)
+
+// RegisterCancellationPolicy makes the policy selectable by its name from the configuration
+// It replaces any policy previously registered with the same name
+func RegisterCancellationPolicy(policy CancellationPolicy) {
+	registeredPoliciesLock.Lock()
+	defer registeredPoliciesLock.Unlock()
+
+	registeredPolicies[policy.Name()] = policy
+}
+
+// LookupCancellationPolicy returns the policy registered with the given name
+// It returns an error if there is no such policy
+func LookupCancellationPolicy(name string) (CancellationPolicy, error) {
+	registeredPoliciesLock.RLock()
+	defer registeredPoliciesLock.RUnlock()
+
+	policy, ok := registeredPolicies[name]
+	if !ok {
+		names := make([]string, 0, len(registeredPolicies))
+		for registered := range registeredPolicies {
+			names = append(names, registered)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown cancellation policy %q, expected one of %v", name, names)
+	}
+	return policy, nil
+}
+
+// CancellationPolicies holds the cancellation policy of each transaction source
+type CancellationPolicies struct {
+	defaultPolicy CancellationPolicy
+	bySource      map[entity.TransactionSource]CancellationPolicy
+}
+
+// NewCancellationPolicies creates the cancellation policies, applying the given policy to every transaction source
+func NewCancellationPolicies(defaultPolicy CancellationPolicy) *CancellationPolicies {
+	return &CancellationPolicies{
+		defaultPolicy: defaultPolicy,
+		bySource:      make(map[entity.TransactionSource]CancellationPolicy),
+	}
+}
+
+// ParseCancellationPolicies creates the cancellation policies from their configuration
+// The configuration maps a transaction source, or DefaultCancellationPolicyKey, to a registered policy name
+// The transaction sources not configured, without a configured default, use the odd ID policy
+// It returns an error if a transaction source or a policy name is unknown
+func ParseCancellationPolicies(config map[string]string) (*CancellationPolicies, error) {
+	policies := NewCancellationPolicies(OddIDCancellationPolicy{})
+
+	for key, name := range config {
+		policy, err := LookupCancellationPolicy(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if key == DefaultCancellationPolicyKey {
+			policies.defaultPolicy = policy
+			continue
+		}
+
+		source := entity.ParseTransactionSource(key)
+		if source == nil {
+			return nil, fmt.Errorf("%w: %q", entity.ErrInvalidTransactionSource, key)
+		}
+		policies.WithSourcePolicy(*source, policy)
+	}
+
+	return policies, nil
+}
+
+// WithSourcePolicy applies the given policy to the game results of the transaction source
+func (p *CancellationPolicies) WithSourcePolicy(source entity.TransactionSource, policy CancellationPolicy) *CancellationPolicies {
+	p.bySource[source] = policy
+	return p
+}
+
+// For returns the policy applying to the game results of the transaction source
+func (p *CancellationPolicies) For(source entity.TransactionSource) CancellationPolicy {
+	if policy, ok := p.bySource[source]; ok {
+		return policy
+	}
+	return p.defaultPolicy
+}
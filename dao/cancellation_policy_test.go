@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"testing"
+
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// amountCancellationPolicy cancels the game results above a threshold
+type amountCancellationPolicy struct {
+	threshold entity.Money
+}
+
+func (p amountCancellationPolicy) Name() string {
+	return "amount_threshold"
+}
+
+func (p amountCancellationPolicy) ShouldCancel(candidate CancellationCandidate) bool {
+	return candidate.GameResult.Amount > p.threshold
+}
+
+func TestOddIDCancellationPolicy(t *testing.T) {
+	policy := OddIDCancellationPolicy{}
+
+	assert.Equal(t, "odd_id", policy.Name())
+	assert.True(t, policy.ShouldCancel(CancellationCandidate{GameResult: entity.GameResult{ID: 1}}))
+	assert.False(t, policy.ShouldCancel(CancellationCandidate{GameResult: entity.GameResult{ID: 2}}))
+}
+
+func TestNoCancellationPolicy(t *testing.T) {
+	policy := NoCancellationPolicy{}
+
+	assert.Equal(t, "none", policy.Name())
+	assert.False(t, policy.ShouldCancel(CancellationCandidate{GameResult: entity.GameResult{ID: 1}}))
+}
+
+func TestCancellationPoliciesFor(t *testing.T) {
+	policies := NewCancellationPolicies(OddIDCancellationPolicy{}).
+		WithSourcePolicy(entity.TransactionSourcePayment, NoCancellationPolicy{})
+
+	assert.Equal(t, NoCancellationPolicy{}, policies.For(entity.TransactionSourcePayment))
+	assert.Equal(t, OddIDCancellationPolicy{}, policies.For(entity.TransactionSourceGame))
+	assert.Equal(t, OddIDCancellationPolicy{}, policies.For(entity.TransactionSourceServer))
+}
+
+func TestParseCancellationPolicies(t *testing.T) {
+	policies, err := ParseCancellationPolicies(map[string]string{
+		DefaultCancellationPolicyKey: "none",
+		"game":                       "odd_id",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, OddIDCancellationPolicy{}, policies.For(entity.TransactionSourceGame))
+	assert.Equal(t, NoCancellationPolicy{}, policies.For(entity.TransactionSourcePayment))
+}
+
+func TestParseCancellationPoliciesEmpty(t *testing.T) {
+	policies, err := ParseCancellationPolicies(nil)
+	require.NoError(t, err)
+
+	// The odd ID rule stays the default
+	assert.Equal(t, OddIDCancellationPolicy{}, policies.For(entity.TransactionSourceGame))
+}
+
+func TestParseCancellationPoliciesUnknownPolicy(t *testing.T) {
+	_, err := ParseCancellationPolicies(map[string]string{"game": "unknown"})
+	assert.ErrorContains(t, err, `unknown cancellation policy "unknown"`)
+}
+
+func TestParseCancellationPoliciesUnknownSource(t *testing.T) {
+	_, err := ParseCancellationPolicies(map[string]string{"casino": "none"})
+	assert.ErrorIs(t, err, entity.ErrInvalidTransactionSource)
+}
+
+func TestRegisterCancellationPolicy(t *testing.T) {
+	RegisterCancellationPolicy(amountCancellationPolicy{threshold: 100_00})
+
+	policies, err := ParseCancellationPolicies(map[string]string{"payment": "amount_threshold"})
+	require.NoError(t, err)
+
+	policy := policies.For(entity.TransactionSourcePayment)
+	assert.True(t, policy.ShouldCancel(CancellationCandidate{GameResult: entity.GameResult{Amount: 100_01}}))
+	assert.False(t, policy.ShouldCancel(CancellationCandidate{GameResult: entity.GameResult{Amount: 100_00}}))
+}
@@ -7,6 +7,9 @@ import (
 )
 
 type GameResultDAO interface {
-	CreateGameResult(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount float64, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error)
-	ValidateGameResults(ctx context.Context, totalGamesToCancel int) error
+	CreateGameResult(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount entity.Money, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error)
+	CreateGameResults(ctx context.Context, transactionSource entity.TransactionSource, submissions []entity.GameResultSubmission) []entity.GameResultOutcome
+	ListGameResults(ctx context.Context, filter entity.GameResultFilter) (*entity.GameResultPage, error)
+	ValidateGameResults(ctx context.Context, totalGamesToCancel int) (*entity.ValidationRun, error)
+	ValidateUserGameResults(ctx context.Context, userId uuid.UUID, totalGamesToCancel int) (*entity.ValidationRun, error)
 }
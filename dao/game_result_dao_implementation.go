@@ -2,42 +2,79 @@ package dao
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/ildomm/cceab/database"
 	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/metrics"
 	"github.com/jmoiron/sqlx"
-	"log"
-	"sync"
+	"log/slog"
+	"slices"
 	"time"
 )
 
+// DefaultValidationBatchSize is the maximum number of users validated on each validation round
+const DefaultValidationBatchSize = 100
+
+// DefaultTotalGamesToCancel is the maximum number of game results of a user canceled on each validation
+const DefaultTotalGamesToCancel = 10
+
 type gameResultDAO struct {
-	querier database.Querier
-	lock    sync.Mutex
+	querier              database.Querier
+	cancellationPolicies *CancellationPolicies
+	validationBatchSize  int
+	dryRun               bool
 }
 
 // NewGameResultDAO creates a new game result DAO
+// The game results of every transaction source are validated with the odd ID cancellation policy
 func NewGameResultDAO(querier database.Querier) *gameResultDAO {
-	return &gameResultDAO{querier: querier}
+	return &gameResultDAO{
+		querier:              querier,
+		cancellationPolicies: NewCancellationPolicies(OddIDCancellationPolicy{}),
+		validationBatchSize:  DefaultValidationBatchSize,
+	}
+}
+
+// WithCancellationPolicies sets the policies deciding which game results the validation cancels
+func (dm *gameResultDAO) WithCancellationPolicies(cancellationPolicies *CancellationPolicies) {
+	dm.cancellationPolicies = cancellationPolicies
+}
+
+// WithValidationBatchSize sets the maximum number of users validated on each validation round
+func (dm *gameResultDAO) WithValidationBatchSize(validationBatchSize int) {
+	dm.validationBatchSize = validationBatchSize
+}
+
+// WithDryRun sets whether the validation only evaluates the pending game results, without committing any change
+func (dm *gameResultDAO) WithDryRun(dryRun bool) {
+	dm.dryRun = dryRun
 }
 
 // CreateGameResult creates a new game result
 // It validates the transaction and updates the user balance
+// Game results of the same user are serialized by the user row lock, other users are not blocked
 // It returns the created game result
 // It returns an error if the transaction is invalid or if there is an error creating the game result
-func (dm *gameResultDAO) CreateGameResult(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount float64, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error) {
-	dm.lock.Lock()
-	defer dm.lock.Unlock()
-
-	// Check the transaction and its related user
-	balance := 0.0
-	if user, err := dm.validateTransaction(ctx, userId, gameStatus, amount, transactionID); err != nil {
-		return nil, err
-	} else {
-		balance = dm.calculateNewBalance(user.Balance, gameStatus, amount)
+func (dm *gameResultDAO) CreateGameResult(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount entity.Money, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error) {
+	gameResult, err := dm.createGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
+
+	switch {
+	case err == nil:
+		metrics.GameResults.WithLabelValues(string(gameStatus), string(transactionSource)).Inc()
+	case errors.Is(err, entity.ErrUserNegativeBalance):
+		metrics.GameResultRejections.WithLabelValues(metrics.RejectionNegativeBalance, string(transactionSource)).Inc()
+	case errors.Is(err, entity.ErrTransactionIdExists):
+		metrics.GameResultRejections.WithLabelValues(metrics.RejectionTransactionIdExists, string(transactionSource)).Inc()
 	}
 
+	return gameResult, err
+}
+
+// createGameResult creates the game result, see CreateGameResult
+func (dm *gameResultDAO) createGameResult(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount entity.Money, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error) {
 	gameResult := entity.GameResult{
 		UserID:            userId,
 		GameStatus:        gameStatus,
@@ -48,10 +85,28 @@ func (dm *gameResultDAO) CreateGameResult(ctx context.Context, userId uuid.UUID,
 		CreatedAt:         time.Now(),
 	}
 
+	// Retries of an already recorded transaction get the stored game result back
+	if recorded, err := dm.findRecordedTransaction(ctx, gameResult); recorded != nil || err != nil {
+		return recorded, err
+	}
+
 	// Perform the whole operation inside a db transaction
 	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		// No other processes can update the user until end of this transaction
+		user, err := dm.querier.LockUserRow(ctx, *txn, userId)
+		if err != nil {
+			return fmt.Errorf("locking user row: %w", err)
+		}
+
+		// Check the transaction against the locked user
+		if err := dm.validateTransaction(user, gameStatus, amount); err != nil {
+			return err
+		}
+		balance := dm.calculateNewBalance(user.Balance, gameStatus, amount)
+
 		if err := dm.persistGameResultTransaction(ctx, txn, userId, &gameResult, balance); err != nil {
-			log.Printf("error persisting game result: %v", err)
+			slog.ErrorContext(ctx, "error persisting game result", "error", err)
 			return err
 		}
 
@@ -60,44 +115,225 @@ func (dm *gameResultDAO) CreateGameResult(ctx context.Context, userId uuid.UUID,
 		return nil
 	})
 	if err != nil {
-		log.Printf("error performing game result db transaction: %v", err)
+		switch {
+		case errors.Is(err, entity.ErrUserNotFound),
+			errors.Is(err, entity.ErrUserDisabled),
+			errors.Is(err, entity.ErrUserNegativeBalance):
+			return nil, err
+
+		// A concurrent request recorded the same transaction ID first
+		case errors.Is(err, entity.ErrTransactionIdExists):
+			if recorded, err := dm.findRecordedTransaction(ctx, gameResult); recorded != nil || err != nil {
+				return recorded, err
+			}
+			return nil, entity.ErrTransactionIdExists
+		}
+
+		slog.ErrorContext(ctx, "error performing game result db transaction", "error", err)
 		return nil, entity.ErrCreatingGameResult
 	}
 
 	return &gameResult, nil
 }
 
-// validateTransaction validates the transaction
-// It returns the user if the transaction is valid
-func (dm *gameResultDAO) validateTransaction(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount float64, transactionID string) (*entity.User, error) {
-	exists, err := dm.querier.CheckTransactionID(ctx, transactionID)
+// CreateGameResults creates many game results at once, possibly for different users
+// The game results of each user are recorded in a single db transaction, in the submitted order
+// It returns one outcome per submission, in the same order, instead of failing the whole batch
+func (dm *gameResultDAO) CreateGameResults(ctx context.Context, transactionSource entity.TransactionSource, submissions []entity.GameResultSubmission) []entity.GameResultOutcome {
+	outcomes := make([]entity.GameResultOutcome, len(submissions))
+
+	// Group the submissions by user, keeping the order they were submitted in
+	var userIds []uuid.UUID
+	submissionsByUser := make(map[uuid.UUID][]int)
+	for i, submission := range submissions {
+		if _, ok := submissionsByUser[submission.UserID]; !ok {
+			userIds = append(userIds, submission.UserID)
+		}
+		submissionsByUser[submission.UserID] = append(submissionsByUser[submission.UserID], i)
+	}
+
+	// Transaction IDs are unique across the whole batch
+	transactionIds := make(map[string]bool)
+
+	for _, userId := range userIds {
+		dm.createUserGameResults(ctx, userId, transactionSource, submissions, submissionsByUser[userId], outcomes, transactionIds)
+	}
+
+	for i, outcome := range outcomes {
+		switch outcome.Status {
+		case entity.BatchItemStatusCreated:
+			metrics.GameResults.WithLabelValues(string(submissions[i].GameStatus), string(transactionSource)).Inc()
+		case entity.BatchItemStatusInsufficientBalance:
+			metrics.GameResultRejections.WithLabelValues(metrics.RejectionNegativeBalance, string(transactionSource)).Inc()
+		case entity.BatchItemStatusDuplicate:
+			metrics.GameResultRejections.WithLabelValues(metrics.RejectionTransactionIdExists, string(transactionSource)).Inc()
+		}
+	}
+
+	return outcomes
+}
+
+// createUserGameResults records the given submissions of a single user
+// The outcome of each submission is written into the outcomes slice, at the same index
+func (dm *gameResultDAO) createUserGameResults(ctx context.Context, userId uuid.UUID, transactionSource entity.TransactionSource, submissions []entity.GameResultSubmission, indexes []int, outcomes []entity.GameResultOutcome, transactionIds map[string]bool) {
+	setOutcome := func(indexes []int, status entity.BatchItemStatus, err error) {
+		for _, i := range indexes {
+			outcomes[i] = entity.GameResultOutcome{Status: status, Err: err}
+		}
+	}
+
+	// Until the db transaction succeeds, every submission is considered failed
+	setOutcome(indexes, entity.BatchItemStatusFailed, entity.ErrCreatingGameResult)
+
+	var accepted []int
+	var gameResults []entity.GameResult
+
+	// Perform the whole operation inside a db transaction
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		// No other processes can update the user until end of this transaction
+		user, err := dm.querier.LockUserRow(ctx, *txn, userId)
+		if err != nil {
+			return fmt.Errorf("locking user row: %w", err)
+		}
+		if user == nil {
+			setOutcome(indexes, entity.BatchItemStatusUnknownUser, entity.ErrUserNotFound)
+			return nil
+		}
+		if user.Disabled {
+			setOutcome(indexes, entity.BatchItemStatusUserDisabled, entity.ErrUserDisabled)
+			return nil
+		}
+
+		// Apply the submissions one after the other on the running balance
+		balance := user.Balance
+
+		for _, i := range indexes {
+			submission := submissions[i]
+
+			exists := transactionIds[submission.TransactionID]
+			if !exists {
+				exists, err = dm.querier.CheckTransactionID(ctx, submission.TransactionID)
+				if err != nil {
+					slog.ErrorContext(ctx, "error locating transaction", "transaction_id", submission.TransactionID, "error", err)
+					continue
+				}
+			}
+			if exists {
+				setOutcome([]int{i}, entity.BatchItemStatusDuplicate, entity.ErrTransactionIdExists)
+				continue
+			}
+
+			// No negative balance allowed
+			if submission.GameStatus == entity.GameStatusLost && balance < submission.Amount {
+				setOutcome([]int{i}, entity.BatchItemStatusInsufficientBalance, entity.ErrUserNegativeBalance)
+				continue
+			}
+
+			gameResult := entity.GameResult{
+				UserID:            userId,
+				GameStatus:        submission.GameStatus,
+				ValidationStatus:  entity.ValidationStatusPending,
+				TransactionSource: transactionSource,
+				TransactionID:     submission.TransactionID,
+				Amount:            submission.Amount,
+				CreatedAt:         time.Now(),
+			}
+
+			// A concurrent request may have recorded the same transaction ID since the check,
+			// only this submission is then a duplicate, the db transaction goes on with the others
+			id, err := dm.querier.InsertGameResult(ctx, *txn, gameResult)
+			if errors.Is(err, entity.ErrTransactionIdExists) {
+				transactionIds[submission.TransactionID] = true
+				setOutcome([]int{i}, entity.BatchItemStatusDuplicate, entity.ErrTransactionIdExists)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("inserting game result: %w", err)
+			}
+			gameResult.ID = id
+
+			balance = dm.calculateNewBalance(balance, submission.GameStatus, submission.Amount)
+			transactionIds[submission.TransactionID] = true
+			accepted = append(accepted, i)
+			gameResults = append(gameResults, gameResult)
+		}
+
+		if len(accepted) == 0 {
+			return nil
+		}
+
+		if err := dm.querier.UpdateUserBalance(ctx, *txn, userId, balance, false); err != nil {
+			return fmt.Errorf("updating user balance: %w", err)
+		}
+
+		// Commit the transaction
+		// Success, continue with the transaction commit
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "error performing game results batch db transaction", "user_id", userId, "error", err)
+		for _, i := range accepted {
+			delete(transactionIds, submissions[i].TransactionID)
+		}
+		setOutcome(accepted, entity.BatchItemStatusFailed, entity.ErrCreatingGameResult)
+		return
+	}
+
+	for j, i := range accepted {
+		outcomes[i] = entity.GameResultOutcome{Status: entity.BatchItemStatusCreated, GameResult: &gameResults[j]}
+	}
+}
+
+// findRecordedTransaction looks for a game result already recorded under the same transaction ID
+// It returns nil when the transaction ID is new
+// Otherwise it returns the recorded game result, along with ErrTransactionIdReplayed when the
+// submission is identical to the recorded one, or ErrTransactionIdConflict when it differs
+func (dm *gameResultDAO) findRecordedTransaction(ctx context.Context, gameResult entity.GameResult) (*entity.GameResult, error) {
+	exists, err := dm.querier.CheckTransactionID(ctx, gameResult.TransactionID)
 	if err != nil {
-		log.Printf("error locating transaction: %v", err)
+		slog.ErrorContext(ctx, "error locating transaction", "transaction_id", gameResult.TransactionID, "error", err)
 		return nil, err
 	}
-	if exists {
-		return nil, entity.ErrTransactionIdExists
+	if !exists {
+		return nil, nil
 	}
 
-	user, err := dm.querier.SelectUser(ctx, userId)
+	recorded, err := dm.querier.SelectGameResultByTransactionID(ctx, gameResult.TransactionID)
 	if err != nil {
-		log.Printf("error locating user: %v", err)
+		slog.ErrorContext(ctx, "error selecting transaction", "transaction_id", gameResult.TransactionID, "error", err)
 		return nil, err
 	}
+	if recorded == nil {
+		return nil, entity.ErrTransactionIdExists
+	}
+
+	if recorded.SamePayload(gameResult) {
+		return recorded, entity.ErrTransactionIdReplayed
+	}
+	return recorded, entity.ErrTransactionIdConflict
+}
+
+// validateTransaction validates the transaction against the given user
+// It returns an error if the user does not exist, is disabled, or can not afford the game result
+func (dm *gameResultDAO) validateTransaction(user *entity.User, gameStatus entity.GameStatus, amount entity.Money) error {
 	if user == nil {
-		return nil, entity.ErrUserNotFound
+		return entity.ErrUserNotFound
+	}
+	if user.Disabled {
+		return entity.ErrUserDisabled
 	}
 
 	// No negative balance allowed
 	if gameStatus == entity.GameStatusLost && user.Balance < amount {
-		return nil, entity.ErrUserNegativeBalance
+		return entity.ErrUserNegativeBalance
 	}
 
-	return user, nil
+	return nil
 }
 
 // calculateNewBalance calculates the new balance based on the game status
-func (dm *gameResultDAO) calculateNewBalance(currentBalance float64, gameStatus entity.GameStatus, amount float64) float64 {
+func (dm *gameResultDAO) calculateNewBalance(currentBalance entity.Money, gameStatus entity.GameStatus, amount entity.Money) entity.Money {
 	if gameStatus == entity.GameStatusWin {
 		return currentBalance + amount
 	}
@@ -105,13 +341,8 @@ func (dm *gameResultDAO) calculateNewBalance(currentBalance float64, gameStatus
 }
 
 // persistGameResultTransaction persists the game result transaction
-func (dm *gameResultDAO) persistGameResultTransaction(ctx context.Context, txn *sqlx.Tx, userId uuid.UUID, gameResult *entity.GameResult, balance float64) error {
-
-	// No other processes can update the user until end of this transaction
-	if err := dm.querier.LockUserRow(ctx, *txn, userId); err != nil {
-		return fmt.Errorf("locking user row: %w", err)
-	}
-
+// The user row must already be locked by the transaction
+func (dm *gameResultDAO) persistGameResultTransaction(ctx context.Context, txn *sqlx.Tx, userId uuid.UUID, gameResult *entity.GameResult, balance entity.Money) error {
 	id, err := dm.querier.InsertGameResult(ctx, *txn, *gameResult)
 	if err != nil {
 		return fmt.Errorf("inserting game result: %w", err)
@@ -125,65 +356,334 @@ func (dm *gameResultDAO) persistGameResultTransaction(ctx context.Context, txn *
 	return nil
 }
 
-// ValidateGameResults validates the game results
-// It cancels the game results that should be canceled and approves the rest
-// It returns an error if there is an error validating the game results
-func (dm *gameResultDAO) ValidateGameResults(ctx context.Context, totalGamesToCancel int) error {
+// ListGameResults lists the game results of a user, matching the given filter
+// The validated game results come along with their validation decision
+// It returns a page of game results, along with the cursor for the next page
+// It returns an error if the user does not exist
+func (dm *gameResultDAO) ListGameResults(ctx context.Context, filter entity.GameResultFilter) (*entity.GameResultPage, error) {
+	if filter.Limit <= 0 {
+		return nil, entity.ErrInvalidLimit
+	}
 
-	// Select the latest users that have not been validated
-	users, err := dm.querier.SelectUsersByValidationStatus(ctx, false)
+	user, err := dm.querier.SelectUser(ctx, filter.UserID)
 	if err != nil {
-		return fmt.Errorf("selecting users by validation status: %w", err)
+		return nil, fmt.Errorf("selecting user: %w", err)
+	}
+	if user == nil {
+		return nil, entity.ErrUserNotFound
 	}
-	log.Printf("validating latest users. Total users %d", len(users))
 
-	for _, user := range users {
-		if err := dm.validateUserGameResults(ctx, user, totalGamesToCancel); err != nil {
-			return fmt.Errorf("validating user game results for user %s: %w", user.ID, err)
+	// Fetch one extra entry to find out if there is a next page
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	gameResults, err := dm.querier.SelectGameResultsByFilter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("selecting game results by filter: %w", err)
+	}
+
+	page := entity.GameResultPage{GameResults: gameResults}
+	if len(gameResults) > limit {
+		page.GameResults = gameResults[:limit]
+		page.NextCursor = page.GameResults[limit-1].ID
+	}
+
+	if err := dm.attachValidationDecisions(ctx, page.GameResults); err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+// attachValidationDecisions sets the validation decision of the validated game results
+func (dm *gameResultDAO) attachValidationDecisions(ctx context.Context, gameResults []entity.GameResult) error {
+	var validatedIds []int
+	for _, gameResult := range gameResults {
+		if gameResult.ValidationStatus == entity.ValidationStatusAccepted || gameResult.ValidationStatus == entity.ValidationStatusCanceled {
+			validatedIds = append(validatedIds, gameResult.ID)
+		}
+	}
+	if len(validatedIds) == 0 {
+		return nil
+	}
+
+	decisions, err := dm.querier.SelectValidationDecisionsByGameResults(ctx, validatedIds)
+	if err != nil {
+		return fmt.Errorf("selecting validation decisions by game results: %w", err)
+	}
+
+	decisionsByGameResult := make(map[int]entity.ValidationDecision, len(decisions))
+	for _, decision := range decisions {
+		decisionsByGameResult[decision.GameResultID] = decision
+	}
+	for i := range gameResults {
+		if decision, ok := decisionsByGameResult[gameResults[i].ID]; ok {
+			gameResults[i].Decision = &decision
 		}
 	}
+
 	return nil
 }
 
-// validateUserGameResults validates the game results for a user
-func (dm *gameResultDAO) validateUserGameResults(ctx context.Context, user entity.User, totalGamesToCancel int) error {
+// ValidateGameResults validates the game results, of up to a batch of users
+// Each user is claimed and validated in its own db transaction, skipping the users claimed by other validators,
+// so several validators can run concurrently, and the users of a crashed validator are claimed again by the others
+// It cancels the game results that should be canceled and approves the rest, recording the decision on each one
+// A user failing its validation is left pending, and not claimed again in the round, so it does not hold back the other users
+// Once the context is cancelled, it stops claiming users, finishing the validation of the current one
+// It records the validation run, unless there was no user to validate
+// It returns the validation run, along with the errors of the users failed and the error which stopped it, if any
+// On a dry run, it evaluates the pending game results of every user instead, see dryRunValidation
+func (dm *gameResultDAO) ValidateGameResults(ctx context.Context, totalGamesToCancel int) (*entity.ValidationRun, error) {
+	if dm.dryRun {
+		return dm.dryRunValidation(ctx, totalGamesToCancel)
+	}
+
+	run := entity.ValidationRun{StartedAt: time.Now()}
 
-	// Select the game results for the user that are pending validation
-	gameResults, err := dm.querier.SelectGameResultsByUser(ctx, user.ID, entity.ValidationStatusPending)
+	// A cancellation only stops claiming new users: the user being validated is finished regardless,
+	// so its transaction commits, or rolls back on failure, instead of being interrupted halfway
+	validationCtx := context.WithoutCancel(ctx)
+
+	var failedUserIds []uuid.UUID
+	var failures []error
+	for run.UsersProcessed+len(failedUserIds) < dm.validationBatchSize && ctx.Err() == nil {
+		validation, err := dm.validateNextUser(validationCtx, &run, failedUserIds, totalGamesToCancel)
+
+		var userErr *userValidationError
+		if errors.As(err, &userErr) {
+			failedUserIds = append(failedUserIds, userErr.userId)
+			failures = append(failures, err)
+			continue
+		}
+		if err != nil {
+			failures = append(failures, err)
+			break
+		}
+		if validation == nil {
+			break
+		}
+
+		validation.addTo(&run)
+		validation.observe()
+	}
+	err := errors.Join(failures...)
+
+	run.FinishedAt = time.Now()
 	if err != nil {
-		return fmt.Errorf("selecting game results by user: %w", err)
+		failValidationRun(ctx, &run, err)
 	}
 
-	balance := user.Balance
-	totalTransactionsCanceled := 0
+	if run.ID != 0 || err != nil {
+		dm.finishValidationRun(validationCtx, &run)
+	}
+
+	slog.InfoContext(ctx, "validated latest users", "validation_run_id", run.ID, "users_processed", run.UsersProcessed)
+	return &run, err
+}
+
+// ValidateUserGameResults validates the game results of the given user right away, out of the validation rounds
+// The user row is locked, so it waits for any validator validating the user meanwhile
+// It records the validation run, along with the decision on each game result
+// It returns the validation run, along with the error which stopped it, if any
+// It returns an error if the user does not exist
+func (dm *gameResultDAO) ValidateUserGameResults(ctx context.Context, userId uuid.UUID, totalGamesToCancel int) (*entity.ValidationRun, error) {
+	run := entity.ValidationRun{StartedAt: time.Now()}
+
+	var validation *userValidation
 
 	// Perform the whole operation inside a db transaction
-	err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
 
-		// No other processes can update the user until end of this transaction
-		if err := dm.querier.LockUserRow(ctx, *txn, user.ID); err != nil {
+		// No other processes can update or claim the user until end of this transaction
+		user, err := dm.querier.LockUserRow(ctx, *txn, userId)
+		if err != nil {
 			return fmt.Errorf("locking user row: %w", err)
 		}
+		if user == nil {
+			return entity.ErrUserNotFound
+		}
 
-		// Check all the game results, until:
-		// - All the transactions to cancel have been canceled, based on the limit (totalGamesToCancel)
-		// - All the rest of the transactions have been approved
-		for _, gameResult := range gameResults {
-			if totalTransactionsCanceled < totalGamesToCancel && gameResult.ShouldBeCanceled() {
-				if err := dm.cancelGameResult(ctx, txn, gameResult, &balance); err != nil {
-					return fmt.Errorf("canceling game result: %w", err)
-				}
-				totalTransactionsCanceled++
+		if err := dm.startValidationRun(ctx, &run); err != nil {
+			return err
+		}
+
+		validation, err = dm.validateUserGameResults(ctx, txn, run.ID, *user, totalGamesToCancel)
+		if err != nil {
+			return fmt.Errorf("validating user game results for user %s: %w", user.ID, err)
+		}
+
+		// Commit the transaction
+		// Success, continue with the transaction commit
+		return nil
+	})
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return nil, err
+	}
+
+	run.FinishedAt = time.Now()
+	if err != nil {
+		failValidationRun(ctx, &run, err)
+	} else {
+		validation.addTo(&run)
+		validation.observe()
+	}
+
+	dm.finishValidationRun(ctx, &run)
+
+	slog.InfoContext(ctx, "validated user", "user_id", userId, "validation_run_id", run.ID, "results_canceled", run.ResultsCanceled)
+	return &run, err
+}
+
+// dryRunValidation evaluates the pending game results of every user waiting for validation
+// Nothing is locked nor changed: neither the game results, the users, nor the validation runs
+// It returns the validation run as it would be, along with the evaluation of each user
+func (dm *gameResultDAO) dryRunValidation(ctx context.Context, totalGamesToCancel int) (*entity.ValidationRun, error) {
+	run := entity.ValidationRun{StartedAt: time.Now(), DryRun: true}
+
+	users, err := dm.querier.SelectUsersByValidationStatus(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("selecting users by validation status: %w", err)
+	}
+
+	for _, user := range users {
+		gameResults, err := dm.querier.SelectGameResultsByUser(ctx, user.ID, entity.ValidationStatusPending)
+		if err != nil {
+			return nil, fmt.Errorf("selecting game results by user %s: %w", user.ID, err)
+		}
+
+		decisions, balance := dm.decideGameResults(user, gameResults, totalGamesToCancel)
+		for _, decision := range decisions {
+			if decision.Decision == entity.ValidationStatusCanceled {
+				run.ResultsCanceled++
 			} else {
-				if err := dm.approveGameResult(ctx, txn, gameResult); err != nil {
-					return fmt.Errorf("approving game result: %w", err)
-				}
+				run.ResultsApproved++
 			}
 		}
 
-		// Reset the user balance
-		if err := dm.querier.UpdateUserBalance(ctx, *txn, user.ID, balance, true); err != nil {
-			return fmt.Errorf("updating user balance: %w", err)
+		run.UsersProcessed++
+		run.BalanceDelta += balance - user.Balance
+		run.Users = append(run.Users, entity.UserValidation{
+			UserID:        user.ID,
+			BalanceBefore: user.Balance,
+			BalanceAfter:  balance,
+			Decisions:     decisions,
+		})
+	}
+
+	run.FinishedAt = time.Now()
+
+	slog.InfoContext(ctx, "dry run evaluated", "users_processed", run.UsersProcessed)
+	return &run, nil
+}
+
+// startValidationRun records the validation run as started, setting its ID
+// The decisions of the run refer to its ID, so it is recorded along with the first user to validate
+func (dm *gameResultDAO) startValidationRun(ctx context.Context, run *entity.ValidationRun) error {
+	id, err := dm.querier.InsertValidationRun(ctx, *run)
+	if err != nil {
+		return fmt.Errorf("recording validation run: %w", err)
+	}
+	run.ID = id
+	return nil
+}
+
+// failValidationRun records the validation run as failed
+// The error itself, which can hold the SQL or the driver details, is only logged, the run holding the ErrValidationFailed message
+func failValidationRun(ctx context.Context, run *entity.ValidationRun, err error) {
+	slog.ErrorContext(ctx, "validation run failed", "validation_run_id", run.ID, "error", err)
+	run.Error = sql.NullString{String: entity.ErrValidationFailed.Error(), Valid: true}
+}
+
+// finishValidationRun records the outcome of the validation run, recording the run itself if not started yet
+// A failure to record is only logged, the validation itself is already committed
+func (dm *gameResultDAO) finishValidationRun(ctx context.Context, run *entity.ValidationRun) {
+	outcome := metrics.OutcomeSuccess
+	if run.Error.Valid {
+		outcome = metrics.OutcomeFailure
+	}
+	metrics.ValidationRunDuration.WithLabelValues(outcome).Observe(run.FinishedAt.Sub(run.StartedAt).Seconds())
+
+	if run.ID == 0 {
+		id, err := dm.querier.InsertValidationRun(ctx, *run)
+		if err != nil {
+			slog.ErrorContext(ctx, "error recording validation run", "error", err)
+			return
+		}
+		run.ID = id
+		return
+	}
+
+	if err := dm.querier.UpdateValidationRun(ctx, *run); err != nil {
+		slog.ErrorContext(ctx, "error recording validation run", "validation_run_id", run.ID, "error", err)
+	}
+}
+
+// userValidation tallies the validation of the game results of a user
+type userValidation struct {
+	approved     int
+	canceled     int
+	balanceDelta entity.Money
+	decisions    []entity.ValidationDecision
+}
+
+// addTo adds the tally of the user validation to the validation run
+func (v *userValidation) addTo(run *entity.ValidationRun) {
+	run.UsersProcessed++
+	run.ResultsApproved += v.approved
+	run.ResultsCanceled += v.canceled
+	run.BalanceDelta += v.balanceDelta
+}
+
+// observe counts the decisions of the user validation, once committed
+func (v *userValidation) observe() {
+	for _, decision := range v.decisions {
+		metrics.ValidationDecisions.WithLabelValues(string(decision.Decision), string(decision.Reason)).Inc()
+	}
+}
+
+// userValidationError is the failure of the validation of a claimed user, the other users can still be validated
+type userValidationError struct {
+	userId uuid.UUID
+	err    error
+}
+
+func (e *userValidationError) Error() string {
+	return fmt.Sprintf("validating user game results for user %s: %v", e.userId, e.err)
+}
+
+func (e *userValidationError) Unwrap() error {
+	return e.err
+}
+
+// validateNextUser claims a user waiting for validation, but the excluded ones, and validates its game results
+// It returns nil when there is no user left to claim, and a userValidationError when the validation of the user failed
+func (dm *gameResultDAO) validateNextUser(ctx context.Context, run *entity.ValidationRun, excludedUserIds []uuid.UUID, totalGamesToCancel int) (*userValidation, error) {
+	var user *entity.User
+	var validation *userValidation
+
+	// Perform the whole operation inside a db transaction
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		var err error
+
+		// No other processes can update or claim the user until end of this transaction
+		user, err = dm.querier.ClaimUserForValidation(ctx, *txn, excludedUserIds)
+		if err != nil {
+			return fmt.Errorf("claiming user for validation: %w", err)
+		}
+		if user == nil {
+			return nil
+		}
+
+		if run.ID == 0 {
+			if err := dm.startValidationRun(ctx, run); err != nil {
+				return err
+			}
+		}
+
+		validation, err = dm.validateUserGameResults(ctx, txn, run.ID, *user, totalGamesToCancel)
+		if err != nil {
+			return &userValidationError{userId: user.ID, err: err}
 		}
 
 		// Commit the transaction
@@ -191,30 +691,133 @@ func (dm *gameResultDAO) validateUserGameResults(ctx context.Context, user entit
 		return nil
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
 	}
 
-	log.Printf("%d game results cancelled for user %s.", totalTransactionsCanceled, user.ID)
-	return nil
+	slog.InfoContext(ctx, "validated user", "user_id", user.ID, "validation_run_id", run.ID, "results_canceled", validation.canceled)
+	return validation, nil
 }
 
-// cancelGameResult cancels the game result
-// Calculates the new balance based on the game status
-func (dm *gameResultDAO) cancelGameResult(ctx context.Context, txn *sqlx.Tx, gameResult entity.GameResult, balance *float64) error {
-	if err := dm.querier.UpdateGameResult(ctx, *txn, gameResult.ID, entity.ValidationStatusCanceled); err != nil {
-		return fmt.Errorf("updating game result to canceled: %w", err)
+// validateUserGameResults validates the game results of a user claimed in the transaction
+// It returns the tally of the validation
+func (dm *gameResultDAO) validateUserGameResults(ctx context.Context, txn *sqlx.Tx, runId int, user entity.User, totalGamesToCancel int) (*userValidation, error) {
+	validation := userValidation{}
+
+	// Read the game results only once the user is claimed,
+	// so game results recorded meanwhile are not missed
+	gameResults, err := dm.querier.SelectGameResultsByUser(ctx, user.ID, entity.ValidationStatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("selecting game results by user: %w", err)
 	}
 
-	if gameResult.GameStatus == entity.GameStatusWin {
-		*balance -= gameResult.Amount
-	} else {
-		*balance += gameResult.Amount
+	decisions, balance := dm.decideGameResults(user, gameResults, totalGamesToCancel)
+
+	for _, decision := range decisions {
+		if decision.Decision == entity.ValidationStatusCanceled {
+			if err := dm.cancelGameResult(ctx, txn, decision.GameResultID); err != nil {
+				return nil, fmt.Errorf("canceling game result: %w", err)
+			}
+			validation.canceled++
+		} else {
+			if err := dm.approveGameResult(ctx, txn, decision.GameResultID); err != nil {
+				return nil, fmt.Errorf("approving game result: %w", err)
+			}
+			validation.approved++
+		}
+
+		// Audit the decision along with the game result update
+		decision.ValidationRunID = runId
+		if err := dm.recordValidationDecision(ctx, txn, decision); err != nil {
+			return nil, err
+		}
+		validation.decisions = append(validation.decisions, decision)
+	}
+
+	// Reset the user balance
+	if err := dm.querier.UpdateUserBalance(ctx, *txn, user.ID, balance, true); err != nil {
+		return nil, fmt.Errorf("updating user balance: %w", err)
+	}
+
+	validation.balanceDelta = balance - user.Balance
+	return &validation, nil
+}
+
+// decideGameResults decides on the pending game results of a user, without applying anything
+// The game results come the newest first, so the newest ones are canceled first
+// It returns the decision on each game result, in order, and the balance of the user once they are applied
+func (dm *gameResultDAO) decideGameResults(user entity.User, gameResults []entity.GameResult, totalGamesToCancel int) ([]entity.ValidationDecision, entity.Money) {
+	decisions := make([]entity.ValidationDecision, 0, len(gameResults))
+	balance := user.Balance
+	canceled := 0
+
+	// The game results recorded before each one are the following ones, the policies get them the oldest first
+	chronological := slices.Clone(gameResults)
+	slices.Reverse(chronological)
+
+	// Check all the game results, until:
+	// - All the transactions to cancel have been canceled, based on the limit (totalGamesToCancel)
+	//   and on the cancellation policy of their transaction source
+	// - All the rest of the transactions have been approved
+	for i, gameResult := range gameResults {
+		candidate := CancellationCandidate{User: user, GameResult: gameResult, Previous: slices.Clone(chronological[:len(gameResults)-1-i])}
+		policy := dm.cancellationPolicies.For(gameResult.TransactionSource)
+
+		decision := entity.ValidationDecision{
+			GameResultID:  gameResult.ID,
+			Decision:      entity.ValidationStatusAccepted,
+			Policy:        policy.Name(),
+			Reason:        entity.ValidationReasonAcceptedByPolicy,
+			BalanceBefore: balance,
+		}
+
+		if policy.ShouldCancel(candidate) {
+			if canceled < totalGamesToCancel {
+				decision.Decision = entity.ValidationStatusCanceled
+				decision.Reason = entity.ValidationReasonCanceledByPolicy
+			} else {
+				decision.Reason = entity.ValidationReasonCancellationLimitReached
+			}
+		}
+
+		// Canceling reverts the game result on the balance
+		if decision.Decision == entity.ValidationStatusCanceled {
+			if gameResult.GameStatus == entity.GameStatusWin {
+				balance -= gameResult.Amount
+			} else {
+				balance += gameResult.Amount
+			}
+			canceled++
+		}
+
+		decision.BalanceAfter = balance
+		decisions = append(decisions, decision)
 	}
 
+	return decisions, balance
+}
+
+// cancelGameResult cancels the game result
+func (dm *gameResultDAO) cancelGameResult(ctx context.Context, txn *sqlx.Tx, gameResultId int) error {
+	if err := dm.querier.UpdateGameResult(ctx, *txn, gameResultId, entity.ValidationStatusCanceled); err != nil {
+		return fmt.Errorf("updating game result to canceled: %w", err)
+	}
 	return nil
 }
 
 // approveGameResult approves the game result
-func (dm *gameResultDAO) approveGameResult(ctx context.Context, txn *sqlx.Tx, gameResult entity.GameResult) error {
-	return dm.querier.UpdateGameResult(ctx, *txn, gameResult.ID, entity.ValidationStatusAccepted)
+func (dm *gameResultDAO) approveGameResult(ctx context.Context, txn *sqlx.Tx, gameResultId int) error {
+	return dm.querier.UpdateGameResult(ctx, *txn, gameResultId, entity.ValidationStatusAccepted)
+}
+
+// recordValidationDecision records the validation decision of a game result, within the transaction updating it
+func (dm *gameResultDAO) recordValidationDecision(ctx context.Context, txn *sqlx.Tx, decision entity.ValidationDecision) error {
+	decision.CreatedAt = time.Now()
+
+	if _, err := dm.querier.InsertValidationDecision(ctx, *txn, decision); err != nil {
+		return fmt.Errorf("recording validation decision: %w", err)
+	}
+	return nil
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestCreateGameResultSequenceOnMock(t *testing.T) {
@@ -22,13 +24,12 @@ func TestCreateGameResultSequenceOnMock(t *testing.T) {
 
 	userId := uuid.New()
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock successful interactions
 	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
 	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil)
 	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
@@ -43,7 +44,7 @@ func TestCreateGameResultSequenceOnMock(t *testing.T) {
 	// Inject many game results
 	toInjectTotalEntries := [1000]int{} //nolint:all
 	totalInjected := len(toInjectTotalEntries)
-	expectedBalance := amount * float64(totalInjected)
+	expectedBalance := amount * entity.Money(totalInjected)
 
 	for range toInjectTotalEntries {
 		transactionID = uuid.New().String()
@@ -58,6 +59,7 @@ func TestCreateGameResultSequenceOnMock(t *testing.T) {
 	assert.Equal(t, mockQuerier.GameCount(), totalInjected)
 
 	// Compare the use balance
+	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
 	user, err := mockQuerier.SelectUser(ctx, userId)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBalance, user.Balance)
@@ -71,12 +73,11 @@ func TestCreateGameResultConcurrentOnMock(t *testing.T) {
 
 	userId := uuid.New()
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 
 	// Mock successful interactions
 	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
 	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil)
 	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
@@ -91,7 +92,7 @@ func TestCreateGameResultConcurrentOnMock(t *testing.T) {
 	// Inject many game results
 	toInjectTotalEntries := [1000]int{} //nolint:all
 	totalInjected := len(toInjectTotalEntries)
-	expectedBalance := amount * float64(totalInjected)
+	expectedBalance := amount * entity.Money(totalInjected)
 
 	wg := sync.WaitGroup{}
 	for range toInjectTotalEntries {
@@ -115,6 +116,7 @@ func TestCreateGameResultConcurrentOnMock(t *testing.T) {
 	assert.Equal(t, mockQuerier.GameCount(), totalInjected)
 
 	// Compare the use balance
+	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
 	user, err := mockQuerier.SelectUser(ctx, userId)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBalance, user.Balance)
@@ -127,12 +129,11 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 	instance := NewGameResultDAO(mockQuerier)
 
 	userId := uuid.New()
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 
 	// Mock successful interactions
 	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
 	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil)
 	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
@@ -143,7 +144,7 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 
 		// Must start with some balance, unless the user will have a negative balance for the first
 		// entity.GameStatusLost hit
-		mockQuerier.UpdateUserBalance(ctx, *txn, userId, 1000, false)
+		mockQuerier.UpdateUserBalance(ctx, *txn, userId, 1000_00, false)
 		return nil
 	})
 
@@ -152,7 +153,7 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 	totalInjected := len(toInjectTotalEntries)
 
 	// To equalize the balance
-	expectedBalance := float64(1000)
+	expectedBalance := entity.Money(1000_00)
 
 	wg := sync.WaitGroup{}
 	for range toInjectTotalEntries {
@@ -188,6 +189,200 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 	assert.Equal(t, mockQuerier.GameCount(), totalInjected*2)
 
 	// Compare the use balance
+	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
+	user, err := mockQuerier.SelectUser(ctx, userId)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBalance, user.Balance)
+}
+
+func TestCreateGameResultConcurrentManyUsersOnMock(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+	ctx := context.Background()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	amount := entity.Money(100_00)
+	transactionSource := entity.TransactionSourceGame
+
+	// Mock successful interactions
+	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
+	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, mock.Anything, mock.Anything, false).Return(nil)
+
+	// Give to the mock many users with a balance of 1000
+	userIds := make([]uuid.UUID, 10)
+	for i := range userIds {
+		userIds[i] = uuid.New()
+		mockQuerier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			mockQuerier.UpdateUserBalance(ctx, *txn, userIds[i], 1000_00, false)
+			return nil
+		})
+	}
+
+	// Inject many game results for every user, all at once
+	toInjectTotalEntries := [50]int{} //nolint:all
+	totalInjected := len(toInjectTotalEntries)
+
+	wg := sync.WaitGroup{}
+	for _, userId := range userIds {
+		for range toInjectTotalEntries {
+			for _, gameStatus := range []entity.GameStatus{entity.GameStatusWin, entity.GameStatusLost} {
+				wg.Add(1)
+
+				// A go routine for each game result
+				go func() {
+					defer wg.Done()
+					_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, uuid.New().String())
+					assert.NoError(t, err)
+				}()
+			}
+		}
+	}
+
+	// Wait for all workers to complete processing
+	wg.Wait()
+
+	// Basic mockers expectations check
+	mockQuerier.AssertExpectations(t)
+
+	// Count the game results
+	assert.Equal(t, mockQuerier.GameCount(), totalInjected*2*len(userIds))
+
+	// Compare the users balance, wins and losses are equalized
+	mockQuerier.On("SelectUser", ctx, mock.Anything).Return() // no fake results
+	for _, userId := range userIds {
+		user, err := mockQuerier.SelectUser(ctx, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Money(1000_00), user.Balance)
+	}
+}
+
+func TestCreateGameResultOtherUsersNotBlockedOnMock(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+	ctx := context.Background()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	lockedUserId := uuid.New()
+	otherUserId := uuid.New()
+
+	// Mock successful interactions
+	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, mock.Anything).Return(nil)
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
+	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, mock.Anything, mock.Anything, false).Return(nil)
+
+	// Give to the mock two users with a balance of 0
+	for _, userId := range []uuid.UUID{lockedUserId, otherUserId} {
+		mockQuerier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			mockQuerier.UpdateUserBalance(ctx, *txn, userId, 0, false)
+			return nil
+		})
+	}
+
+	// Hold the row of the first user in a long running transaction
+	locked := make(chan struct{})
+	release := make(chan struct{})
+	go mockQuerier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		mockQuerier.LockUserRow(ctx, *txn, lockedUserId)
+		close(locked)
+		<-release
+		return nil
+	})
+	<-locked
+
+	// The game results of the first user must wait for the row to be released
+	lockedDone := make(chan struct{})
+	go func() {
+		defer close(lockedDone)
+		_, err := instance.CreateGameResult(ctx, lockedUserId, entity.GameStatusWin, entity.Money(100_00), entity.TransactionSourceGame, uuid.New().String())
+		assert.NoError(t, err)
+	}()
+
+	// The game results of other users are not blocked meanwhile
+	_, err := instance.CreateGameResult(ctx, otherUserId, entity.GameStatusWin, entity.Money(100_00), entity.TransactionSourceGame, uuid.New().String())
+	assert.NoError(t, err)
+
+	select {
+	case <-lockedDone:
+		t.Fatal("game result of a locked user should wait for the row lock")
+	default:
+	}
+
+	close(release)
+	<-lockedDone
+
+	// Basic mockers expectations check
+	mockQuerier.AssertExpectations(t)
+	assert.Equal(t, mockQuerier.GameCount(), 2)
+}
+
+func TestCreateGameResultsBatchConcurrentOnMock(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+	ctx := context.Background()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	userId := uuid.New()
+	amount := entity.Money(100_00)
+	transactionSource := entity.TransactionSourceGame
+
+	// Mock successful interactions
+	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil)
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
+	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userId, mock.Anything, false).Return(nil)
+
+	// Give to the mock a user with a balance of 0
+	mockQuerier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		mockQuerier.UpdateUserBalance(ctx, *txn, userId, 0, false)
+		return nil
+	})
+
+	// Inject many batches and single game results for the same user, all at once
+	toInjectTotalEntries := [100]int{} //nolint:all
+	totalInjected := len(toInjectTotalEntries)
+	expectedBalance := amount * entity.Money(totalInjected*3)
+
+	wg := sync.WaitGroup{}
+	for range toInjectTotalEntries {
+		wg.Add(2)
+
+		// A go routine for each batch of two game results
+		go func() {
+			defer wg.Done()
+			outcomes := instance.CreateGameResults(ctx, transactionSource, []entity.GameResultSubmission{
+				{UserID: userId, GameStatus: entity.GameStatusWin, Amount: amount, TransactionID: uuid.New().String()},
+				{UserID: userId, GameStatus: entity.GameStatusWin, Amount: amount, TransactionID: uuid.New().String()},
+			})
+			for _, outcome := range outcomes {
+				assert.Equal(t, entity.BatchItemStatusCreated, outcome.Status)
+			}
+		}()
+
+		// A go routine for each single game result
+		go func() {
+			defer wg.Done()
+			_, err := instance.CreateGameResult(ctx, userId, entity.GameStatusWin, amount, transactionSource, uuid.New().String())
+			assert.NoError(t, err)
+		}()
+	}
+
+	// Wait for all workers to complete processing
+	wg.Wait()
+
+	// Basic mockers expectations check
+	mockQuerier.AssertExpectations(t)
+
+	// Count the game results
+	assert.Equal(t, mockQuerier.GameCount(), totalInjected*3)
+
+	// Compare the use balance
+	mockQuerier.On("SelectUser", ctx, userId).Return() // no fake results
 	user, err := mockQuerier.SelectUser(ctx, userId)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBalance, user.Balance)
@@ -212,7 +407,7 @@ func TestCreateGameResultSequenceOnDB(t *testing.T) {
 
 	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 
 	// Give to the mock a user with a balance of 0
@@ -224,7 +419,7 @@ func TestCreateGameResultSequenceOnDB(t *testing.T) {
 	// Inject many game results
 	toInjectTotalEntries := [100]int{} //nolint:all
 	totalInjected := len(toInjectTotalEntries)
-	expectedBalance := amount * float64(totalInjected)
+	expectedBalance := amount * entity.Money(totalInjected)
 
 	for range toInjectTotalEntries {
 		_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, uuid.New().String())
@@ -250,7 +445,7 @@ func TestCreateGameResultConcurrentOnDB(t *testing.T) {
 
 	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 
 	// Give to the mock a user with a balance of 0
@@ -262,7 +457,7 @@ func TestCreateGameResultConcurrentOnDB(t *testing.T) {
 	// Inject many game results
 	toInjectTotalEntries := [100]int{} //nolint:all
 	totalInjected := len(toInjectTotalEntries)
-	expectedBalance := amount * float64(totalInjected)
+	expectedBalance := amount * entity.Money(totalInjected)
 
 	wg := sync.WaitGroup{}
 	for range toInjectTotalEntries {
@@ -297,7 +492,7 @@ func TestCreateGameResultConcurrentWinLostOnDB(t *testing.T) {
 	instance := NewGameResultDAO(querier)
 
 	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 
 	// Give to the mock a user with a balance of 1000
@@ -305,7 +500,7 @@ func TestCreateGameResultConcurrentWinLostOnDB(t *testing.T) {
 
 		// Must start with some balance, unless the user will have a negative balance for the first
 		// entity.GameStatusLost hit
-		querier.UpdateUserBalance(ctx, *txn, userId, 1000, false)
+		querier.UpdateUserBalance(ctx, *txn, userId, 1000_00, false)
 		return nil
 	})
 
@@ -314,7 +509,7 @@ func TestCreateGameResultConcurrentWinLostOnDB(t *testing.T) {
 	totalInjected := len(toInjectTotalEntries)
 
 	// To equalize the balance
-	expectedBalance := float64(1000)
+	expectedBalance := entity.Money(1000_00)
 
 	wg := sync.WaitGroup{}
 	for range toInjectTotalEntries {
@@ -353,3 +548,167 @@ func TestCreateGameResultConcurrentWinLostOnDB(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBalance, user.Balance)
 }
+
+func TestCreateGameResultConcurrentManyUsersOnDB(t *testing.T) {
+	ctx, teardownTest, querier := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	instance := NewGameResultDAO(querier)
+
+	amount := entity.Money(100_00)
+	transactionSource := entity.TransactionSourceGame
+
+	// Create many users with a balance of 1000
+	userIds := make([]uuid.UUID, 10)
+	for i := range userIds {
+		userId, err := querier.InsertUser(ctx, entity.User{
+			Email:     uuid.New().String() + "@example.com",
+			Balance:   1000_00,
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+		userIds[i] = userId
+	}
+
+	// Inject many game results for every user, all at once
+	toInjectTotalEntries := [20]int{} //nolint:all
+	totalInjected := len(toInjectTotalEntries)
+
+	wg := sync.WaitGroup{}
+	for _, userId := range userIds {
+		for range toInjectTotalEntries {
+			for _, gameStatus := range []entity.GameStatus{entity.GameStatusWin, entity.GameStatusLost} {
+				wg.Add(1)
+
+				// A go routine for each game result
+				go func() {
+					defer wg.Done()
+					_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, uuid.New().String())
+					assert.NoError(t, err)
+				}()
+			}
+		}
+	}
+
+	// Wait for all workers to complete processing
+	wg.Wait()
+
+	// Wins and losses are equalized for every user
+	for _, userId := range userIds {
+		games, err := querier.SelectGameResultsByUser(ctx, userId, entity.ValidationStatusPending)
+		assert.NoError(t, err)
+		assert.Len(t, games, totalInjected*2)
+
+		user, err := querier.SelectUser(ctx, userId)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Money(1000_00), user.Balance)
+	}
+}
+
+func TestCreateGameResultsBatchConcurrentOnDB(t *testing.T) {
+	ctx, teardownTest, querier := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	instance := NewGameResultDAO(querier)
+
+	userId, _ := uuid.Parse("11111111-1111-1111-1111-111111111111")
+	amount := entity.Money(100_00)
+	transactionSource := entity.TransactionSourceGame
+
+	// Give to the user a balance of 0
+	querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		querier.UpdateUserBalance(ctx, *txn, userId, 0, false)
+		return nil
+	})
+
+	// Inject many batches and single game results for the same user, all at once
+	toInjectTotalEntries := [50]int{} //nolint:all
+	totalInjected := len(toInjectTotalEntries)
+	expectedBalance := amount * entity.Money(totalInjected*3)
+
+	wg := sync.WaitGroup{}
+	for range toInjectTotalEntries {
+		wg.Add(2)
+
+		// A go routine for each batch of two game results
+		go func() {
+			defer wg.Done()
+			outcomes := instance.CreateGameResults(ctx, transactionSource, []entity.GameResultSubmission{
+				{UserID: userId, GameStatus: entity.GameStatusWin, Amount: amount, TransactionID: uuid.New().String()},
+				{UserID: userId, GameStatus: entity.GameStatusWin, Amount: amount, TransactionID: uuid.New().String()},
+			})
+			for _, outcome := range outcomes {
+				assert.Equal(t, entity.BatchItemStatusCreated, outcome.Status)
+			}
+		}()
+
+		// A go routine for each single game result
+		go func() {
+			defer wg.Done()
+			_, err := instance.CreateGameResult(ctx, userId, entity.GameStatusWin, amount, transactionSource, uuid.New().String())
+			assert.NoError(t, err)
+		}()
+	}
+
+	// Wait for all workers to complete processing
+	wg.Wait()
+
+	// Count the game results
+	games, err := querier.SelectGameResultsByUser(ctx, userId, entity.ValidationStatusPending)
+	assert.NoError(t, err)
+	assert.Len(t, games, totalInjected*3)
+
+	// Compare the use balance
+	user, err := querier.SelectUser(ctx, userId)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBalance, user.Balance)
+}
+
+func TestValidateGameResultsConcurrentValidatorsOnDB(t *testing.T) {
+	ctx, teardownTest, querier := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	// Many validators running side by side, each one with small batches
+	instances := make([]*gameResultDAO, 3)
+	for i := range instances {
+		instances[i] = NewGameResultDAO(querier)
+		instances[i].WithValidationBatchSize(1)
+	}
+
+	pending, err := querier.SelectUsersByValidationStatus(ctx, false)
+	require.NoError(t, err)
+	require.NotEmpty(t, pending)
+
+	// Every validator runs rounds until there is no user left to claim
+	var totalUsers int64
+	wg := sync.WaitGroup{}
+	for _, instance := range instances {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			for {
+				validationRun, err := instance.ValidateGameResults(ctx, 10)
+				assert.NoError(t, err)
+				if err != nil || validationRun.UsersProcessed == 0 {
+					return
+				}
+				atomic.AddInt64(&totalUsers, int64(validationRun.UsersProcessed))
+			}
+		}()
+	}
+
+	// Wait for all validators to complete processing
+	wg.Wait()
+
+	// Every user is validated exactly once
+	assert.Equal(t, int64(len(pending)), totalUsers)
+	remaining, err := querier.SelectUsersByValidationStatus(ctx, false)
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	// Every round with a user validated is recorded
+	validationRuns, err := querier.SelectValidationRunsByFilter(ctx, entity.ValidationRunFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, validationRuns, len(pending))
+}
@@ -5,14 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"testing"
 
 	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/logging"
+	"github.com/ildomm/cceab/metrics"
 	"github.com/ildomm/cceab/test_helpers"
 )
 
+// observations returns the number of values observed by the histogram
+func observations(t *testing.T, observer prometheus.Observer) uint64 {
+	histogram := &dto.Metric{}
+	require.NoError(t, observer.(prometheus.Metric).Write(histogram))
+	return histogram.GetHistogram().GetSampleCount()
+}
+
 func TestCreateGameResultSuccess(t *testing.T) {
 	mockQuerier := test_helpers.NewMockQuerier()
 
@@ -21,24 +34,27 @@ func TestCreateGameResultSuccess(t *testing.T) {
 	ctx := context.TODO()
 	userId := uuid.New()
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock successful interactions
 	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return(&entity.User{
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
 		ID:      userId,
-		Balance: 200.0,
+		Balance: 200_00,
 	}, nil)
-	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil)
 	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(uuid.New(), nil)
 	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userId, mock.Anything, false).Return(nil)
 
+	recorded := metrics.GameResults.WithLabelValues(string(gameStatus), string(transactionSource))
+	before := testutil.ToFloat64(recorded)
+
 	_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
 
 	assert.NoError(t, err, "CreateGameResult should not return an error")
+	assert.Equal(t, before+1, testutil.ToFloat64(recorded), "The game result should be counted")
 	mockQuerier.AssertExpectations(t)
 
 	// Count the game results
@@ -56,16 +72,114 @@ func TestCreateGameResultTransactionIDExists(t *testing.T) {
 	ctx := context.TODO()
 	userId := uuid.New()
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "existing-transaction-id"
 
-	// Mock transaction ID already exists
+	// Mock transaction ID already exists, but the game result is gone
 	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(true, nil)
+	mockQuerier.On("SelectGameResultByTransactionID", ctx, transactionID).Return(nil, nil)
+
+	rejected := metrics.GameResultRejections.WithLabelValues(metrics.RejectionTransactionIdExists, string(transactionSource))
+	before := testutil.ToFloat64(rejected)
 
 	_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
 
 	assert.EqualError(t, err, entity.ErrTransactionIdExists.Error(), "CreateGameResult should return ErrTransactionIdExists")
+	assert.Equal(t, before+1, testutil.ToFloat64(rejected), "The rejection should be counted")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateGameResultTransactionIDReplayed(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	transactionID := "existing-transaction-id"
+	recorded := &entity.GameResult{
+		ID:                42,
+		UserID:            userId,
+		GameStatus:        entity.GameStatusWin,
+		ValidationStatus:  entity.ValidationStatusAccepted,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     transactionID,
+		Amount:            100_00,
+	}
+
+	// Mock transaction ID already recorded with the same payload
+	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(true, nil)
+	mockQuerier.On("SelectGameResultByTransactionID", ctx, transactionID).Return(recorded, nil)
+
+	gameResult, err := instance.CreateGameResult(ctx, userId, entity.GameStatusWin, entity.Money(100_00), entity.TransactionSourceGame, transactionID)
+
+	assert.ErrorIs(t, err, entity.ErrTransactionIdReplayed, "CreateGameResult should return ErrTransactionIdReplayed")
+	assert.Equal(t, recorded, gameResult, "CreateGameResult should return the recorded game result")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateGameResultTransactionIDConflict(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	transactionID := "existing-transaction-id"
+	recorded := &entity.GameResult{
+		ID:                42,
+		UserID:            userId,
+		GameStatus:        entity.GameStatusWin,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     transactionID,
+		Amount:            100_00,
+	}
+
+	// Mock transaction ID already recorded with another amount
+	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(true, nil)
+	mockQuerier.On("SelectGameResultByTransactionID", ctx, transactionID).Return(recorded, nil)
+
+	gameResult, err := instance.CreateGameResult(ctx, userId, entity.GameStatusWin, entity.Money(90_00), entity.TransactionSourceGame, transactionID)
+
+	assert.ErrorIs(t, err, entity.ErrTransactionIdConflict, "CreateGameResult should return ErrTransactionIdConflict")
+	assert.ErrorIs(t, err, entity.ErrTransactionIdExists, "ErrTransactionIdConflict should be an ErrTransactionIdExists")
+	assert.Equal(t, recorded, gameResult, "CreateGameResult should return the recorded game result")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateGameResultTransactionIDRecordedConcurrently(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	transactionID := "concurrent-transaction-id"
+	recorded := &entity.GameResult{
+		ID:                42,
+		UserID:            userId,
+		GameStatus:        entity.GameStatusWin,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     transactionID,
+		Amount:            100_00,
+	}
+
+	// Mock another request recording the transaction ID between the check and the insert
+	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(false, nil).Once()
+	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(true, nil).Once()
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
+		ID:      userId,
+		Balance: 200_00,
+	}, nil)
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(nil, entity.ErrTransactionIdExists)
+	mockQuerier.On("SelectGameResultByTransactionID", ctx, transactionID).Return(recorded, nil)
+
+	gameResult, err := instance.CreateGameResult(ctx, userId, entity.GameStatusWin, entity.Money(100_00), entity.TransactionSourceGame, transactionID)
+
+	assert.ErrorIs(t, err, entity.ErrTransactionIdReplayed, "CreateGameResult should return ErrTransactionIdReplayed")
+	assert.Equal(t, recorded, gameResult, "CreateGameResult should return the recorded game result")
 	mockQuerier.AssertExpectations(t)
 }
 
@@ -77,13 +191,14 @@ func TestCreateGameResultUserNotFound(t *testing.T) {
 	ctx := context.TODO()
 	userId := uuid.New()
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock user not found
 	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return(nil, entity.ErrUserNotFound)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil, nil)
 
 	_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
 
@@ -91,6 +206,30 @@ func TestCreateGameResultUserNotFound(t *testing.T) {
 	mockQuerier.AssertExpectations(t)
 }
 
+func TestCreateGameResultUserDisabled(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	transactionID := "unique-transaction-id"
+
+	// Mock disabled user
+	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(false, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
+		ID:       userId,
+		Balance:  200_00,
+		Disabled: true,
+	}, nil)
+
+	_, err := instance.CreateGameResult(ctx, userId, entity.GameStatusWin, entity.Money(100_00), entity.TransactionSourceGame, transactionID)
+
+	assert.EqualError(t, err, entity.ErrUserDisabled.Error(), "CreateGameResult should return ErrUserDisabled")
+	mockQuerier.AssertExpectations(t)
+}
+
 func TestCreateGameResultInsufficientBalance(t *testing.T) {
 	mockQuerier := test_helpers.NewMockQuerier()
 
@@ -99,20 +238,25 @@ func TestCreateGameResultInsufficientBalance(t *testing.T) {
 	ctx := context.TODO()
 	userId := uuid.New()
 	gameStatus := entity.GameStatusLost // Assuming this triggers the balance check
-	amount := 300.0                     // Assuming the user's balance is less than this amount
+	amount := entity.Money(300_00)      // Assuming the user's balance is less than this amount
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock user with insufficient balance
 	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return(&entity.User{
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
 		ID:      userId,
-		Balance: 200.0,
+		Balance: 200_00,
 	}, nil)
 
+	rejected := metrics.GameResultRejections.WithLabelValues(metrics.RejectionNegativeBalance, string(transactionSource))
+	before := testutil.ToFloat64(rejected)
+
 	_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
 
 	assert.EqualError(t, err, entity.ErrUserNegativeBalance.Error(), "CreateGameResult should return ErrUserNegativeBalance")
+	assert.Equal(t, before+1, testutil.ToFloat64(rejected), "The rejection should be counted")
 	mockQuerier.AssertExpectations(t)
 }
 
@@ -121,26 +265,29 @@ func TestCreateGameResultDatabaseError(t *testing.T) {
 
 	instance := NewGameResultDAO(mockQuerier)
 
-	ctx := context.TODO()
+	logBuf, restoreLog := test_helpers.CaptureOutput()
+	defer restoreLog()
+
+	ctx := logging.WithRequestID(context.TODO(), "request-1")
 	userId := uuid.New()
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(100_00)
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock successful interactions except for InsertGameResult
 	mockQuerier.On("CheckTransactionID", ctx, transactionID).Return(false, nil)
-	mockQuerier.On("SelectUser", ctx, userId).Return(&entity.User{
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
 		ID:      userId,
-		Balance: 200.0,
+		Balance: 200_00,
 	}, nil)
-	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil)
 	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
 
 	_, err := instance.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
 
 	assert.EqualError(t, err, entity.ErrCreatingGameResult.Error(), "CreateGameResult should return ErrCreatingGameResult")
+	assert.Contains(t, logBuf.String(), `"request_id":"request-1"`, "The error should be logged along with the request ID")
 	mockQuerier.AssertExpectations(t)
 
 	// Count the game results
@@ -157,21 +304,20 @@ func TestValidateGameResultsSuccess(t *testing.T) {
 
 	ctx := context.TODO()
 
-	userID := uuid.New()
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
 
-	// Mock select users with pending validation
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
-		{
-			ID:      userID,
-			Balance: 100.0,
-		},
-	}, nil)
+	userID := uuid.New()
 
-	// Mock lock user row
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(nil)
+	// Mock claim of the user with pending validation, then no user left
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
 
 	// Mock select game results for the user
-	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
 		{
 			ID:                1,
 			UserID:            userID,
@@ -179,22 +325,50 @@ func TestValidateGameResultsSuccess(t *testing.T) {
 			ValidationStatus:  entity.ValidationStatusPending,
 			TransactionSource: entity.TransactionSourceGame,
 			TransactionID:     "tx123",
-			Amount:            50.0,
+			Amount:            50_00,
 		},
 	}, nil)
 
 	// Mock update game result
-	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Return(nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Return(nil)
 
 	// Mock update user balance
-	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userID, mock.Anything, true).Return(nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, userID, mock.Anything, true).Return(nil)
 
 	// Mock transaction operations
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
 
-	err := instance.ValidateGameResults(ctx, 1)
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	// Mock record of the validation decision
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.MatchedBy(func(decision entity.ValidationDecision) bool {
+		return decision.GameResultID == 1 &&
+			decision.Decision == entity.ValidationStatusCanceled &&
+			decision.Policy == OddIDCancellationPolicy{}.Name() &&
+			decision.Reason == entity.ValidationReasonCanceledByPolicy &&
+			decision.BalanceBefore == 100_00 &&
+			decision.BalanceAfter == 50_00 &&
+			decision.ValidationRunID == 1 &&
+			!decision.CreatedAt.IsZero()
+	})).Return(1, nil)
+
+	canceled := metrics.ValidationDecisions.WithLabelValues(string(entity.ValidationStatusCanceled), string(entity.ValidationReasonCanceledByPolicy))
+	canceledBefore := testutil.ToFloat64(canceled)
+	runsBefore := observations(t, metrics.ValidationRunDuration.WithLabelValues(metrics.OutcomeSuccess))
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
 
 	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.Equal(t, 1, validationRun.ID)
+	assert.Equal(t, 1, validationRun.UsersProcessed)
+	assert.Equal(t, 0, validationRun.ResultsApproved)
+	assert.Equal(t, 1, validationRun.ResultsCanceled)
+	assert.Equal(t, entity.Money(-50_00), validationRun.BalanceDelta)
+	assert.False(t, validationRun.Error.Valid)
+	assert.Equal(t, canceledBefore+1, testutil.ToFloat64(canceled), "The cancellation should be counted")
+	assert.Equal(t, runsBefore+1, observations(t, metrics.ValidationRunDuration.WithLabelValues(metrics.OutcomeSuccess)), "The run duration should be observed")
 	mockQuerier.AssertExpectations(t)
 }
 
@@ -205,19 +379,18 @@ func TestValidateGameResultsSuccessOnMultipleEntries(t *testing.T) {
 
 	ctx := context.TODO()
 
-	userID := uuid.New()
-	balanceExpectedAdjustment := 2100.0
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
 
-	// Mock select users with pending validation
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
-		{
-			ID:      userID,
-			Balance: 2500.0, // 50 * 50 = 2500
-		},
-	}, nil)
+	userID := uuid.New()
+	balanceExpectedAdjustment := entity.Money(2100_00)
 
-	// Mock lock user row
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(nil)
+	// Mock claim of the user with pending validation, then no user left
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{
+		ID:      userID,
+		Balance: 2500_00,
+	}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
 
 	// Populate list of game results
 	totalEntries := 50
@@ -232,7 +405,7 @@ func TestValidateGameResultsSuccessOnMultipleEntries(t *testing.T) {
 		ValidationStatus:  entity.ValidationStatusPending,
 		TransactionSource: entity.TransactionSourceGame,
 		TransactionID:     fmt.Sprintf("tx%d", 1),
-		Amount:            50.0,
+		Amount:            50_00,
 	}
 	games = append(games, game)
 
@@ -245,44 +418,248 @@ func TestValidateGameResultsSuccessOnMultipleEntries(t *testing.T) {
 			ValidationStatus:  entity.ValidationStatusPending,
 			TransactionSource: entity.TransactionSourceGame,
 			TransactionID:     fmt.Sprintf("tx%d", i+2),
-			Amount:            50.0,
+			Amount:            50_00,
 		}
 
 		games = append(games, game)
 	}
 
 	// Mock select game results for the user
-	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return(games, nil)
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return(games, nil)
 
 	// Mock update game result
-	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, mock.Anything, entity.ValidationStatusAccepted).Times((totalEntries + 1) - totalGamesToCancel).Return(nil)
-	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Times(totalGamesToCancel).Return(nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, mock.Anything, entity.ValidationStatusAccepted).Times((totalEntries + 1) - totalGamesToCancel).Return(nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Times(totalGamesToCancel).Return(nil)
 
 	// Mock update user balance
-	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userID, balanceExpectedAdjustment, true).Return(nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, userID, balanceExpectedAdjustment, true).Return(nil)
 
 	// Mock transaction operations
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
 
-	err := instance.ValidateGameResults(ctx, totalGamesToCancel)
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	// Mock record of the validation decisions, the odd IDs 21 to 51 are past the limit
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.MatchedBy(func(decision entity.ValidationDecision) bool {
+		return decision.Reason == entity.ValidationReasonCancellationLimitReached && decision.Decision == entity.ValidationStatusAccepted
+	})).Times(16).Return(1, nil)
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.Anything).Times((totalEntries+1)-16).Return(1, nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, totalGamesToCancel)
 
 	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.Equal(t, (totalEntries+1)-totalGamesToCancel, validationRun.ResultsApproved)
+	assert.Equal(t, totalGamesToCancel, validationRun.ResultsCanceled)
+	assert.Equal(t, balanceExpectedAdjustment-2500_00, validationRun.BalanceDelta)
 	mockQuerier.AssertExpectations(t)
 }
 
-func TestValidateGameResultsSelectUsersError(t *testing.T) {
+func TestValidateGameResultsWithSourcePolicies(t *testing.T) {
 	mockQuerier := test_helpers.NewMockQuerier()
 
 	instance := NewGameResultDAO(mockQuerier)
+	instance.WithCancellationPolicies(NewCancellationPolicies(OddIDCancellationPolicy{}).
+		WithSourcePolicy(entity.TransactionSourcePayment, NoCancellationPolicy{}))
 
 	ctx := context.TODO()
 
-	// Mock select users with pending validation error
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return(nil, errors.New("database error"))
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
 
-	err := instance.ValidateGameResults(ctx, 1)
+	userID := uuid.New()
 
-	assert.Error(t, err, "ValidateGameResults should return an error on SelectUsersByValidationStatus")
+	// Mock claim of the user with pending validation, then no user left
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+
+	// Odd IDs on both sources, only the game one is canceled
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+		{ID: 1, UserID: userID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 30_00},
+		{ID: 3, UserID: userID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourcePayment, Amount: 20_00},
+	}, nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, 1, entity.ValidationStatusCanceled).Return(nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, 3, entity.ValidationStatusAccepted).Return(nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, userID, entity.Money(70_00), true).Return(nil)
+
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	// Mock record of the validation decisions, naming the policy of each source
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.MatchedBy(func(decision entity.ValidationDecision) bool {
+		return decision.GameResultID == 1 && decision.Policy == "odd_id" && decision.Reason == entity.ValidationReasonCanceledByPolicy &&
+			decision.BalanceBefore == 100_00 && decision.BalanceAfter == 70_00
+	})).Return(1, nil)
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.MatchedBy(func(decision entity.ValidationDecision) bool {
+		return decision.GameResultID == 3 && decision.Policy == "none" && decision.Reason == entity.ValidationReasonAcceptedByPolicy &&
+			decision.BalanceBefore == 70_00 && decision.BalanceAfter == 70_00
+	})).Return(1, nil)
+
+	_, err := instance.ValidateGameResults(ctx, 10)
+
+	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	mockQuerier.AssertExpectations(t)
+}
+
+// previousRecordingPolicy never cancels, recording the IDs of the previous game results of each candidate
+type previousRecordingPolicy struct {
+	previous map[int][]int
+}
+
+func (p previousRecordingPolicy) Name() string {
+	return "previous_recording"
+}
+
+func (p previousRecordingPolicy) ShouldCancel(candidate CancellationCandidate) bool {
+	ids := []int{}
+	for _, gameResult := range candidate.Previous {
+		ids = append(ids, gameResult.ID)
+	}
+	p.previous[candidate.GameResult.ID] = ids
+
+	// Tampering with the previous game results must not affect the other candidates
+	for i := range candidate.Previous {
+		candidate.Previous[i].ID = 0
+	}
+	return false
+}
+
+func TestValidateGameResultsPreviousGameResults(t *testing.T) {
+	policy := previousRecordingPolicy{previous: map[int][]int{}}
+
+	instance := NewGameResultDAO(test_helpers.NewMockQuerier())
+	instance.WithCancellationPolicies(NewCancellationPolicies(policy))
+
+	// The pending game results come the newest first
+	gameResults := []entity.GameResult{{ID: 3}, {ID: 2}, {ID: 1}}
+
+	decisions, _ := instance.decideGameResults(entity.User{}, gameResults, DefaultTotalGamesToCancel)
+
+	require.Len(t, decisions, 3)
+	assert.Equal(t, []int{3, 2, 1}, []int{decisions[0].GameResultID, decisions[1].GameResultID, decisions[2].GameResultID})
+	assert.Equal(t, map[int][]int{1: {}, 2: {1}, 3: {1, 2}}, policy.previous, "the previous game results should be the older ones, the oldest first")
+	assert.Equal(t, []entity.GameResult{{ID: 3}, {ID: 2}, {ID: 1}}, gameResults, "the game results should be left untouched")
+}
+
+func TestValidateGameResultsFailingUserAheadOfHealthyUser(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	failingUserID := uuid.New()
+	healthyUserID := uuid.New()
+
+	// The failing user waits the longest, it is not claimed again once failed
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, []uuid.UUID(nil)).Return(&entity.User{ID: failingUserID}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, []uuid.UUID{failingUserID}).Return(&entity.User{ID: healthyUserID}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, []uuid.UUID{failingUserID}).Return(nil, nil).Once()
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, mock.Anything, entity.ValidationStatusPending).Return([]entity.GameResult{}, nil)
+
+	// The balance of the failing user breaks the check constraint
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, failingUserID, entity.Money(0), true).
+		Return(errors.New(`new row for relation "users" violates check constraint "users_balance_check"`))
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, healthyUserID, entity.Money(0), true).Return(nil)
+
+	// Mock record of the validation run, along with the failure
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.MatchedBy(func(run entity.ValidationRun) bool {
+		return run.ID == 1 && run.UsersProcessed == 1 && run.Error.String == entity.ErrValidationFailed.Error()
+	})).Return(nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.ErrorContains(t, err, failingUserID.String(), "The failure of the user should be returned")
+	assert.Equal(t, 1, validationRun.UsersProcessed, "The healthy user should be validated past the failing one")
+	mockQuerier.AssertNumberOfCalls(t, "ClaimUserForValidation", 3)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsClaimUserError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	// Mock claim of the user with pending validation error
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.Error(t, err, "ValidateGameResults should return an error on ClaimUserForValidation")
+	assert.Equal(t, 0, validationRun.UsersProcessed)
+	assert.Equal(t, entity.ErrValidationFailed.Error(), validationRun.Error.String, "The failure should be recorded along the validation run, without the error message")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsNoUserToClaim(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	// Mock no user with pending validation, or all claimed by other validators
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.Equal(t, 0, validationRun.UsersProcessed)
+	mockQuerier.AssertNotCalled(t, "SelectGameResultsByUser", mock.Anything, mock.Anything, mock.Anything)
+	mockQuerier.AssertNotCalled(t, "InsertValidationRun", mock.Anything, mock.Anything)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsBatchSize(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+	instance.WithValidationBatchSize(2)
+
+	ctx := context.TODO()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	// More users waiting than the batch size, only the batch is claimed
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{ID: uuid.New()}, nil).Times(2)
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, mock.Anything, entity.ValidationStatusPending).Return([]entity.GameResult{}, nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, mock.Anything, entity.Money(0), true).Return(nil)
+
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.Equal(t, 2, validationRun.UsersProcessed)
+	mockQuerier.AssertNumberOfCalls(t, "ClaimUserForValidation", 2)
 	mockQuerier.AssertExpectations(t)
 }
 
@@ -293,71 +670,141 @@ func TestValidateGameResultsSelectGameResultsError(t *testing.T) {
 
 	ctx := context.TODO()
 
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
 	userID := uuid.New()
 
-	// Mock select users with pending validation
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
-		{
-			ID:      userID,
-			Balance: 100.0,
-		},
-	}, nil)
+	// Mock claim of the user with pending validation, then no user left
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
+
+	// Mock lock user row
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
 
 	// Mock select game results for the user error
-	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return(nil, errors.New("database error"))
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return(nil, errors.New("database error"))
 
-	err := instance.ValidateGameResults(ctx, 1)
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	_, err := instance.ValidateGameResults(ctx, 1)
 
 	assert.Error(t, err, "ValidateGameResults should return an error on SelectGameResultsByUser")
 	mockQuerier.AssertExpectations(t)
 }
 
-func TestValidateGameResultsUserLockError(t *testing.T) {
+func TestValidateGameResultsStartRunError(t *testing.T) {
 	mockQuerier := test_helpers.NewMockQuerier()
 
 	instance := NewGameResultDAO(mockQuerier)
 
 	ctx := context.TODO()
 
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
 	userID := uuid.New()
 
-	// Mock select users with pending validation
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
-		{
-			ID:      userID,
-			Balance: 100.0,
-		},
-	}, nil)
-	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{{}}, nil)
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{ID: userID}, nil).Once()
 
-	// Mock lock user row error
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(errors.New("locking error"))
+	// Mock record of the validation run error
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(0, errors.New("database error"))
 
-	err := instance.ValidateGameResults(ctx, 1)
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
 
-	assert.Error(t, err, "ValidateGameResults should return an error on LockUserRow")
+	assert.Error(t, err, "ValidateGameResults should not validate without a validation run to refer to")
+	assert.Equal(t, 0, validationRun.UsersProcessed)
+	mockQuerier.AssertNotCalled(t, "SelectGameResultsByUser", mock.Anything, mock.Anything, mock.Anything)
 	mockQuerier.AssertExpectations(t)
 }
 
-func TestValidateGameResultsTransactionError(t *testing.T) {
+func TestValidateGameResultsFinishRunError(t *testing.T) {
 	mockQuerier := test_helpers.NewMockQuerier()
 
 	instance := NewGameResultDAO(mockQuerier)
 
 	ctx := context.TODO()
 
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
 	userID := uuid.New()
 
-	// Mock select users with pending validation
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
-		{
-			ID:      userID,
-			Balance: 100.0,
-		},
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{ID: userID}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{}, nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, userID, entity.Money(0), true).Return(nil)
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+
+	// Mock record of the validation run outcome error
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(errors.New("database error"))
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.NoError(t, err, "ValidateGameResults should not fail once the validation is committed")
+	assert.Equal(t, 1, validationRun.UsersProcessed)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsDecisionError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	userID := uuid.New()
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{ID: userID, Balance: 100_00}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, []uuid.UUID{userID}).Return(nil, nil)
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+		{ID: 2, UserID: userID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 10_00},
 	}, nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, 2, entity.ValidationStatusAccepted).Return(nil)
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	// Mock record of the validation decision error, the whole transaction fails
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.Anything).Return(0, errors.New("database error"))
+
+	_, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.Error(t, err, "ValidateGameResults should return an error on InsertValidationDecision")
+	mockQuerier.AssertNotCalled(t, "UpdateUserBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsTransactionError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	userID := uuid.New()
+
+	// Mock claim of the user with pending validation, then no user left
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
 	// Mock select game results for the user
-	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
 		{
 			ID:                1,
 			UserID:            userID,
@@ -365,15 +812,19 @@ func TestValidateGameResultsTransactionError(t *testing.T) {
 			ValidationStatus:  entity.ValidationStatusPending,
 			TransactionSource: entity.TransactionSourceGame,
 			TransactionID:     "tx123",
-			Amount:            50.0,
+			Amount:            50_00,
 		},
 	}, nil)
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(nil)
-	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Return(nil)
-	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userID, mock.Anything, true).Return(nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Return(nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, userID, mock.Anything, true).Return(nil)
 
 	// Mock transaction operations error
-	mockQuerier.On("WithTransaction", ctx, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(errors.New("transaction error"))
+	mockQuerier.On("WithTransaction", validationCtx, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(errors.New("transaction error"))
+
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.Anything).Return(1, nil)
 
 	instance.ValidateGameResults(ctx, 1)
 
@@ -388,21 +839,20 @@ func TestValidateGameResultsGameResultError(t *testing.T) {
 
 	ctx := context.TODO()
 
-	userID := uuid.New()
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
 
-	// Mock select users with pending validation
-	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
-		{
-			ID:      userID,
-			Balance: 100.0,
-		},
-	}, nil)
+	userID := uuid.New()
 
-	// Mock lock user row
-	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(nil)
+	// Mock claim of the user with pending validation, then no user left
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil).Once()
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Return(nil, nil)
 
 	// Mock select game results for the user
-	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
 		{
 			ID:                1,
 			UserID:            userID,
@@ -410,18 +860,494 @@ func TestValidateGameResultsGameResultError(t *testing.T) {
 			ValidationStatus:  entity.ValidationStatusPending,
 			TransactionSource: entity.TransactionSourceGame,
 			TransactionID:     "tx123",
-			Amount:            50.0,
+			Amount:            50_00,
 		},
 	}, nil)
 
 	// Mock update game result error
-	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Return(errors.New("update error"))
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, mock.Anything, entity.ValidationStatusCanceled).Return(errors.New("update error"))
 
 	// Mock transaction operations
 	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
 
-	err := instance.ValidateGameResults(ctx, 1)
+	// Mock record of the validation run
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	_, err := instance.ValidateGameResults(ctx, 1)
 
 	assert.Error(t, err, "ValidateGameResults should return an error on UpdateGameResult")
 	mockQuerier.AssertExpectations(t)
 }
+
+func TestValidateGameResultsCancelledMidRun(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	// The users are validated with the context detached from its cancellation
+	validationCtx := context.WithoutCancel(ctx)
+
+	userID := uuid.New()
+
+	// Mock claim of a user, the cancellation arriving meanwhile
+	mockQuerier.On("ClaimUserForValidation", validationCtx, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		cancel()
+	}).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil).Once()
+
+	// The validation of the claimed user is finished
+	mockQuerier.On("SelectGameResultsByUser", validationCtx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+		{ID: 1, UserID: userID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 50_00},
+	}, nil)
+	mockQuerier.On("UpdateGameResult", validationCtx, mock.Anything, 1, entity.ValidationStatusCanceled).Return(nil)
+	mockQuerier.On("UpdateUserBalance", validationCtx, mock.Anything, userID, entity.Money(50_00), true).Return(nil)
+	mockQuerier.On("WithTransaction", validationCtx, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+	mockQuerier.On("InsertValidationRun", validationCtx, mock.Anything).Return(1, nil)
+	mockQuerier.On("InsertValidationDecision", validationCtx, mock.Anything, mock.Anything).Return(1, nil)
+	mockQuerier.On("UpdateValidationRun", validationCtx, mock.Anything).Return(nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.Equal(t, 1, validationRun.UsersProcessed)
+	assert.Equal(t, 1, validationRun.ResultsCanceled)
+
+	// No other user is claimed once cancelled
+	mockQuerier.AssertNumberOfCalls(t, "ClaimUserForValidation", 1)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsCancelledBeforeRun(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.Equal(t, 0, validationRun.UsersProcessed)
+	mockQuerier.AssertNotCalled(t, "ClaimUserForValidation", mock.Anything, mock.Anything, mock.Anything)
+	mockQuerier.AssertNotCalled(t, "InsertValidationRun", mock.Anything, mock.Anything)
+}
+
+func TestValidateUserGameResultsSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	userID := uuid.New()
+
+	// Mock lock of the user
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(&entity.User{
+		ID:      userID,
+		Balance: 100_00,
+	}, nil)
+
+	// Mock select game results for the user
+	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return([]entity.GameResult{
+		{ID: 1, UserID: userID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 30_00},
+		{ID: 2, UserID: userID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 20_00},
+	}, nil)
+
+	// Mock update game results and user balance
+	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, 1, entity.ValidationStatusCanceled).Return(nil)
+	mockQuerier.On("UpdateGameResult", ctx, mock.Anything, 2, entity.ValidationStatusAccepted).Return(nil)
+	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userID, entity.Money(70_00), true).Return(nil)
+
+	// Mock transaction operations
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+
+	// Mock record of the validation run and decisions
+	mockQuerier.On("InsertValidationRun", ctx, mock.Anything).Return(3, nil)
+	mockQuerier.On("UpdateValidationRun", ctx, mock.MatchedBy(func(run entity.ValidationRun) bool {
+		return run.ID == 3 && run.UsersProcessed == 1 && !run.Error.Valid
+	})).Return(nil)
+	mockQuerier.On("InsertValidationDecision", ctx, mock.Anything, mock.MatchedBy(func(decision entity.ValidationDecision) bool {
+		return decision.ValidationRunID == 3
+	})).Times(2).Return(1, nil)
+
+	validationRun, err := instance.ValidateUserGameResults(ctx, userID, 1)
+
+	assert.NoError(t, err, "ValidateUserGameResults should not return an error")
+	assert.Equal(t, 3, validationRun.ID)
+	assert.Equal(t, 1, validationRun.UsersProcessed)
+	assert.Equal(t, 1, validationRun.ResultsApproved)
+	assert.Equal(t, 1, validationRun.ResultsCanceled)
+	assert.Equal(t, entity.Money(-30_00), validationRun.BalanceDelta)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateUserGameResultsUserNotFound(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	userID := uuid.New()
+
+	// Mock lock of an unknown user
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(nil, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+
+	validationRun, err := instance.ValidateUserGameResults(ctx, userID, 1)
+
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+	assert.Nil(t, validationRun)
+	mockQuerier.AssertNotCalled(t, "InsertValidationRun", mock.Anything, mock.Anything)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateUserGameResultsError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	userID := uuid.New()
+
+	// Mock lock of the user
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error")).Return(nil)
+
+	// Mock select game results error
+	mockQuerier.On("SelectGameResultsByUser", ctx, userID, entity.ValidationStatusPending).Return(nil, errors.New("select error"))
+
+	// Mock record of the validation run, along with its error
+	mockQuerier.On("InsertValidationRun", ctx, mock.Anything).Return(4, nil)
+	mockQuerier.On("UpdateValidationRun", ctx, mock.MatchedBy(func(run entity.ValidationRun) bool {
+		return run.ID == 4 && run.UsersProcessed == 0 && run.Error.String == entity.ErrValidationFailed.Error()
+	})).Return(nil)
+
+	validationRun, err := instance.ValidateUserGameResults(ctx, userID, 1)
+
+	assert.Error(t, err, "ValidateUserGameResults should return an error on SelectGameResultsByUser")
+	assert.Equal(t, 4, validationRun.ID)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsDryRun(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+	instance.WithDryRun(true)
+
+	ctx := context.TODO()
+
+	winnerID := uuid.New()
+	loserID := uuid.New()
+
+	// Mock select of every user with pending validation
+	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return([]entity.User{
+		{ID: winnerID, Balance: 100_00},
+		{ID: loserID, Balance: 20_00},
+	}, nil)
+
+	// Mock select game results for the users
+	mockQuerier.On("SelectGameResultsByUser", ctx, winnerID, entity.ValidationStatusPending).Return([]entity.GameResult{
+		{ID: 1, UserID: winnerID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 50_00},
+		{ID: 2, UserID: winnerID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 10_00},
+		{ID: 3, UserID: winnerID, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: 5_00},
+	}, nil)
+	mockQuerier.On("SelectGameResultsByUser", ctx, loserID, entity.ValidationStatusPending).Return([]entity.GameResult{
+		{ID: 5, UserID: loserID, GameStatus: entity.GameStatusLost, TransactionSource: entity.TransactionSourceGame, Amount: 7_50},
+	}, nil)
+
+	validationRun, err := instance.ValidateGameResults(ctx, 1)
+
+	require.NoError(t, err, "ValidateGameResults should not return an error")
+	assert.True(t, validationRun.DryRun)
+	assert.Zero(t, validationRun.ID, "A dry run should not be recorded")
+	assert.Equal(t, 2, validationRun.UsersProcessed)
+	assert.Equal(t, 2, validationRun.ResultsApproved)
+	assert.Equal(t, 2, validationRun.ResultsCanceled)
+	assert.Equal(t, entity.Money(-50_00+7_50), validationRun.BalanceDelta)
+
+	require.Len(t, validationRun.Users, 2)
+
+	winner := validationRun.Users[0]
+	assert.Equal(t, winnerID, winner.UserID)
+	assert.Equal(t, entity.Money(100_00), winner.BalanceBefore)
+	assert.Equal(t, entity.Money(50_00), winner.BalanceAfter)
+	require.Len(t, winner.Decisions, 3)
+	assert.Equal(t, entity.ValidationReasonCanceledByPolicy, winner.Decisions[0].Reason)
+	assert.Equal(t, entity.ValidationReasonAcceptedByPolicy, winner.Decisions[1].Reason)
+	assert.Equal(t, entity.ValidationReasonCancellationLimitReached, winner.Decisions[2].Reason)
+	assert.Equal(t, entity.ValidationStatusAccepted, winner.Decisions[2].Decision)
+
+	loser := validationRun.Users[1]
+	assert.Equal(t, entity.Money(27_50), loser.BalanceAfter)
+	assert.Equal(t, entity.ValidationStatusCanceled, loser.Decisions[0].Decision)
+
+	// Nothing is locked, changed nor recorded
+	mockQuerier.AssertNotCalled(t, "WithTransaction", mock.Anything, mock.Anything)
+	mockQuerier.AssertNotCalled(t, "InsertValidationRun", mock.Anything, mock.Anything)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestValidateGameResultsDryRunError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+	instance.WithDryRun(true)
+
+	ctx := context.TODO()
+
+	// Mock select of the users error
+	mockQuerier.On("SelectUsersByValidationStatus", ctx, false).Return(nil, errors.New("select error"))
+
+	_, err := instance.ValidateGameResults(ctx, 1)
+
+	assert.Error(t, err, "ValidateGameResults should return an error on SelectUsersByValidationStatus")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListGameResultsSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userID := uuid.New()
+	filter := entity.GameResultFilter{UserID: userID, Limit: 2}
+
+	mockQuerier.On("SelectUser", ctx, userID).Return(&entity.User{ID: userID}, nil)
+
+	// One more entry than the limit, so there is a next page
+	mockQuerier.On("SelectGameResultsByFilter", ctx, entity.GameResultFilter{UserID: userID, Limit: 3}).Return([]entity.GameResult{
+		{ID: 9, UserID: userID},
+		{ID: 7, UserID: userID},
+		{ID: 4, UserID: userID},
+	}, nil)
+
+	page, err := instance.ListGameResults(ctx, filter)
+
+	assert.NoError(t, err, "ListGameResults should not return an error")
+	assert.Len(t, page.GameResults, 2)
+	assert.Equal(t, 7, page.NextCursor)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListGameResultsWithValidationDecisions(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userID := uuid.New()
+
+	mockQuerier.On("SelectUser", ctx, userID).Return(&entity.User{ID: userID}, nil)
+	mockQuerier.On("SelectGameResultsByFilter", ctx, entity.GameResultFilter{UserID: userID, Limit: 4}).Return([]entity.GameResult{
+		{ID: 9, UserID: userID, ValidationStatus: entity.ValidationStatusPending},
+		{ID: 7, UserID: userID, ValidationStatus: entity.ValidationStatusCanceled},
+		{ID: 4, UserID: userID, ValidationStatus: entity.ValidationStatusAccepted},
+	}, nil)
+
+	// Only the validated game results have a decision to look for
+	mockQuerier.On("SelectValidationDecisionsByGameResults", ctx, []int{7, 4}).Return([]entity.ValidationDecision{
+		{GameResultID: 7, Decision: entity.ValidationStatusCanceled, Reason: entity.ValidationReasonCanceledByPolicy},
+	}, nil)
+
+	page, err := instance.ListGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 3})
+
+	assert.NoError(t, err, "ListGameResults should not return an error")
+	assert.Nil(t, page.GameResults[0].Decision)
+	assert.Equal(t, entity.ValidationReasonCanceledByPolicy, page.GameResults[1].Decision.Reason)
+	assert.Nil(t, page.GameResults[2].Decision, "Game results validated before the audit have no decision")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListGameResultsLastPage(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userID := uuid.New()
+	filter := entity.GameResultFilter{UserID: userID, Limit: 2}
+
+	mockQuerier.On("SelectUser", ctx, userID).Return(&entity.User{ID: userID}, nil)
+	mockQuerier.On("SelectGameResultsByFilter", ctx, mock.Anything).Return([]entity.GameResult{
+		{ID: 9, UserID: userID},
+	}, nil)
+
+	page, err := instance.ListGameResults(ctx, filter)
+
+	assert.NoError(t, err, "ListGameResults should not return an error")
+	assert.Len(t, page.GameResults, 1)
+	assert.Zero(t, page.NextCursor)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListGameResultsUserNotFound(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userID := uuid.New()
+
+	// Mock user not found
+	mockQuerier.On("SelectUser", ctx, userID).Return()
+
+	_, err := instance.ListGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 10})
+
+	assert.EqualError(t, err, entity.ErrUserNotFound.Error(), "ListGameResults should return ErrUserNotFound")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListGameResultsInvalidLimit(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	_, err := instance.ListGameResults(context.TODO(), entity.GameResultFilter{UserID: uuid.New()})
+
+	assert.EqualError(t, err, entity.ErrInvalidLimit.Error(), "ListGameResults should return ErrInvalidLimit")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListGameResultsDatabaseError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userID := uuid.New()
+
+	mockQuerier.On("SelectUser", ctx, userID).Return(&entity.User{ID: userID}, nil)
+	mockQuerier.On("SelectGameResultsByFilter", ctx, mock.Anything).Return(nil, errors.New("database error"))
+
+	_, err := instance.ListGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 10})
+
+	assert.Error(t, err, "ListGameResults should return an error on SelectGameResultsByFilter")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateGameResultsBatch(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	unknownUserId := uuid.New()
+
+	submissions := []entity.GameResultSubmission{
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 50_00, TransactionID: "tx1"},
+		{UserID: unknownUserId, GameStatus: entity.GameStatusWin, Amount: 10_00, TransactionID: "tx2"},
+		{UserID: userId, GameStatus: entity.GameStatusLost, Amount: 500_00, TransactionID: "tx3"},
+		{UserID: userId, GameStatus: entity.GameStatusLost, Amount: 120_00, TransactionID: "tx4"},
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 10_00, TransactionID: "tx1"},
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 10_00, TransactionID: "existing"},
+	}
+
+	// Mock the users
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
+		ID:      userId,
+		Balance: 100_00,
+	}, nil)
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, unknownUserId).Return(nil, nil)
+
+	// Mock the transaction IDs
+	mockQuerier.On("CheckTransactionID", ctx, "existing").Return(true, nil)
+	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
+
+	// Only the win of 50 and the lost of 120 are recorded: 100 + 50 - 120 = 30
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Times(2).Return(uuid.New(), nil)
+	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userId, entity.Money(30_00), false).Return(nil)
+
+	negativeBalance := metrics.GameResultRejections.WithLabelValues(metrics.RejectionNegativeBalance, string(entity.TransactionSourceGame))
+	duplicates := metrics.GameResultRejections.WithLabelValues(metrics.RejectionTransactionIdExists, string(entity.TransactionSourceGame))
+	negativeBalanceBefore, duplicatesBefore := testutil.ToFloat64(negativeBalance), testutil.ToFloat64(duplicates)
+
+	outcomes := instance.CreateGameResults(ctx, entity.TransactionSourceGame, submissions)
+
+	assert.Equal(t, negativeBalanceBefore+1, testutil.ToFloat64(negativeBalance), "The insufficient balance should be counted")
+	assert.Equal(t, duplicatesBefore+2, testutil.ToFloat64(duplicates), "The duplicates should be counted")
+	assert.Len(t, outcomes, len(submissions))
+	assert.Equal(t, entity.BatchItemStatusCreated, outcomes[0].Status)
+	assert.NotNil(t, outcomes[0].GameResult)
+	assert.Equal(t, entity.BatchItemStatusUnknownUser, outcomes[1].Status)
+	assert.Equal(t, entity.BatchItemStatusInsufficientBalance, outcomes[2].Status)
+	assert.Equal(t, entity.BatchItemStatusCreated, outcomes[3].Status)
+	assert.Equal(t, entity.BatchItemStatusDuplicate, outcomes[4].Status)
+	assert.Equal(t, entity.BatchItemStatusDuplicate, outcomes[5].Status)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateGameResultsBatchConcurrentDuplicate(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+
+	submissions := []entity.GameResultSubmission{
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 50_00, TransactionID: "tx1"},
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 10_00, TransactionID: "tx2"},
+		{UserID: userId, GameStatus: entity.GameStatusLost, Amount: 20_00, TransactionID: "tx3"},
+	}
+
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{ID: userId, Balance: 100_00}, nil)
+	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+
+	// A concurrent request records tx2 between the check and the insert
+	isRacing := func(gameResult entity.GameResult) bool { return gameResult.TransactionID == "tx2" }
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.MatchedBy(isRacing)).Return(nil, entity.ErrTransactionIdExists)
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Times(2).Return(uuid.New(), nil)
+
+	// Only the win of 50 and the lost of 20 are recorded: 100 + 50 - 20 = 130
+	mockQuerier.On("UpdateUserBalance", ctx, mock.Anything, userId, entity.Money(130_00), false).Return(nil)
+
+	outcomes := instance.CreateGameResults(ctx, entity.TransactionSourceGame, submissions)
+
+	assert.Equal(t, entity.BatchItemStatusCreated, outcomes[0].Status)
+	assert.Equal(t, entity.BatchItemStatusDuplicate, outcomes[1].Status)
+	assert.ErrorIs(t, outcomes[1].Err, entity.ErrTransactionIdExists)
+	assert.Equal(t, entity.BatchItemStatusCreated, outcomes[2].Status)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateGameResultsBatchDatabaseError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewGameResultDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	disabledUserId := uuid.New()
+
+	submissions := []entity.GameResultSubmission{
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 50_00, TransactionID: "tx1"},
+		{UserID: disabledUserId, GameStatus: entity.GameStatusWin, Amount: 50_00, TransactionID: "tx2"},
+	}
+
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{ID: userId}, nil)
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, disabledUserId).Return(&entity.User{ID: disabledUserId, Disabled: true}, nil)
+	mockQuerier.On("CheckTransactionID", ctx, mock.Anything).Return(false, nil)
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+
+	outcomes := instance.CreateGameResults(ctx, entity.TransactionSourceGame, submissions)
+
+	assert.Equal(t, entity.BatchItemStatusFailed, outcomes[0].Status)
+	assert.ErrorIs(t, outcomes[0].Err, entity.ErrCreatingGameResult)
+	assert.Equal(t, entity.BatchItemStatusUserDisabled, outcomes[1].Status)
+	mockQuerier.AssertExpectations(t)
+}
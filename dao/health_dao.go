@@ -0,0 +1,10 @@
+package dao
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+)
+
+type HealthDAO interface {
+	CheckDatabase(ctx context.Context) (*entity.DatabaseHealth, error)
+}
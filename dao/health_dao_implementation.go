@@ -0,0 +1,47 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/entity"
+	"time"
+)
+
+type healthDAO struct {
+	querier database.Querier
+}
+
+// NewHealthDAO creates a new health DAO
+func NewHealthDAO(querier database.Querier) *healthDAO {
+	return &healthDAO{querier: querier}
+}
+
+// CheckDatabase pings the database, and reads its migration version and the connection pool statistics
+// It returns an error if the database can not be reached
+func (dm *healthDAO) CheckDatabase(ctx context.Context) (*entity.DatabaseHealth, error) {
+	start := time.Now()
+	if err := dm.querier.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("pinging database: %w", err)
+	}
+	responseTime := time.Since(start)
+
+	version, dirty, err := dm.querier.MigrationVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading migration version: %w", err)
+	}
+
+	stats := dm.querier.Stats()
+
+	return &entity.DatabaseHealth{
+		ResponseTime:       responseTime,
+		MigrationVersion:   version,
+		MigrationDirty:     dirty,
+		OpenConnections:    stats.OpenConnections,
+		InUseConnections:   stats.InUse,
+		IdleConnections:    stats.Idle,
+		MaxOpenConnections: stats.MaxOpenConnections,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration,
+	}, nil
+}
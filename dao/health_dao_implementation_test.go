@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+
+	"github.com/ildomm/cceab/test_helpers"
+)
+
+func TestCheckDatabaseSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewHealthDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("Ping", ctx).Return(nil)
+	mockQuerier.On("MigrationVersion", ctx).Return(uint(11), false, nil)
+	mockQuerier.On("Stats").Return(sql.DBStats{
+		MaxOpenConnections: 10,
+		OpenConnections:    3,
+		InUse:              1,
+		Idle:               2,
+		WaitCount:          4,
+		WaitDuration:       time.Second,
+	})
+
+	databaseHealth, err := instance.CheckDatabase(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(11), databaseHealth.MigrationVersion)
+	assert.False(t, databaseHealth.MigrationDirty)
+	assert.Equal(t, 3, databaseHealth.OpenConnections)
+	assert.Equal(t, 1, databaseHealth.InUseConnections)
+	assert.Equal(t, 2, databaseHealth.IdleConnections)
+	assert.Equal(t, 10, databaseHealth.MaxOpenConnections)
+	assert.Equal(t, int64(4), databaseHealth.WaitCount)
+	assert.Equal(t, time.Second, databaseHealth.WaitDuration)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCheckDatabasePingError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewHealthDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("Ping", ctx).Return(errors.New("connection refused"))
+
+	_, err := instance.CheckDatabase(ctx)
+
+	assert.ErrorContains(t, err, "connection refused")
+	mockQuerier.AssertNotCalled(t, "MigrationVersion", ctx)
+}
+
+func TestCheckDatabaseMigrationVersionError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewHealthDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("Ping", ctx).Return(nil)
+	mockQuerier.On("MigrationVersion", ctx).Return(uint(0), false, errors.New("no migration table"))
+
+	_, err := instance.CheckDatabase(ctx)
+
+	assert.Error(t, err)
+	mockQuerier.AssertExpectations(t)
+}
@@ -0,0 +1,244 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const tracerName = "github.com/ildomm/cceab/dao"
+
+type tracingGameResultDAO struct {
+	gameResultDAO GameResultDAO
+	tracer        trace.Tracer
+}
+
+// NewTracingGameResultDAO wraps the given game result DAO, recording a span for each operation
+func NewTracingGameResultDAO(gameResultDAO GameResultDAO) *tracingGameResultDAO {
+	return &tracingGameResultDAO{gameResultDAO: gameResultDAO, tracer: otel.Tracer(tracerName)}
+}
+
+func (dm *tracingGameResultDAO) CreateGameResult(ctx context.Context, userId uuid.UUID, gameStatus entity.GameStatus, amount entity.Money, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error) {
+	ctx, span := dm.tracer.Start(ctx, "GameResultDAO.CreateGameResult", trace.WithAttributes(
+		attribute.String("user.id", userId.String()),
+		attribute.String("game_result.status", string(gameStatus)),
+		attribute.String("game_result.source", string(transactionSource)),
+		attribute.String("game_result.transaction_id", transactionID),
+	))
+	gameResult, err := dm.gameResultDAO.CreateGameResult(ctx, userId, gameStatus, amount, transactionSource, transactionID)
+
+	// A replayed transaction gets the recorded game result back, it is not a failure
+	spanErr := err
+	if errors.Is(err, entity.ErrTransactionIdReplayed) {
+		span.SetAttributes(attribute.Bool("game_result.replayed", true))
+		spanErr = nil
+	}
+	tracing.EndSpan(span, spanErr)
+	return gameResult, err
+}
+
+func (dm *tracingGameResultDAO) CreateGameResults(ctx context.Context, transactionSource entity.TransactionSource, submissions []entity.GameResultSubmission) []entity.GameResultOutcome {
+	ctx, span := dm.tracer.Start(ctx, "GameResultDAO.CreateGameResults", trace.WithAttributes(
+		attribute.String("game_result.source", string(transactionSource)),
+		attribute.Int("game_result.batch_size", len(submissions)),
+	))
+	outcomes := dm.gameResultDAO.CreateGameResults(ctx, transactionSource, submissions)
+	tracing.EndSpan(span, nil)
+	return outcomes
+}
+
+func (dm *tracingGameResultDAO) ListGameResults(ctx context.Context, filter entity.GameResultFilter) (*entity.GameResultPage, error) {
+	ctx, span := dm.tracer.Start(ctx, "GameResultDAO.ListGameResults", trace.WithAttributes(
+		attribute.String("user.id", filter.UserID.String()),
+	))
+	page, err := dm.gameResultDAO.ListGameResults(ctx, filter)
+	tracing.EndSpan(span, err)
+	return page, err
+}
+
+func (dm *tracingGameResultDAO) ValidateGameResults(ctx context.Context, totalGamesToCancel int) (*entity.ValidationRun, error) {
+	ctx, span := dm.tracer.Start(ctx, "GameResultDAO.ValidateGameResults")
+	validationRun, err := dm.gameResultDAO.ValidateGameResults(ctx, totalGamesToCancel)
+	if validationRun != nil {
+		span.SetAttributes(attribute.Int("validation_run.id", validationRun.ID), attribute.Int("validation_run.users_processed", validationRun.UsersProcessed))
+	}
+	tracing.EndSpan(span, err)
+	return validationRun, err
+}
+
+func (dm *tracingGameResultDAO) ValidateUserGameResults(ctx context.Context, userId uuid.UUID, totalGamesToCancel int) (*entity.ValidationRun, error) {
+	ctx, span := dm.tracer.Start(ctx, "GameResultDAO.ValidateUserGameResults", trace.WithAttributes(
+		attribute.String("user.id", userId.String()),
+	))
+	validationRun, err := dm.gameResultDAO.ValidateUserGameResults(ctx, userId, totalGamesToCancel)
+	if validationRun != nil {
+		span.SetAttributes(attribute.Int("validation_run.id", validationRun.ID))
+	}
+	tracing.EndSpan(span, err)
+	return validationRun, err
+}
+
+type tracingUserDAO struct {
+	userDAO UserDAO
+	tracer  trace.Tracer
+}
+
+// NewTracingUserDAO wraps the given user DAO, recording a span for each operation
+func NewTracingUserDAO(userDAO UserDAO) *tracingUserDAO {
+	return &tracingUserDAO{userDAO: userDAO, tracer: otel.Tracer(tracerName)}
+}
+
+func (dm *tracingUserDAO) CreateUser(ctx context.Context, email string, balance entity.Money) (*entity.User, error) {
+	ctx, span := dm.tracer.Start(ctx, "UserDAO.CreateUser")
+	user, err := dm.userDAO.CreateUser(ctx, email, balance)
+	tracing.EndSpan(span, err)
+	return user, err
+}
+
+func (dm *tracingUserDAO) UpdateUser(ctx context.Context, userId uuid.UUID, update entity.UserUpdate) (*entity.User, error) {
+	ctx, span := dm.tracer.Start(ctx, "UserDAO.UpdateUser", trace.WithAttributes(
+		attribute.String("user.id", userId.String()),
+	))
+	user, err := dm.userDAO.UpdateUser(ctx, userId, update)
+	tracing.EndSpan(span, err)
+	return user, err
+}
+
+func (dm *tracingUserDAO) GetUserAccount(ctx context.Context, userId uuid.UUID) (*entity.UserAccount, error) {
+	ctx, span := dm.tracer.Start(ctx, "UserDAO.GetUserAccount", trace.WithAttributes(
+		attribute.String("user.id", userId.String()),
+	))
+	account, err := dm.userDAO.GetUserAccount(ctx, userId)
+	tracing.EndSpan(span, err)
+	return account, err
+}
+
+type tracingValidationRunDAO struct {
+	validationRunDAO ValidationRunDAO
+	tracer           trace.Tracer
+}
+
+// NewTracingValidationRunDAO wraps the given validation run DAO, recording a span for each operation
+func NewTracingValidationRunDAO(validationRunDAO ValidationRunDAO) *tracingValidationRunDAO {
+	return &tracingValidationRunDAO{validationRunDAO: validationRunDAO, tracer: otel.Tracer(tracerName)}
+}
+
+func (dm *tracingValidationRunDAO) ListValidationRuns(ctx context.Context, filter entity.ValidationRunFilter) (*entity.ValidationRunPage, error) {
+	ctx, span := dm.tracer.Start(ctx, "ValidationRunDAO.ListValidationRuns")
+	page, err := dm.validationRunDAO.ListValidationRuns(ctx, filter)
+	tracing.EndSpan(span, err)
+	return page, err
+}
+
+func (dm *tracingValidationRunDAO) GetValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error) {
+	ctx, span := dm.tracer.Start(ctx, "ValidationRunDAO.GetValidationRun", trace.WithAttributes(
+		attribute.Int("validation_run.id", validationRunId),
+	))
+	validationRun, err := dm.validationRunDAO.GetValidationRun(ctx, validationRunId)
+	tracing.EndSpan(span, err)
+	return validationRun, err
+}
+
+type tracingHealthDAO struct {
+	healthDAO HealthDAO
+	tracer    trace.Tracer
+}
+
+// NewTracingHealthDAO wraps the given health DAO, recording a span for each operation
+func NewTracingHealthDAO(healthDAO HealthDAO) *tracingHealthDAO {
+	return &tracingHealthDAO{healthDAO: healthDAO, tracer: otel.Tracer(tracerName)}
+}
+
+func (dm *tracingHealthDAO) CheckDatabase(ctx context.Context) (*entity.DatabaseHealth, error) {
+	ctx, span := dm.tracer.Start(ctx, "HealthDAO.CheckDatabase")
+	health, err := dm.healthDAO.CheckDatabase(ctx)
+	tracing.EndSpan(span, err)
+	return health, err
+}
+
+type tracingAPIKeyDAO struct {
+	apiKeyDAO APIKeyDAO
+	tracer    trace.Tracer
+}
+
+// NewTracingAPIKeyDAO wraps the given api key DAO, recording a span for each operation
+func NewTracingAPIKeyDAO(apiKeyDAO APIKeyDAO) *tracingAPIKeyDAO {
+	return &tracingAPIKeyDAO{apiKeyDAO: apiKeyDAO, tracer: otel.Tracer(tracerName)}
+}
+
+func (dm *tracingAPIKeyDAO) CreateAPIKey(ctx context.Context, transactionSource entity.TransactionSource, description string) (*entity.APIKey, string, error) {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.CreateAPIKey", trace.WithAttributes(
+		attribute.String("api_key.source", string(transactionSource)),
+	))
+	apiKey, key, err := dm.apiKeyDAO.CreateAPIKey(ctx, transactionSource, description)
+	if apiKey != nil {
+		span.SetAttributes(attribute.Int("api_key.id", apiKey.ID))
+	}
+	tracing.EndSpan(span, err)
+	return apiKey, key, err
+}
+
+func (dm *tracingAPIKeyDAO) AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error) {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.AuthenticateAPIKey")
+	apiKey, err := dm.apiKeyDAO.AuthenticateAPIKey(ctx, key)
+	if apiKey != nil {
+		span.SetAttributes(
+			attribute.Int("api_key.id", apiKey.ID),
+			attribute.String("api_key.source", string(apiKey.TransactionSource)),
+		)
+	}
+	tracing.EndSpan(span, err)
+	return apiKey, err
+}
+
+func (dm *tracingAPIKeyDAO) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.ListAPIKeys")
+	apiKeys, err := dm.apiKeyDAO.ListAPIKeys(ctx)
+	tracing.EndSpan(span, err)
+	return apiKeys, err
+}
+
+func (dm *tracingAPIKeyDAO) RotateAPIKey(ctx context.Context, apiKeyId int, gracePeriod time.Duration) (*entity.APIKey, string, error) {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.RotateAPIKey", trace.WithAttributes(
+		attribute.Int("api_key.id", apiKeyId),
+		attribute.String("api_key.grace_period", gracePeriod.String()),
+	))
+	apiKey, key, err := dm.apiKeyDAO.RotateAPIKey(ctx, apiKeyId, gracePeriod)
+	if apiKey != nil {
+		span.SetAttributes(attribute.Int("api_key.new_id", apiKey.ID))
+	}
+	tracing.EndSpan(span, err)
+	return apiKey, key, err
+}
+
+func (dm *tracingAPIKeyDAO) RevokeAPIKey(ctx context.Context, apiKeyId int) (*entity.APIKey, error) {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.RevokeAPIKey", trace.WithAttributes(
+		attribute.Int("api_key.id", apiKeyId),
+	))
+	apiKey, err := dm.apiKeyDAO.RevokeAPIKey(ctx, apiKeyId)
+	tracing.EndSpan(span, err)
+	return apiKey, err
+}
+
+func (dm *tracingAPIKeyDAO) RecordNonce(ctx context.Context, apiKeyId int, nonce string, ttl time.Duration) error {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.RecordNonce", trace.WithAttributes(
+		attribute.Int("api_key.id", apiKeyId),
+	))
+	err := dm.apiKeyDAO.RecordNonce(ctx, apiKeyId, nonce, ttl)
+	tracing.EndSpan(span, err)
+	return err
+}
+
+func (dm *tracingAPIKeyDAO) PurgeExpiredNonces(ctx context.Context) (int64, error) {
+	ctx, span := dm.tracer.Start(ctx, "APIKeyDAO.PurgeExpiredNonces")
+	deleted, err := dm.apiKeyDAO.PurgeExpiredNonces(ctx)
+	span.SetAttributes(attribute.Int64("request_nonce.deleted", deleted))
+	tracing.EndSpan(span, err)
+	return deleted, err
+}
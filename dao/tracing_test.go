@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/test_helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"testing"
+)
+
+func TestTracingGameResultDAONestsQuerierSpans(t *testing.T) {
+	recorder := test_helpers.RecordSpans(t)
+
+	userId := uuid.New()
+	recorded := &entity.GameResult{
+		ID:                1,
+		UserID:            userId,
+		GameStatus:        entity.GameStatusWin,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     "tx1",
+		Amount:            10_00,
+	}
+
+	// The transaction was already recorded with the same payload
+	mockQuerier := test_helpers.NewMockQuerier()
+	mockQuerier.On("CheckTransactionID", mock.Anything, "tx1").Return(true, nil)
+	mockQuerier.On("SelectGameResultByTransactionID", mock.Anything, "tx1").Return(recorded, nil)
+
+	instance := NewTracingGameResultDAO(NewGameResultDAO(database.NewTracingQuerier(mockQuerier)))
+
+	gameResult, err := instance.CreateGameResult(context.Background(), userId, entity.GameStatusWin, 10_00, entity.TransactionSourceGame, "tx1")
+	assert.ErrorIs(t, err, entity.ErrTransactionIdReplayed)
+	assert.Equal(t, recorded, gameResult)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "Querier.CheckTransactionID", spans[0].Name())
+	assert.Equal(t, "Querier.SelectGameResultByTransactionID", spans[1].Name())
+
+	daoSpan := spans[2]
+	assert.Equal(t, "GameResultDAO.CreateGameResult", daoSpan.Name())
+	assert.Equal(t, daoSpan.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, daoSpan.SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Contains(t, daoSpan.Attributes(), attribute.String("user.id", userId.String()))
+	assert.Contains(t, daoSpan.Attributes(), attribute.Bool("game_result.replayed", true))
+	assert.Equal(t, codes.Unset, daoSpan.Status().Code, "a replayed transaction is not a failure")
+}
+
+func TestTracingUserDAORecordsError(t *testing.T) {
+	recorder := test_helpers.RecordSpans(t)
+
+	userId := uuid.New()
+	mockUserDAO := test_helpers.NewMockUserDAO()
+	mockUserDAO.On("GetUserAccount", mock.Anything, userId).Return(nil, entity.ErrUserNotFound)
+
+	_, err := NewTracingUserDAO(mockUserDAO).GetUserAccount(context.Background(), userId)
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "UserDAO.GetUserAccount", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, entity.ErrUserNotFound.Error(), spans[0].Status().Description)
+}
@@ -0,0 +1,13 @@
+package dao
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+)
+
+type UserDAO interface {
+	CreateUser(ctx context.Context, email string, balance entity.Money) (*entity.User, error)
+	UpdateUser(ctx context.Context, userId uuid.UUID, update entity.UserUpdate) (*entity.User, error)
+	GetUserAccount(ctx context.Context, userId uuid.UUID) (*entity.UserAccount, error)
+}
@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/entity"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+type userDAO struct {
+	querier database.Querier
+}
+
+// NewUserDAO creates a new user DAO
+func NewUserDAO(querier database.Querier) *userDAO {
+	return &userDAO{querier: querier}
+}
+
+// CreateUser creates a new user
+// It returns the created user
+// It returns an error if the email is invalid or already taken, or if the balance is negative
+func (dm *userDAO) CreateUser(ctx context.Context, email string, balance entity.Money) (*entity.User, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if balance < 0 {
+		return nil, entity.ErrInvalidBalance
+	}
+
+	user := entity.User{
+		Email:   email,
+		Balance: balance,
+		// A new user has no game result waiting for validation
+		GamesResultValidated: sql.NullBool{Bool: true, Valid: true},
+		CreatedAt:            time.Now(),
+	}
+
+	id, err := dm.querier.InsertUser(ctx, user)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserEmailExists) {
+			return nil, err
+		}
+		slog.ErrorContext(ctx, "error inserting user", "error", err)
+		return nil, entity.ErrCreatingUser
+	}
+	user.ID = id
+
+	return &user, nil
+}
+
+// UpdateUser applies the given changes to the user
+// It returns the updated user
+// It returns an error if the user does not exist, or if the new email is invalid or already taken
+func (dm *userDAO) UpdateUser(ctx context.Context, userId uuid.UUID, update entity.UserUpdate) (*entity.User, error) {
+	if update.Email != nil {
+		email, err := normalizeEmail(*update.Email)
+		if err != nil {
+			return nil, err
+		}
+		update.Email = &email
+	}
+
+	var user *entity.User
+
+	// Perform the whole operation inside a db transaction
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		// No other processes can update the user until end of this transaction
+		var err error
+		user, err = dm.querier.LockUserRow(ctx, *txn, userId)
+		if err != nil {
+			return fmt.Errorf("locking user row: %w", err)
+		}
+		if user == nil {
+			return entity.ErrUserNotFound
+		}
+
+		if update.Email != nil {
+			user.Email = *update.Email
+		}
+		if update.Disabled != nil {
+			user.Disabled = *update.Disabled
+		}
+
+		return dm.querier.UpdateUser(ctx, *txn, *user)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// normalizeEmail validates the email, returning its bare address
+func normalizeEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return "", entity.ErrInvalidEmail
+	}
+	return strings.ToLower(address.Address), nil
+}
+
+// GetUserAccount returns the user along with its balance breakdown
+// The pending balance is the sum of the game results not validated yet
+// The settled balance is the part of the balance already validated
+// It returns an error if the user does not exist
+func (dm *userDAO) GetUserAccount(ctx context.Context, userId uuid.UUID) (*entity.UserAccount, error) {
+	user, err := dm.querier.SelectUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("selecting user: %w", err)
+	}
+	if user == nil {
+		return nil, entity.ErrUserNotFound
+	}
+
+	pendingBalance, err := dm.querier.SumPendingAmountByUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("summing pending amount by user: %w", err)
+	}
+
+	account := entity.UserAccount{
+		User:           *user,
+		PendingBalance: pendingBalance,
+		SettledBalance: user.Balance - pendingBalance,
+	}
+
+	return &account, nil
+}
@@ -0,0 +1,216 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/test_helpers"
+)
+
+func TestGetUserAccountSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+
+	mockQuerier.On("SelectUser", ctx, userId).Return(&entity.User{
+		ID:      userId,
+		Balance: 200_00,
+	}, nil)
+	mockQuerier.On("SumPendingAmountByUser", ctx, userId).Return(entity.Money(-50_00), nil)
+
+	account, err := instance.GetUserAccount(ctx, userId)
+
+	assert.NoError(t, err, "GetUserAccount should not return an error")
+	assert.Equal(t, entity.Money(200_00), account.Balance)
+	assert.Equal(t, entity.Money(-50_00), account.PendingBalance)
+	assert.Equal(t, entity.Money(250_00), account.SettledBalance)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestGetUserAccountUserNotFound(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+
+	// Mock user not found
+	mockQuerier.On("SelectUser", ctx, userId).Return()
+
+	_, err := instance.GetUserAccount(ctx, userId)
+
+	assert.EqualError(t, err, entity.ErrUserNotFound.Error(), "GetUserAccount should return ErrUserNotFound")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestGetUserAccountDatabaseError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+
+	mockQuerier.On("SelectUser", ctx, userId).Return(&entity.User{ID: userId}, nil)
+	mockQuerier.On("SumPendingAmountByUser", ctx, userId).Return(entity.Money(0), errors.New("database error"))
+
+	_, err := instance.GetUserAccount(ctx, userId)
+
+	assert.Error(t, err, "GetUserAccount should return an error on SumPendingAmountByUser")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateUserSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+
+	mockQuerier.On("InsertUser", ctx, mock.MatchedBy(func(user entity.User) bool {
+		return user.Email == "new.user@example.com" && user.Balance == entity.Money(25_00) && !user.Disabled
+	})).Return(userId, nil)
+
+	user, err := instance.CreateUser(ctx, " New.User@Example.com ", entity.Money(25_00))
+
+	assert.NoError(t, err, "CreateUser should not return an error")
+	assert.Equal(t, userId, user.ID)
+	assert.Equal(t, "new.user@example.com", user.Email)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateUserInvalidInput(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	_, err := instance.CreateUser(ctx, "not-an-email", 0)
+	assert.EqualError(t, err, entity.ErrInvalidEmail.Error(), "CreateUser should return ErrInvalidEmail")
+
+	_, err = instance.CreateUser(ctx, "user@example.com", -1)
+	assert.EqualError(t, err, entity.ErrInvalidBalance.Error(), "CreateUser should return ErrInvalidBalance")
+
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateUserEmailExists(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("InsertUser", ctx, mock.Anything).Return(nil, entity.ErrUserEmailExists)
+
+	_, err := instance.CreateUser(ctx, "user1@example.com", 0)
+
+	assert.EqualError(t, err, entity.ErrUserEmailExists.Error(), "CreateUser should return ErrUserEmailExists")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestCreateUserDatabaseError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("InsertUser", ctx, mock.Anything).Return(nil, errors.New("database error"))
+
+	_, err := instance.CreateUser(ctx, "user@example.com", 0)
+
+	assert.EqualError(t, err, entity.ErrCreatingUser.Error(), "CreateUser should return ErrCreatingUser")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestUpdateUserSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	email := "changed@example.com"
+	disabled := true
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{
+		ID:      userId,
+		Email:   "user@example.com",
+		Balance: 10_00,
+	}, nil)
+	mockQuerier.On("UpdateUser", ctx, mock.Anything, entity.User{
+		ID:       userId,
+		Email:    email,
+		Balance:  10_00,
+		Disabled: true,
+	}).Return(nil)
+
+	user, err := instance.UpdateUser(ctx, userId, entity.UserUpdate{Email: &email, Disabled: &disabled})
+
+	assert.NoError(t, err, "UpdateUser should not return an error")
+	assert.Equal(t, email, user.Email)
+	assert.True(t, user.Disabled)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestUpdateUserUserNotFound(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	disabled := true
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(nil, nil)
+
+	_, err := instance.UpdateUser(ctx, userId, entity.UserUpdate{Disabled: &disabled})
+
+	assert.EqualError(t, err, entity.ErrUserNotFound.Error(), "UpdateUser should return ErrUserNotFound")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestUpdateUserEmailExists(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	ctx := context.TODO()
+	userId := uuid.New()
+	email := "user2@example.com"
+
+	mockQuerier.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	mockQuerier.On("LockUserRow", ctx, mock.Anything, userId).Return(&entity.User{ID: userId, Email: "user1@example.com"}, nil)
+	mockQuerier.On("UpdateUser", ctx, mock.Anything, mock.Anything).Return(entity.ErrUserEmailExists)
+
+	_, err := instance.UpdateUser(ctx, userId, entity.UserUpdate{Email: &email})
+
+	assert.ErrorIs(t, err, entity.ErrUserEmailExists, "UpdateUser should return ErrUserEmailExists")
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestUpdateUserInvalidEmail(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewUserDAO(mockQuerier)
+
+	email := "not-an-email"
+	_, err := instance.UpdateUser(context.TODO(), uuid.New(), entity.UserUpdate{Email: &email})
+
+	assert.EqualError(t, err, entity.ErrInvalidEmail.Error(), "UpdateUser should return ErrInvalidEmail")
+	mockQuerier.AssertExpectations(t)
+}
@@ -0,0 +1,11 @@
+package dao
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+)
+
+type ValidationRunDAO interface {
+	ListValidationRuns(ctx context.Context, filter entity.ValidationRunFilter) (*entity.ValidationRunPage, error)
+	GetValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error)
+}
@@ -0,0 +1,56 @@
+package dao
+
+import (
+	"context"
+	"fmt"
+	"github.com/ildomm/cceab/database"
+	"github.com/ildomm/cceab/entity"
+)
+
+type validationRunDAO struct {
+	querier database.Querier
+}
+
+// NewValidationRunDAO creates a new validation run DAO
+func NewValidationRunDAO(querier database.Querier) *validationRunDAO {
+	return &validationRunDAO{querier: querier}
+}
+
+// ListValidationRuns lists the validation runs, matching the given filter
+// It returns a page of validation runs, along with the cursor for the next page
+func (dm *validationRunDAO) ListValidationRuns(ctx context.Context, filter entity.ValidationRunFilter) (*entity.ValidationRunPage, error) {
+	if filter.Limit <= 0 {
+		return nil, entity.ErrInvalidLimit
+	}
+
+	// Fetch one extra entry to find out if there is a next page
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	validationRuns, err := dm.querier.SelectValidationRunsByFilter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("selecting validation runs by filter: %w", err)
+	}
+
+	page := entity.ValidationRunPage{ValidationRuns: validationRuns}
+	if len(validationRuns) > limit {
+		page.ValidationRuns = validationRuns[:limit]
+		page.NextCursor = page.ValidationRuns[limit-1].ID
+	}
+
+	return &page, nil
+}
+
+// GetValidationRun reads a validation run
+// It returns an error if the validation run does not exist
+func (dm *validationRunDAO) GetValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error) {
+	validationRun, err := dm.querier.SelectValidationRun(ctx, validationRunId)
+	if err != nil {
+		return nil, fmt.Errorf("selecting validation run: %w", err)
+	}
+	if validationRun == nil {
+		return nil, entity.ErrValidationRunNotFound
+	}
+
+	return validationRun, nil
+}
@@ -0,0 +1,99 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/test_helpers"
+)
+
+func TestListValidationRunsSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewValidationRunDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	// One extra entry is fetched to detect the next page
+	mockQuerier.On("SelectValidationRunsByFilter", ctx, entity.ValidationRunFilter{Cursor: 10, Limit: 3}).Return([]entity.ValidationRun{
+		{ID: 9}, {ID: 8}, {ID: 7},
+	}, nil)
+
+	page, err := instance.ListValidationRuns(ctx, entity.ValidationRunFilter{Cursor: 10, Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.ValidationRuns, 2)
+	assert.Equal(t, 8, page.NextCursor)
+	mockQuerier.AssertExpectations(t)
+}
+
+func TestListValidationRunsLastPage(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewValidationRunDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("SelectValidationRunsByFilter", ctx, entity.ValidationRunFilter{Limit: 3}).Return([]entity.ValidationRun{{ID: 1}}, nil)
+
+	page, err := instance.ListValidationRuns(ctx, entity.ValidationRunFilter{Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.ValidationRuns, 1)
+	assert.Zero(t, page.NextCursor)
+}
+
+func TestListValidationRunsInvalidLimit(t *testing.T) {
+	instance := NewValidationRunDAO(test_helpers.NewMockQuerier())
+
+	_, err := instance.ListValidationRuns(context.TODO(), entity.ValidationRunFilter{})
+
+	assert.ErrorIs(t, err, entity.ErrInvalidLimit)
+}
+
+func TestGetValidationRunSuccess(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewValidationRunDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("SelectValidationRun", ctx, 5).Return(&entity.ValidationRun{ID: 5, UsersProcessed: 3}, nil)
+
+	validationRun, err := instance.GetValidationRun(ctx, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, validationRun.UsersProcessed)
+}
+
+func TestGetValidationRunNotFound(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewValidationRunDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("SelectValidationRun", ctx, 5).Return(nil, nil)
+
+	_, err := instance.GetValidationRun(ctx, 5)
+
+	assert.ErrorIs(t, err, entity.ErrValidationRunNotFound)
+}
+
+func TestGetValidationRunDatabaseError(t *testing.T) {
+	mockQuerier := test_helpers.NewMockQuerier()
+
+	instance := NewValidationRunDAO(mockQuerier)
+
+	ctx := context.TODO()
+
+	mockQuerier.On("SelectValidationRun", ctx, 5).Return(nil, errors.New("database error"))
+
+	_, err := instance.GetValidationRun(ctx, 5)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, entity.ErrValidationRunNotFound)
+}
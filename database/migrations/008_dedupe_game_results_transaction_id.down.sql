@@ -0,0 +1 @@
+-- The duplicate transaction IDs are not restored, the renamed game results keep the original transaction ID as prefix
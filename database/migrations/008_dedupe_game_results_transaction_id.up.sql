@@ -0,0 +1,13 @@
+-- Transaction IDs are made unique across all the sources, the unique index being built concurrently by a later migration
+-- The game results sharing a transaction ID are all kept, their balance changes being already applied:
+-- the oldest one keeps the transaction ID, the others are renamed "<transaction ID>#duplicate-<game result ID>"
+UPDATE game_results
+SET transaction_id = game_results.transaction_id || '#duplicate-' || game_results.id
+FROM (
+    SELECT transaction_id, MIN(id) AS kept_id
+    FROM game_results
+    GROUP BY transaction_id
+    HAVING COUNT(*) > 1
+) AS duplicates
+WHERE game_results.transaction_id = duplicates.transaction_id
+AND game_results.id <> duplicates.kept_id;
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS game_results_notify_pending ON game_results;
+DROP FUNCTION IF EXISTS notify_pending_game_results();
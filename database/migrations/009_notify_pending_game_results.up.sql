@@ -0,0 +1,13 @@
+-- Notify the validators of each user with new game results waiting for validation
+-- The notifications are delivered on commit, once per user and transaction
+CREATE OR REPLACE FUNCTION notify_pending_game_results() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('game_results_pending', NEW.user_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS game_results_notify_pending ON game_results;
+CREATE TRIGGER game_results_notify_pending
+    AFTER INSERT ON game_results
+    FOR EACH ROW EXECUTE FUNCTION notify_pending_game_results();
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS validation_runs (
+    id                SERIAL PRIMARY KEY,
+    started_at        TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    finished_at       TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    users_processed   INTEGER NOT NULL DEFAULT 0,
+    results_approved  INTEGER NOT NULL DEFAULT 0,
+    results_canceled  INTEGER NOT NULL DEFAULT 0,
+    balance_delta     DECIMAL(12,2) NOT NULL DEFAULT 0.00,
+    error             VARCHAR NULL
+);
+
+CREATE INDEX IF NOT EXISTS validation_runs_pxt_started_at ON validation_runs (started_at);
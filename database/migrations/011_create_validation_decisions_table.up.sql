@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS validation_decisions (
+    id                 SERIAL PRIMARY KEY,
+    game_result_id     INTEGER NOT NULL, /* DO NOT make it a Referential Integrity Constraint for performance reasons ONLY */
+    decision           validation_statuses NOT NULL,
+    policy             VARCHAR NOT NULL,
+    reason_code        VARCHAR NOT NULL,
+    balance_before     DECIMAL(10,2) NOT NULL,
+    balance_after      DECIMAL(10,2) NOT NULL,
+    validation_run_id  INTEGER NOT NULL,
+    created_at         TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS validation_decisions_pxt_game_result_id ON validation_decisions (game_result_id);
+CREATE INDEX IF NOT EXISTS validation_decisions_pxt_validation_run_id ON validation_decisions (validation_run_id);
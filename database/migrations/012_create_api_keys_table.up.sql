@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id                  SERIAL PRIMARY KEY,
+    prefix              VARCHAR NOT NULL,
+    key_hash            VARCHAR NOT NULL,
+    transaction_source  transaction_sources NOT NULL,
+    description         VARCHAR NOT NULL DEFAULT '',
+    created_at          TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    expires_at          TIMESTAMP(6) WITHOUT TIME ZONE NULL,
+    revoked_at          TIMESTAMP(6) WITHOUT TIME ZONE NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_uq_key_hash ON api_keys (key_hash);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS request_nonces;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS signing_key;
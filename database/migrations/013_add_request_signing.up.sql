@@ -0,0 +1,10 @@
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS signing_key VARCHAR NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS request_nonces (
+    api_key_id  INTEGER NOT NULL,
+    nonce       VARCHAR NOT NULL,
+    expires_at  TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    PRIMARY KEY (api_key_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS request_nonces_pxt_expires_at ON request_nonces (expires_at);
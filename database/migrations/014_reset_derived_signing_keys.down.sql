@@ -0,0 +1 @@
+-- The derived signing keys are not restored, they can be computed by anyone knowing the api key
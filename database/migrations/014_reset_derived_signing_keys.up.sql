@@ -0,0 +1,3 @@
+-- The signing keys were derived from the api keys, sent in clear along with every request, so anyone seeing a request could sign others
+-- They are dropped, the keys have to be rotated to get a random signing key
+UPDATE api_keys SET signing_key = '' WHERE signing_key <> '';
@@ -0,0 +1 @@
+-- The internal error messages of the validation runs are not restored
@@ -0,0 +1,2 @@
+-- The errors of the validation runs held the internal error messages, only the failure is kept
+UPDATE validation_runs SET error = 'validation failed' WHERE error IS NOT NULL AND error <> 'validation failed';
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS game_results_uq_transaction_id;
@@ -0,0 +1,4 @@
+-- Built concurrently, without blocking the writes, so on its own: it can not run within a transaction
+-- Should it fail, the invalid index left behind has to be dropped with "DROP INDEX CONCURRENTLY game_results_uq_transaction_id",
+-- and the migration forced back to 15 to run again, see the README
+CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS game_results_uq_transaction_id ON game_results (transaction_id);
@@ -0,0 +1 @@
+CREATE INDEX CONCURRENTLY IF NOT EXISTS game_results_pxt_transaction_id ON game_results (transaction_id);
@@ -0,0 +1,2 @@
+-- Replaced by the unique index
+DROP INDEX CONCURRENTLY IF EXISTS game_results_pxt_transaction_id;
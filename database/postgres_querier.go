@@ -5,17 +5,19 @@ import (
 	"database/sql"
 	"embed"
 	"errors"
+	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/google/uuid"
 	"github.com/ildomm/cceab/entity"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
-	"log"
+	"github.com/lib/pq"
+	"log/slog"
 	"net/url"
 	"time"
 )
@@ -39,7 +41,7 @@ func NewPostgresQuerier(ctx context.Context, url string) (*PostgresQuerier, erro
 	if err != nil {
 		return &querier, err
 	}
-	log.Print("opened database connection")
+	slog.Info("opened database connection")
 
 	// Ping the database to check that the connection is actually working
 	err = querier.dbConn.Ping()
@@ -52,14 +54,14 @@ func NewPostgresQuerier(ctx context.Context, url string) (*PostgresQuerier, erro
 	if err != nil {
 		return &querier, err
 	}
-	log.Print("database migration complete")
+	slog.Info("database migration complete")
 
 	return &querier, nil
 }
 
 func (q *PostgresQuerier) Close() {
 	q.dbConn.Close()
-	log.Print("closed database connection")
+	slog.Info("closed database connection")
 }
 
 var (
@@ -116,6 +118,32 @@ func (q *PostgresQuerier) migrationsURL() (string, error) {
 	return url.String(), nil
 }
 
+// Ping checks the database is reachable
+func (q *PostgresQuerier) Ping(ctx context.Context) error {
+	return q.dbConn.PingContext(ctx)
+}
+
+var selectMigrationVersionSQL = fmt.Sprintf(`SELECT version, dirty FROM %s LIMIT 1`, CustomMigrationValue)
+
+// MigrationVersion returns the version of the last migration applied, and whether it failed halfway
+func (q *PostgresQuerier) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var migration struct {
+		Version uint `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+
+	err := q.dbConn.GetContext(ctx, &migration, selectMigrationVersionSQL)
+	if err != nil {
+		return 0, false, err
+	}
+	return migration.Version, migration.Dirty, nil
+}
+
+// Stats returns the statistics of the connection pool
+func (q *PostgresQuerier) Stats() sql.DBStats {
+	return q.dbConn.Stats()
+}
+
 ////////////////////////////////// Database Querier standard operations /////////////////////////////////////////////////////////
 
 // WithTransaction creates a new transaction and handles rollback/commit based on the
@@ -134,8 +162,10 @@ func (q *PostgresQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx)
 			_ = tx.Rollback()
 			panic(p)
 		} else if err != nil {
-			// something went wrong, rollback
-			err = tx.Rollback()
+			// something went wrong, rollback, keeping the original error
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
 		} else {
 			// all good, commit
 			err = tx.Commit()
@@ -147,13 +177,30 @@ func (q *PostgresQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx)
 	return err
 }
 
+// uniqueViolationCode is the Postgres error code raised when a unique index is violated
+const uniqueViolationCode = "23505"
+
+// isUniqueViolation tells if the error was raised by the given unique index
+func isUniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == constraint
+	}
+	return false
+}
+
 ////////////////////////////////// Database Querier domain operations /////////////////////////////////////////////////////////
 
 const insertGameResultSQL = `
 	INSERT INTO game_results ( user_id, game_status, validation_status, transaction_source, transaction_id, amount, created_at)
 	VALUES                 ( $1,      $2,          $3,                $4,                 $5,             $6,     $7)
+	ON CONFLICT (transaction_id) DO NOTHING
 	RETURNING id`
 
+// InsertGameResult inserts the game result
+// It returns ErrTransactionIdExists when another game result was already recorded under the same
+// transaction ID, even by a concurrent transaction. The db transaction is left usable, so the other
+// game results of the transaction can still be recorded
 func (q *PostgresQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (int, error) {
 	var id int
 
@@ -169,26 +216,79 @@ func (q *PostgresQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gam
 		gameResult.Amount,
 		gameResult.CreatedAt)
 
+	// Nothing is returned when the transaction ID conflicts
+	if errors.Is(err, sql.ErrNoRows) {
+		return id, entity.ErrTransactionIdExists
+	}
 	return id, err
 }
 
-const lockUserRowStep1SQL = `LOCK TABLE users IN ROW EXCLUSIVE MODE;`
-const lockUserRowStep2SQL = `SELECT * FROM users WHERE id = $1 FOR UPDATE;`
+const insertUserSQL = `
+	INSERT INTO users ( email, balance, games_result_validated, disabled, created_at)
+	VALUES            ( $1,    $2,      $3,                     $4,       $5)
+	RETURNING id`
 
-func (q *PostgresQuerier) LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) error {
-	_, err := txn.ExecContext(ctx, lockUserRowStep1SQL)
-	if err != nil {
-		return err
-	}
+// usersEmailIndex is the unique index enforcing one user per email
+const usersEmailIndex = "users_pxt_email"
 
-	_, err = txn.ExecContext(ctx, lockUserRowStep2SQL, userId)
-	if err != nil {
-		return err
+func (q *PostgresQuerier) InsertUser(ctx context.Context, user entity.User) (uuid.UUID, error) {
+	var id uuid.UUID
+
+	err := q.dbConn.GetContext(
+		ctx,
+		&id,
+		insertUserSQL,
+		user.Email,
+		user.Balance,
+		user.GamesResultValidated,
+		user.Disabled,
+		user.CreatedAt)
+
+	if isUniqueViolation(err, usersEmailIndex) {
+		return id, entity.ErrUserEmailExists
 	}
+	return id, err
+}
+
+const updateUserProfileSQL = `
+	UPDATE users
+	SET 
+		email = :email,
+		disabled = :disabled
+	WHERE id = :id`
+
+func (q *PostgresQuerier) UpdateUser(ctx context.Context, txn sqlx.Tx, user entity.User) error {
+	_, err := txn.NamedExecContext(ctx, updateUserProfileSQL, user)
 
+	if isUniqueViolation(err, usersEmailIndex) {
+		return entity.ErrUserEmailExists
+	}
 	return err
 }
 
+const lockUserRowSQL = `SELECT * FROM users WHERE id = $1 FOR UPDATE`
+
+// LockUserRow locks the user row until the end of the transaction, returning the locked user
+// Only transactions on the same user wait for each other
+func (q *PostgresQuerier) LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) (*entity.User, error) {
+	var user entity.User
+
+	err := txn.GetContext(
+		ctx,
+		&user,
+		lockUserRowSQL,
+		userId)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
 const selectUserSQL = `SELECT * FROM users WHERE id = $1`
 
 func (q *PostgresQuerier) SelectUser(ctx context.Context, userId uuid.UUID) (*entity.User, error) {
@@ -224,6 +324,111 @@ func (q *PostgresQuerier) SelectUsersByValidationStatus(ctx context.Context, val
 	return users, err
 }
 
+// The users waiting the longest are claimed first, skipping the users claimed by other transactions
+const claimUserForValidationSQL = `
+	SELECT * FROM users
+	WHERE games_result_validated = FALSE
+	AND id <> ALL($1::uuid[])
+	ORDER BY last_game_result_at ASC NULLS FIRST
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED`
+
+// ClaimUserForValidation locks a user waiting for validation until the end of the transaction, returning it
+// Users already locked by other transactions are skipped, so concurrent validators never claim the same user,
+// and so are the excluded users, whose validation failed earlier in the round
+// It returns nil when there is no user left to claim
+func (q *PostgresQuerier) ClaimUserForValidation(ctx context.Context, txn sqlx.Tx, excludedUserIds []uuid.UUID) (*entity.User, error) {
+	var user entity.User
+
+	excluded := make([]string, len(excludedUserIds))
+	for i, userId := range excludedUserIds {
+		excluded[i] = userId.String()
+	}
+
+	err := txn.GetContext(
+		ctx,
+		&user,
+		claimUserForValidationSQL,
+		excluded)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
+// PendingValidationsChannel is notified with the ID of each user with new game results waiting for validation
+const PendingValidationsChannel = "game_results_pending"
+
+// ListenPendingValidations listens to the users with new game results waiting for validation, until the context is done
+// The returned channel receives the notified user IDs, and uuid.Nil when notifications may have been missed,
+// after a connection loss
+func (q *PostgresQuerier) ListenPendingValidations(ctx context.Context) (<-chan uuid.UUID, error) {
+	listener := pq.NewListener(q.dbURL, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.WarnContext(ctx, "error listening to pending validations", "error", err)
+		}
+	})
+
+	if err := listener.Listen(PendingValidationsChannel); err != nil {
+		listener.Close() //nolint:all
+		return nil, err
+	}
+
+	userIds := make(chan uuid.UUID)
+	go func() {
+		defer close(userIds)
+		defer listener.Close() //nolint:all
+
+		for {
+			var userId uuid.UUID
+
+			select {
+			case <-ctx.Done():
+				return
+			case notification := <-listener.Notify:
+				// A nil notification follows a reconnection
+				if notification != nil {
+					var err error
+					if userId, err = uuid.Parse(notification.Extra); err != nil {
+						slog.WarnContext(ctx, "invalid pending validation notification", "notification", notification.Extra, "error", err)
+						continue
+					}
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case userIds <- userId:
+			}
+		}
+	}()
+
+	return userIds, nil
+}
+
+const sumPendingAmountByUserSQL = `
+	SELECT COALESCE(SUM(CASE WHEN game_status = 'win' THEN amount ELSE -amount END), 0)
+	FROM game_results
+	WHERE user_id = $1 AND validation_status = 'pending'`
+
+func (q *PostgresQuerier) SumPendingAmountByUser(ctx context.Context, userId uuid.UUID) (entity.Money, error) {
+	var amount entity.Money
+
+	err := q.dbConn.GetContext(
+		ctx,
+		&amount,
+		sumPendingAmountByUserSQL,
+		userId)
+
+	return amount, err
+}
+
 const selectCheckTransactionSQL = `SELECT count(*) FROM game_results WHERE transaction_id = $1`
 
 func (q *PostgresQuerier) CheckTransactionID(ctx context.Context, transactionId string) (bool, error) {
@@ -240,6 +445,27 @@ func (q *PostgresQuerier) CheckTransactionID(ctx context.Context, transactionId
 	}
 }
 
+const selectGameResultByTransactionIDSQL = `SELECT * FROM game_results WHERE transaction_id = $1 ORDER BY id LIMIT 1`
+
+func (q *PostgresQuerier) SelectGameResultByTransactionID(ctx context.Context, transactionId string) (*entity.GameResult, error) {
+	var gameResult entity.GameResult
+
+	err := q.dbConn.GetContext(
+		ctx,
+		&gameResult,
+		selectGameResultByTransactionIDSQL,
+		transactionId)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &gameResult, nil
+}
+
 const selectGameResultsByUserSQL = `SELECT * FROM game_results WHERE user_id = $1 AND validation_status = $2 ORDER BY created_at DESC`
 
 func (q *PostgresQuerier) SelectGameResultsByUser(ctx context.Context, userId uuid.UUID, validationStatus entity.ValidationStatus) ([]entity.GameResult, error) {
@@ -255,6 +481,55 @@ func (q *PostgresQuerier) SelectGameResultsByUser(ctx context.Context, userId uu
 	return gameResults, err
 }
 
+const selectGameResultsByFilterSQL = `SELECT * FROM game_results WHERE user_id = $1`
+
+func (q *PostgresQuerier) SelectGameResultsByFilter(ctx context.Context, filter entity.GameResultFilter) ([]entity.GameResult, error) {
+	var gameResults []entity.GameResult
+
+	query := selectGameResultsByFilterSQL
+	args := []interface{}{filter.UserID}
+
+	// Each optional filter is appended as a new positional parameter
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND %s $%d", condition, len(args))
+	}
+
+	if filter.ValidationStatus != nil {
+		where("validation_status =", *filter.ValidationStatus)
+	}
+	if filter.GameStatus != nil {
+		where("game_status =", *filter.GameStatus)
+	}
+	if filter.TransactionSource != nil {
+		where("transaction_source =", *filter.TransactionSource)
+	}
+	if filter.CreatedFrom != nil {
+		where("created_at >=", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where("created_at <=", *filter.CreatedTo)
+	}
+	if filter.Cursor > 0 {
+		where("id <", filter.Cursor)
+	}
+
+	query += " ORDER BY id DESC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	err := q.dbConn.SelectContext(
+		ctx,
+		&gameResults,
+		query,
+		args...)
+
+	return gameResults, err
+}
+
 const updateUserSQL = `
 	UPDATE users
 	SET 
@@ -263,7 +538,7 @@ const updateUserSQL = `
 		last_game_result_at = :last_game_result_at
 	WHERE id = :id`
 
-func (q *PostgresQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance float64, validationStatus bool) error {
+func (q *PostgresQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance entity.Money, validationStatus bool) error {
 	user := entity.User{
 		ID:                   userId,
 		Balance:              balance,
@@ -292,3 +567,279 @@ func (q *PostgresQuerier) UpdateGameResult(ctx context.Context, txn sqlx.Tx, gam
 
 	return err
 }
+
+const insertValidationRunSQL = `
+	INSERT INTO validation_runs ( started_at, finished_at, users_processed, results_approved, results_canceled, balance_delta, error)
+	VALUES                      ( $1,         $2,          $3,              $4,               $5,               $6,            $7)
+	RETURNING id`
+
+func (q *PostgresQuerier) InsertValidationRun(ctx context.Context, validationRun entity.ValidationRun) (int, error) {
+	var id int
+
+	err := q.dbConn.GetContext(
+		ctx,
+		&id,
+		insertValidationRunSQL,
+		validationRun.StartedAt,
+		validationRun.FinishedAt,
+		validationRun.UsersProcessed,
+		validationRun.ResultsApproved,
+		validationRun.ResultsCanceled,
+		validationRun.BalanceDelta,
+		validationRun.Error)
+
+	return id, err
+}
+
+const selectValidationRunSQL = `SELECT * FROM validation_runs WHERE id = $1`
+
+func (q *PostgresQuerier) SelectValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error) {
+	var validationRun entity.ValidationRun
+
+	err := q.dbConn.GetContext(
+		ctx,
+		&validationRun,
+		selectValidationRunSQL,
+		validationRunId)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &validationRun, nil
+}
+
+const selectValidationRunsByFilterSQL = `SELECT * FROM validation_runs WHERE TRUE`
+
+func (q *PostgresQuerier) SelectValidationRunsByFilter(ctx context.Context, filter entity.ValidationRunFilter) ([]entity.ValidationRun, error) {
+	var validationRuns []entity.ValidationRun
+
+	query := selectValidationRunsByFilterSQL
+	var args []interface{}
+
+	// Each optional filter is appended as a new positional parameter
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND %s $%d", condition, len(args))
+	}
+
+	if filter.StartedFrom != nil {
+		where("started_at >=", *filter.StartedFrom)
+	}
+	if filter.StartedTo != nil {
+		where("started_at <=", *filter.StartedTo)
+	}
+	if filter.Cursor > 0 {
+		where("id <", filter.Cursor)
+	}
+
+	query += " ORDER BY id DESC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	err := q.dbConn.SelectContext(
+		ctx,
+		&validationRuns,
+		query,
+		args...)
+
+	return validationRuns, err
+}
+
+const updateValidationRunSQL = `
+	UPDATE validation_runs
+	SET 
+		finished_at = :finished_at,
+		users_processed = :users_processed,
+		results_approved = :results_approved,
+		results_canceled = :results_canceled,
+		balance_delta = :balance_delta,
+		error = :error
+	WHERE id = :id`
+
+func (q *PostgresQuerier) UpdateValidationRun(ctx context.Context, validationRun entity.ValidationRun) error {
+	_, err := q.dbConn.NamedExecContext(ctx, updateValidationRunSQL, validationRun)
+
+	return err
+}
+
+const insertValidationDecisionSQL = `
+	INSERT INTO validation_decisions ( game_result_id, decision, policy, reason_code, balance_before, balance_after, validation_run_id, created_at)
+	VALUES                           ( $1,             $2,       $3,     $4,          $5,             $6,            $7,                $8)
+	RETURNING id`
+
+// InsertValidationDecision inserts the validation decision of a game result
+// It must be part of the transaction updating the game result, so both are recorded or none
+func (q *PostgresQuerier) InsertValidationDecision(ctx context.Context, txn sqlx.Tx, validationDecision entity.ValidationDecision) (int, error) {
+	var id int
+
+	err := txn.GetContext(
+		ctx,
+		&id,
+		insertValidationDecisionSQL,
+		validationDecision.GameResultID,
+		validationDecision.Decision,
+		validationDecision.Policy,
+		validationDecision.Reason,
+		validationDecision.BalanceBefore,
+		validationDecision.BalanceAfter,
+		validationDecision.ValidationRunID,
+		validationDecision.CreatedAt)
+
+	return id, err
+}
+
+const selectValidationDecisionsByGameResultsSQL = `SELECT * FROM validation_decisions WHERE game_result_id = ANY($1) ORDER BY id`
+
+func (q *PostgresQuerier) SelectValidationDecisionsByGameResults(ctx context.Context, gameResultIds []int) ([]entity.ValidationDecision, error) {
+	var validationDecisions []entity.ValidationDecision
+
+	err := q.dbConn.SelectContext(
+		ctx,
+		&validationDecisions,
+		selectValidationDecisionsByGameResultsSQL,
+		gameResultIds)
+
+	return validationDecisions, err
+}
+
+const insertAPIKeySQL = `
+	INSERT INTO api_keys ( prefix, key_hash, signing_key, transaction_source, description, created_at, expires_at, revoked_at)
+	VALUES               ( $1,     $2,       $3,          $4,                 $5,          $6,         $7,         $8)
+	RETURNING id`
+
+func (q *PostgresQuerier) InsertAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) (int, error) {
+	var id int
+
+	err := txn.GetContext(
+		ctx,
+		&id,
+		insertAPIKeySQL,
+		apiKey.Prefix,
+		apiKey.KeyHash,
+		apiKey.SigningKey,
+		apiKey.TransactionSource,
+		apiKey.Description,
+		apiKey.CreatedAt,
+		apiKey.ExpiresAt,
+		apiKey.RevokedAt)
+
+	return id, err
+}
+
+const updateAPIKeySQL = `
+	UPDATE api_keys
+	SET 
+		description = :description,
+		expires_at = :expires_at,
+		revoked_at = :revoked_at
+	WHERE id = :id`
+
+func (q *PostgresQuerier) UpdateAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) error {
+	_, err := txn.NamedExecContext(ctx, updateAPIKeySQL, apiKey)
+
+	return err
+}
+
+const lockAPIKeyRowSQL = `SELECT * FROM api_keys WHERE id = $1 FOR UPDATE`
+
+// LockAPIKeyRow locks the api key row until the end of the transaction, returning the locked api key
+// Concurrent rotations of the same key wait for each other, so only one of them replaces the key
+func (q *PostgresQuerier) LockAPIKeyRow(ctx context.Context, txn sqlx.Tx, apiKeyId int) (*entity.APIKey, error) {
+	var apiKey entity.APIKey
+
+	err := txn.GetContext(
+		ctx,
+		&apiKey,
+		lockAPIKeyRowSQL,
+		apiKeyId)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &apiKey, nil
+}
+
+const selectAPIKeyByHashSQL = `SELECT * FROM api_keys WHERE key_hash = $1`
+
+func (q *PostgresQuerier) SelectAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	var apiKey entity.APIKey
+
+	err := q.dbConn.GetContext(
+		ctx,
+		&apiKey,
+		selectAPIKeyByHashSQL,
+		keyHash)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &apiKey, nil
+}
+
+const selectAPIKeysSQL = `SELECT * FROM api_keys ORDER BY id`
+
+func (q *PostgresQuerier) SelectAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	var apiKeys []entity.APIKey
+
+	err := q.dbConn.SelectContext(
+		ctx,
+		&apiKeys,
+		selectAPIKeysSQL)
+
+	return apiKeys, err
+}
+
+// An expired nonce, not purged yet, is taken over, so only the live nonces conflict
+const insertRequestNonceSQL = `
+	INSERT INTO request_nonces ( api_key_id, nonce, expires_at)
+	VALUES                     ( $1,         $2,    $3)
+	ON CONFLICT (api_key_id, nonce) DO UPDATE
+	SET expires_at = EXCLUDED.expires_at
+	WHERE request_nonces.expires_at <= $4`
+
+// InsertRequestNonce records the nonce of a signed request, until it expires
+// It returns false if the nonce was already recorded for the api key, and has not expired yet
+func (q *PostgresQuerier) InsertRequestNonce(ctx context.Context, apiKeyId int, nonce string, expiresAt time.Time) (bool, error) {
+	result, err := q.dbConn.ExecContext(
+		ctx,
+		insertRequestNonceSQL,
+		apiKeyId,
+		nonce,
+		expiresAt,
+		time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	inserted, err := result.RowsAffected()
+	return inserted == 1, err
+}
+
+const deleteExpiredRequestNoncesSQL = `DELETE FROM request_nonces WHERE expires_at <= $1`
+
+func (q *PostgresQuerier) DeleteExpiredRequestNonces(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.dbConn.ExecContext(
+		ctx,
+		deleteExpiredRequestNoncesSQL,
+		before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -2,7 +2,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/google/uuid"
 	"github.com/ildomm/cceab/entity"
 	"github.com/ildomm/cceab/test_helpers"
@@ -73,6 +77,27 @@ func setupTestQuerier(t *testing.T) (context.Context, func(t *testing.T), *Postg
 	}, q
 }
 
+func TestDatabaseHealth(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	t.Run("Ping_Success", func(t *testing.T) {
+		require.NoError(t, q.Ping(ctx))
+	})
+
+	t.Run("MigrationVersion_Success", func(t *testing.T) {
+		version, dirty, err := q.MigrationVersion(ctx)
+		require.NoError(t, err)
+		require.NotZero(t, version, "The migrations should be applied")
+		require.False(t, dirty)
+	})
+
+	t.Run("Stats_Success", func(t *testing.T) {
+		stats := q.Stats()
+		require.GreaterOrEqual(t, stats.OpenConnections, 1)
+	})
+}
+
 func TestDatabaseWithTransaction(t *testing.T) {
 	ctx, teardownTest, q := setupTestQuerier(t)
 	defer teardownTest(t)
@@ -84,8 +109,10 @@ func TestDatabaseWithTransaction(t *testing.T) {
 		// Start a transaction that is expected to WORK
 		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
 
-			err := q.LockUserRow(ctx, *txn, userId)
+			user, err := q.LockUserRow(ctx, *txn, userId)
 			require.NoError(t, err)
+			require.NotNil(t, user)
+			require.Equal(t, userId, user.ID)
 
 			// No error, then the db commit() will happen
 			return nil
@@ -93,6 +120,105 @@ func TestDatabaseWithTransaction(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("LockUserRow_UnknownUser", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+			user, err := q.LockUserRow(ctx, *txn, uuid.New())
+			require.NoError(t, err)
+			require.Nil(t, user)
+
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("LockUserRow_SerializesSameUser", func(t *testing.T) {
+		locked := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan error, 1)
+
+		// Hold the user row in a first transaction
+		go func() {
+			done <- q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+				if _, err := q.LockUserRow(ctx, *txn, userId); err != nil {
+					return err
+				}
+				close(locked)
+				<-release
+				return q.UpdateUserBalance(ctx, *txn, userId, 42_00, false)
+			})
+		}()
+		<-locked
+
+		// The second transaction must wait for the first one, then see its changes
+		waited := make(chan struct{})
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			close(waited)
+			close(release)
+		}()
+
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			user, err := q.LockUserRow(ctx, *txn, userId)
+			require.NoError(t, err)
+
+			select {
+			case <-waited:
+			default:
+				t.Error("LockUserRow should wait for the concurrent transaction")
+			}
+			require.Equal(t, entity.Money(42_00), user.Balance)
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("ClaimUserForValidation_SkipsClaimedUsers", func(t *testing.T) {
+		claimed := make(chan *entity.User)
+		release := make(chan struct{})
+		done := make(chan error, 1)
+
+		// Hold a claimed user in a first transaction
+		go func() {
+			done <- q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+				user, err := q.ClaimUserForValidation(ctx, *txn, nil)
+				if err != nil {
+					return err
+				}
+				claimed <- user
+				<-release
+				return nil
+			})
+		}()
+		first := <-claimed
+		require.NotNil(t, first)
+		require.False(t, first.GamesResultValidated.Bool)
+
+		// The second transaction must not wait, and claim another user
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			second, err := q.ClaimUserForValidation(ctx, *txn, nil)
+			require.NoError(t, err)
+			require.NotNil(t, second)
+			require.NotEqual(t, first.ID, second.ID)
+			return nil
+		})
+		require.NoError(t, err)
+
+		close(release)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("WithTransaction_RollbackKeepsError", func(t *testing.T) {
+		expectedErr := errors.New("expected failure")
+
+		// Start a transaction that is expected to FAIL
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return expectedErr
+		})
+		require.ErrorIs(t, err, expectedErr)
+	})
+
 	t.Run("InsertGameResult_Success", func(t *testing.T) {
 		gameResult := entity.GameResult{
 			UserID:            userId,
@@ -118,12 +244,89 @@ func TestDatabaseWithTransaction(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("ListenPendingValidations_NotifiedOnCommit", func(t *testing.T) {
+		listenCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		pendingValidations, err := q.ListenPendingValidations(listenCtx)
+		require.NoError(t, err)
+
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, entity.GameResult{
+				UserID:            userId,
+				GameStatus:        entity.GameStatusWin,
+				ValidationStatus:  entity.ValidationStatusPending,
+				TransactionSource: entity.TransactionSourceGame,
+				TransactionID:     uuid.New().String(),
+				Amount:            10,
+				CreatedAt:         time.Now(),
+			})
+			require.NoError(t, err)
+
+			// Nothing is notified before the commit
+			select {
+			case <-pendingValidations:
+				t.Error("ListenPendingValidations should not be notified before the commit")
+			case <-time.After(100 * time.Millisecond):
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		select {
+		case notified := <-pendingValidations:
+			require.Equal(t, userId, notified)
+		case <-time.After(5 * time.Second):
+			t.Error("ListenPendingValidations should be notified of the committed game result")
+		}
+
+		// The channel is closed once the context is done
+		cancel()
+		for range pendingValidations {
+		}
+	})
+
+	t.Run("InsertGameResult_TransactionIdExists", func(t *testing.T) {
+		gameResult := entity.GameResult{
+			UserID:            userId,
+			GameStatus:        entity.GameStatusLost,
+			ValidationStatus:  entity.ValidationStatusPending,
+			TransactionSource: entity.TransactionSourcePayment,
+			TransactionID:     "anything",
+			Amount:            20,
+			CreatedAt:         time.Now(),
+		}
+
+		// Start a transaction that is expected to FAIL, even from another source
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, gameResult)
+			return err
+		})
+		require.ErrorIs(t, err, entity.ErrTransactionIdExists)
+
+		// The conflict does not abort the transaction, the other game results can still be inserted
+		rollback := errors.New("rollback")
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, gameResult)
+			require.ErrorIs(t, err, entity.ErrTransactionIdExists)
+
+			gameResult.TransactionID = "anything-else"
+			id, err := q.InsertGameResult(ctx, *txn, gameResult)
+			require.NoError(t, err)
+			require.NotZero(t, id)
+
+			// Leave the game results of the user unchanged
+			return rollback
+		})
+		require.ErrorIs(t, err, rollback)
+	})
+
 	t.Run("UpdateUserBalance_Success", func(t *testing.T) {
 
 		// Start a transaction that is expected to WORK
 		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
 
-			err := q.UpdateUserBalance(ctx, *txn, userId, 100, true)
+			err := q.UpdateUserBalance(ctx, *txn, userId, 100_00, true)
 			require.NoError(t, err)
 
 			// No error, then the db commit() will happen
@@ -134,7 +337,7 @@ func TestDatabaseWithTransaction(t *testing.T) {
 		// Check the new balance
 		user, err := q.SelectUser(ctx, userId)
 		require.NoError(t, err)
-		require.Equal(t, 100.0, user.Balance)
+		require.Equal(t, entity.Money(100_00), user.Balance)
 	})
 }
 
@@ -151,6 +354,50 @@ func TestDatabaseBasicOperations(t *testing.T) {
 		require.Equal(t, userId, user.ID)
 	})
 
+	t.Run("InsertUser_Success", func(t *testing.T) {
+		user := entity.User{
+			Email:     "new.user@example.com",
+			Balance:   25,
+			CreatedAt: time.Now(),
+		}
+
+		id, err := q.InsertUser(ctx, user)
+		require.NoError(t, err)
+
+		inserted, err := q.SelectUser(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, user.Email, inserted.Email)
+		require.Equal(t, entity.Money(25_00), inserted.Balance)
+		require.False(t, inserted.Disabled)
+	})
+
+	t.Run("InsertUser_EmailExists", func(t *testing.T) {
+		_, err := q.InsertUser(ctx, entity.User{Email: "user1@example.com", CreatedAt: time.Now()})
+		require.ErrorIs(t, err, entity.ErrUserEmailExists)
+	})
+
+	t.Run("UpdateUser_Success", func(t *testing.T) {
+		otherUserId, err := uuid.Parse("99999999-9999-9999-9999-999999999999")
+		require.NoError(t, err)
+
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateUser(ctx, *txn, entity.User{ID: otherUserId, Email: "changed@example.com", Disabled: true})
+		})
+		require.NoError(t, err)
+
+		user, err := q.SelectUser(ctx, otherUserId)
+		require.NoError(t, err)
+		require.Equal(t, "changed@example.com", user.Email)
+		require.True(t, user.Disabled)
+	})
+
+	t.Run("UpdateUser_EmailExists", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateUser(ctx, *txn, entity.User{ID: userId, Email: "user2@example.com"})
+		})
+		require.ErrorIs(t, err, entity.ErrUserEmailExists)
+	})
+
 	t.Run("SelectUsersByValidationStatus_Success", func(t *testing.T) {
 		users, err := q.SelectUsersByValidationStatus(ctx, false)
 		require.NoError(t, err)
@@ -192,13 +439,25 @@ func TestDatabaseBasicOperations(t *testing.T) {
 		require.True(t, exist)
 	})
 
+	t.Run("SelectGameResultByTransactionID_Success", func(t *testing.T) {
+		gameResult, err := q.SelectGameResultByTransactionID(ctx, "anything")
+		require.NoError(t, err)
+		require.NotNil(t, gameResult)
+		require.Equal(t, userId, gameResult.UserID)
+		require.Equal(t, entity.Money(10_00), gameResult.Amount)
+
+		gameResult, err = q.SelectGameResultByTransactionID(ctx, "nothing")
+		require.NoError(t, err)
+		require.Nil(t, gameResult)
+	})
+
 	t.Run("SelectGameResultsByUser_Success", func(t *testing.T) {
 		gameResult := entity.GameResult{
 			UserID:            userId,
 			GameStatus:        entity.GameStatusWin,
 			ValidationStatus:  entity.ValidationStatusCanceled,
 			TransactionSource: entity.TransactionSourceServer,
-			TransactionID:     "anything",
+			TransactionID:     "canceled-anything",
 			Amount:            10,
 			CreatedAt:         time.Now(),
 		}
@@ -225,13 +484,101 @@ func TestDatabaseBasicOperations(t *testing.T) {
 		require.Len(t, gameResults, 0)
 	})
 
+	t.Run("SelectGameResultsByFilter_Success", func(t *testing.T) {
+		otherUserId, err := uuid.Parse("22222222-2222-2222-2222-222222222222")
+		require.NoError(t, err)
+
+		gameResults := []entity.GameResult{
+			{UserID: otherUserId, GameStatus: entity.GameStatusWin, ValidationStatus: entity.ValidationStatusPending, TransactionSource: entity.TransactionSourceGame},
+			{UserID: otherUserId, GameStatus: entity.GameStatusLost, ValidationStatus: entity.ValidationStatusPending, TransactionSource: entity.TransactionSourceGame},
+			{UserID: otherUserId, GameStatus: entity.GameStatusWin, ValidationStatus: entity.ValidationStatusAccepted, TransactionSource: entity.TransactionSourcePayment},
+		}
+
+		// Start a transaction that is expected to WORK
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			for i, gameResult := range gameResults {
+				gameResult.TransactionID = fmt.Sprintf("filter-%d", i)
+				gameResult.Amount = 10
+				gameResult.CreatedAt = time.Now()
+
+				_, err := q.InsertGameResult(ctx, *txn, gameResult)
+				require.NoError(t, err)
+			}
+
+			// No error, then the db commit() will happen
+			return nil
+		})
+		require.NoError(t, err)
+
+		all, err := q.SelectGameResultsByFilter(ctx, entity.GameResultFilter{UserID: otherUserId})
+		require.NoError(t, err)
+		require.Len(t, all, 3)
+		require.Greater(t, all[0].ID, all[1].ID, "game results should be ordered from the newest")
+
+		pending := entity.ValidationStatusPending
+		filtered, err := q.SelectGameResultsByFilter(ctx, entity.GameResultFilter{UserID: otherUserId, ValidationStatus: &pending})
+		require.NoError(t, err)
+		require.Len(t, filtered, 2)
+
+		source := entity.TransactionSourcePayment
+		filtered, err = q.SelectGameResultsByFilter(ctx, entity.GameResultFilter{UserID: otherUserId, TransactionSource: &source})
+		require.NoError(t, err)
+		require.Len(t, filtered, 1)
+
+		tomorrow := time.Now().AddDate(0, 0, 1)
+		filtered, err = q.SelectGameResultsByFilter(ctx, entity.GameResultFilter{UserID: otherUserId, CreatedFrom: &tomorrow})
+		require.NoError(t, err)
+		require.Len(t, filtered, 0)
+
+		page, err := q.SelectGameResultsByFilter(ctx, entity.GameResultFilter{UserID: otherUserId, Cursor: all[0].ID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		require.Equal(t, all[1].ID, page[0].ID)
+	})
+
+	t.Run("SumPendingAmountByUser_Success", func(t *testing.T) {
+		otherUserId, err := uuid.Parse("33333333-3333-3333-3333-333333333333")
+		require.NoError(t, err)
+
+		gameResults := []entity.GameResult{
+			{GameStatus: entity.GameStatusWin, ValidationStatus: entity.ValidationStatusPending, Amount: 30},
+			{GameStatus: entity.GameStatusLost, ValidationStatus: entity.ValidationStatusPending, Amount: 10},
+			{GameStatus: entity.GameStatusWin, ValidationStatus: entity.ValidationStatusAccepted, Amount: 100},
+		}
+
+		amount, err := q.SumPendingAmountByUser(ctx, otherUserId)
+		require.NoError(t, err)
+		require.Equal(t, entity.Money(0), amount)
+
+		// Start a transaction that is expected to WORK
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			for i, gameResult := range gameResults {
+				gameResult.UserID = otherUserId
+				gameResult.TransactionSource = entity.TransactionSourceGame
+				gameResult.TransactionID = fmt.Sprintf("pending-%d", i)
+				gameResult.CreatedAt = time.Now()
+
+				_, err := q.InsertGameResult(ctx, *txn, gameResult)
+				require.NoError(t, err)
+			}
+
+			// No error, then the db commit() will happen
+			return nil
+		})
+		require.NoError(t, err)
+
+		amount, err = q.SumPendingAmountByUser(ctx, otherUserId)
+		require.NoError(t, err)
+		require.Equal(t, entity.Money(20_00), amount)
+	})
+
 	t.Run("UpdateGameResult_Success", func(t *testing.T) {
 		gameResult := entity.GameResult{
 			UserID:            userId,
 			GameStatus:        entity.GameStatusWin,
 			ValidationStatus:  entity.ValidationStatusPending,
 			TransactionSource: entity.TransactionSourceServer,
-			TransactionID:     "anything",
+			TransactionID:     "updated-anything",
 			Amount:            10,
 			CreatedAt:         time.Now(),
 		}
@@ -253,3 +600,273 @@ func TestDatabaseBasicOperations(t *testing.T) {
 
 	})
 }
+
+func TestDatabaseValidationRuns(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	var ids []int
+	t.Run("InsertValidationRun_Success", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			id, err := q.InsertValidationRun(ctx, entity.ValidationRun{
+				StartedAt:       startedAt.Add(time.Duration(i) * time.Hour),
+				FinishedAt:      startedAt.Add(time.Duration(i)*time.Hour + time.Second),
+				UsersProcessed:  2,
+				ResultsApproved: 5,
+				ResultsCanceled: 1,
+				BalanceDelta:    -10_15,
+				Error:           sql.NullString{String: "failure", Valid: i == 2},
+			})
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+	})
+
+	t.Run("SelectValidationRun_Success", func(t *testing.T) {
+		validationRun, err := q.SelectValidationRun(ctx, ids[2])
+		require.NoError(t, err)
+		require.NotNil(t, validationRun)
+		require.Equal(t, 2, validationRun.UsersProcessed)
+		require.Equal(t, entity.Money(-10_15), validationRun.BalanceDelta)
+		require.Equal(t, "failure", validationRun.Error.String)
+	})
+
+	t.Run("SelectValidationRun_NotFound", func(t *testing.T) {
+		validationRun, err := q.SelectValidationRun(ctx, 999999)
+		require.NoError(t, err)
+		require.Nil(t, validationRun)
+	})
+
+	t.Run("SelectValidationRunsByFilter_Success", func(t *testing.T) {
+		validationRuns, err := q.SelectValidationRunsByFilter(ctx, entity.ValidationRunFilter{})
+		require.NoError(t, err)
+		require.Len(t, validationRuns, 3)
+		require.Equal(t, ids[2], validationRuns[0].ID, "The newest run should come first")
+
+		startedFrom := startedAt.Add(30 * time.Minute)
+		validationRuns, err = q.SelectValidationRunsByFilter(ctx, entity.ValidationRunFilter{StartedFrom: &startedFrom, Cursor: ids[2], Limit: 5})
+		require.NoError(t, err)
+		require.Len(t, validationRuns, 1)
+		require.Equal(t, ids[1], validationRuns[0].ID)
+	})
+	t.Run("UpdateValidationRun_Success", func(t *testing.T) {
+		validationRun, err := q.SelectValidationRun(ctx, ids[0])
+		require.NoError(t, err)
+
+		validationRun.UsersProcessed = 7
+		validationRun.BalanceDelta = 25_50
+		err = q.UpdateValidationRun(ctx, *validationRun)
+		require.NoError(t, err)
+
+		validationRun, err = q.SelectValidationRun(ctx, ids[0])
+		require.NoError(t, err)
+		require.Equal(t, 7, validationRun.UsersProcessed)
+		require.Equal(t, entity.Money(25_50), validationRun.BalanceDelta)
+	})
+
+	t.Run("InsertValidationDecision_Success", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			for _, gameResultId := range []int{1, 2} {
+				_, err := q.InsertValidationDecision(ctx, *txn, entity.ValidationDecision{
+					GameResultID:    gameResultId,
+					Decision:        entity.ValidationStatusCanceled,
+					Policy:          "odd_id",
+					Reason:          entity.ValidationReasonCanceledByPolicy,
+					BalanceBefore:   100_00,
+					BalanceAfter:    89_85,
+					ValidationRunID: ids[0],
+					CreatedAt:       time.Now(),
+				})
+				require.NoError(t, err)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		validationDecisions, err := q.SelectValidationDecisionsByGameResults(ctx, []int{2, 3})
+		require.NoError(t, err)
+		require.Len(t, validationDecisions, 1)
+		require.Equal(t, 2, validationDecisions[0].GameResultID)
+		require.Equal(t, entity.ValidationReasonCanceledByPolicy, validationDecisions[0].Reason)
+		require.Equal(t, entity.Money(89_85), validationDecisions[0].BalanceAfter)
+	})
+
+	t.Run("InsertValidationDecision_RolledBack", func(t *testing.T) {
+		expectedErr := errors.New("expected failure")
+
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertValidationDecision(ctx, *txn, entity.ValidationDecision{
+				GameResultID:    4,
+				Decision:        entity.ValidationStatusAccepted,
+				Policy:          "none",
+				Reason:          entity.ValidationReasonAcceptedByPolicy,
+				ValidationRunID: ids[0],
+				CreatedAt:       time.Now(),
+			})
+			require.NoError(t, err)
+			return expectedErr
+		})
+		require.ErrorIs(t, err, expectedErr)
+
+		validationDecisions, err := q.SelectValidationDecisionsByGameResults(ctx, []int{4})
+		require.NoError(t, err)
+		require.Empty(t, validationDecisions)
+	})
+}
+
+func TestDatabaseAPIKeys(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	var id int
+	t.Run("InsertAPIKey_Success", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			var err error
+			id, err = q.InsertAPIKey(ctx, *txn, entity.APIKey{
+				Prefix:            "cceab_abcdefgh",
+				KeyHash:           "hash-1",
+				SigningKey:        "signing-key-1",
+				TransactionSource: entity.TransactionSourceGame,
+				Description:       "slots",
+				CreatedAt:         time.Now(),
+			})
+			return err
+		})
+		require.NoError(t, err)
+		require.NotZero(t, id)
+	})
+
+	t.Run("InsertAPIKey_DuplicateHash", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertAPIKey(ctx, *txn, entity.APIKey{
+				Prefix:            "cceab_abcdefgh",
+				KeyHash:           "hash-1",
+				TransactionSource: entity.TransactionSourceServer,
+				CreatedAt:         time.Now(),
+			})
+			return err
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("SelectAPIKeyByHash_Success", func(t *testing.T) {
+		apiKey, err := q.SelectAPIKeyByHash(ctx, "hash-1")
+		require.NoError(t, err)
+		require.NotNil(t, apiKey)
+		require.Equal(t, id, apiKey.ID)
+		require.Equal(t, entity.TransactionSourceGame, apiKey.TransactionSource)
+		require.Equal(t, "signing-key-1", apiKey.SigningKey)
+		require.False(t, apiKey.RevokedAt.Valid)
+	})
+
+	t.Run("SelectAPIKeyByHash_NotFound", func(t *testing.T) {
+		apiKey, err := q.SelectAPIKeyByHash(ctx, "unknown")
+		require.NoError(t, err)
+		require.Nil(t, apiKey)
+	})
+
+	t.Run("UpdateAPIKey_Success", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			apiKey, err := q.LockAPIKeyRow(ctx, *txn, id)
+			require.NoError(t, err)
+			require.NotNil(t, apiKey)
+
+			apiKey.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			return q.UpdateAPIKey(ctx, *txn, *apiKey)
+		})
+		require.NoError(t, err)
+
+		apiKeys, err := q.SelectAPIKeys(ctx)
+		require.NoError(t, err)
+		require.Len(t, apiKeys, 1)
+		require.True(t, apiKeys[0].RevokedAt.Valid)
+	})
+
+	t.Run("InsertRequestNonce_Reused", func(t *testing.T) {
+		inserted, err := q.InsertRequestNonce(ctx, id, "nonce-1", time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.True(t, inserted)
+
+		inserted, err = q.InsertRequestNonce(ctx, id, "nonce-1", time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.False(t, inserted, "A live nonce should not be recorded twice")
+
+		// The same nonce is independent for another api key
+		inserted, err = q.InsertRequestNonce(ctx, id+1, "nonce-1", time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.True(t, inserted)
+	})
+
+	t.Run("InsertRequestNonce_Expired", func(t *testing.T) {
+		inserted, err := q.InsertRequestNonce(ctx, id, "nonce-2", time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+		require.True(t, inserted)
+
+		inserted, err = q.InsertRequestNonce(ctx, id, "nonce-2", time.Now().Add(time.Minute))
+		require.NoError(t, err)
+		require.True(t, inserted, "An expired nonce should be taken over")
+	})
+
+	t.Run("DeleteExpiredRequestNonces_Success", func(t *testing.T) {
+		_, err := q.InsertRequestNonce(ctx, id, "nonce-3", time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		deleted, err := q.DeleteExpiredRequestNonces(ctx, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+	})
+
+	t.Run("LockAPIKeyRow_NotFound", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			apiKey, err := q.LockAPIKeyRow(ctx, *txn, 999999)
+			require.NoError(t, err)
+			require.Nil(t, apiKey)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestDatabaseMigrationDedupesTransactionIds(t *testing.T) {
+	testDB := test_helpers.NewTestDatabase(t)
+	defer testDB.Close(t)
+	q := &PostgresQuerier{dbURL: testDB.ConnectionString(t) + "?sslmode=disable"}
+
+	migrationsURL, err := q.migrationsURL()
+	require.NoError(t, err)
+	d, err := iofs.New(fs, "migrations")
+	require.NoError(t, err)
+	m, err := migrate.NewWithSourceInstance("iofs", d, migrationsURL)
+	require.NoError(t, err)
+	defer m.Close()
+
+	// The game results recorded before the transaction IDs were unique
+	require.NoError(t, m.Migrate(7))
+
+	dbConn, err := sqlx.Open("pgx", q.dbURL)
+	require.NoError(t, err)
+	defer dbConn.Close()
+
+	_, err = dbConn.Exec(`
+		INSERT INTO game_results (id, user_id, game_status, validation_status, transaction_source, transaction_id, amount, created_at)
+		VALUES (1, uuid_generate_v4(), 'win', 'accepted', 'game', 'tx-1', 10, NOW()),
+		       (2, uuid_generate_v4(), 'win', 'accepted', 'game', 'tx-2', 10, NOW()),
+		       (3, uuid_generate_v4(), 'lost', 'pending', 'server', 'tx-1', 10, NOW()),
+		       (4, uuid_generate_v4(), 'win', 'pending', 'payment', 'tx-1', 10, NOW())`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Up())
+
+	// The game results are all kept, the oldest one keeping the transaction ID
+	var transactionIds []string
+	require.NoError(t, dbConn.Select(&transactionIds, `SELECT transaction_id FROM game_results ORDER BY id`))
+	assert.Equal(t, []string{"tx-1", "tx-2", "tx-1#duplicate-3", "tx-1#duplicate-4"}, transactionIds)
+
+	// The transaction IDs are unique from then on
+	_, err = dbConn.Exec(`
+		INSERT INTO game_results (user_id, game_status, validation_status, transaction_source, transaction_id, amount, created_at)
+		VALUES (uuid_generate_v4(), 'win', 'pending', 'game', 'tx-2', 10, NOW())`)
+	assert.ErrorContains(t, err, "game_results_uq_transaction_id")
+}
@@ -2,24 +2,54 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"github.com/google/uuid"
 	"github.com/ildomm/cceab/entity"
 	"github.com/jmoiron/sqlx"
+	"time"
 )
 
 type Querier interface {
 	Close()
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+	Stats() sql.DBStats
 	WithTransaction(ctx context.Context, fn func(*sqlx.Tx) error) (err error)
 
 	InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (int, error)
 
-	LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) error
+	InsertUser(ctx context.Context, user entity.User) (uuid.UUID, error)
+	UpdateUser(ctx context.Context, txn sqlx.Tx, user entity.User) error
+
+	LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) (*entity.User, error)
 	SelectUser(ctx context.Context, userId uuid.UUID) (*entity.User, error)
 	SelectUsersByValidationStatus(ctx context.Context, validationStatus bool) ([]entity.User, error)
+	ClaimUserForValidation(ctx context.Context, txn sqlx.Tx, excludedUserIds []uuid.UUID) (*entity.User, error)
+	ListenPendingValidations(ctx context.Context) (<-chan uuid.UUID, error)
+	SumPendingAmountByUser(ctx context.Context, userId uuid.UUID) (entity.Money, error)
 
 	CheckTransactionID(ctx context.Context, transactionId string) (bool, error)
+	SelectGameResultByTransactionID(ctx context.Context, transactionId string) (*entity.GameResult, error)
 	SelectGameResultsByUser(ctx context.Context, userId uuid.UUID, validationStatus entity.ValidationStatus) ([]entity.GameResult, error)
+	SelectGameResultsByFilter(ctx context.Context, filter entity.GameResultFilter) ([]entity.GameResult, error)
 
-	UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance float64, validationStatus bool) error
+	UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance entity.Money, validationStatus bool) error
 	UpdateGameResult(ctx context.Context, txn sqlx.Tx, gameResultId int, validationStatus entity.ValidationStatus) error
+
+	InsertValidationRun(ctx context.Context, validationRun entity.ValidationRun) (int, error)
+	UpdateValidationRun(ctx context.Context, validationRun entity.ValidationRun) error
+	SelectValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error)
+	SelectValidationRunsByFilter(ctx context.Context, filter entity.ValidationRunFilter) ([]entity.ValidationRun, error)
+
+	InsertValidationDecision(ctx context.Context, txn sqlx.Tx, validationDecision entity.ValidationDecision) (int, error)
+	SelectValidationDecisionsByGameResults(ctx context.Context, gameResultIds []int) ([]entity.ValidationDecision, error)
+
+	InsertAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) (int, error)
+	UpdateAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) error
+	LockAPIKeyRow(ctx context.Context, txn sqlx.Tx, apiKeyId int) (*entity.APIKey, error)
+	SelectAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	SelectAPIKeys(ctx context.Context) ([]entity.APIKey, error)
+
+	InsertRequestNonce(ctx context.Context, apiKeyId int, nonce string, expiresAt time.Time) (bool, error)
+	DeleteExpiredRequestNonces(ctx context.Context, before time.Time) (int64, error)
 }
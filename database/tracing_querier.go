@@ -0,0 +1,276 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/tracing"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
+)
+
+// TracingQuerier is a Querier recording a span, and a debug log, for each call to the Querier it wraps
+// The calls made within a transaction are recorded alongside the span of the transaction itself
+type TracingQuerier struct {
+	querier Querier
+	tracer  trace.Tracer
+}
+
+// NewTracingQuerier wraps the given Querier, recording its calls with the global tracer provider
+func NewTracingQuerier(querier Querier) *TracingQuerier {
+	return &TracingQuerier{
+		querier: querier,
+		tracer:  otel.Tracer("github.com/ildomm/cceab/database"),
+	}
+}
+
+// start starts the span of a call to the Querier
+// It returns the function ending the span, logging the call along with the error which ended it, if any
+func (q *TracingQuerier) start(ctx context.Context, operation string) (context.Context, func(error)) {
+	ctx, span := q.tracer.Start(ctx, "Querier."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(operation)),
+	)
+	start := time.Now()
+
+	return ctx, func(err error) {
+		tracing.EndSpan(span, err)
+
+		attrs := []any{"operation", operation, "duration_ms", time.Since(start).Milliseconds()}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		}
+		slog.DebugContext(ctx, "database call", attrs...)
+	}
+}
+
+func (q *TracingQuerier) Close() {
+	q.querier.Close()
+}
+
+func (q *TracingQuerier) Stats() sql.DBStats {
+	return q.querier.Stats()
+}
+
+func (q *TracingQuerier) Ping(ctx context.Context) error {
+	ctx, end := q.start(ctx, "Ping")
+	err := q.querier.Ping(ctx)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	ctx, end := q.start(ctx, "MigrationVersion")
+	version, dirty, err := q.querier.MigrationVersion(ctx)
+	end(err)
+	return version, dirty, err
+}
+
+func (q *TracingQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx) error) error {
+	ctx, end := q.start(ctx, "WithTransaction")
+	err := q.querier.WithTransaction(ctx, fn)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (int, error) {
+	ctx, end := q.start(ctx, "InsertGameResult")
+	id, err := q.querier.InsertGameResult(ctx, txn, gameResult)
+	end(err)
+	return id, err
+}
+
+func (q *TracingQuerier) InsertUser(ctx context.Context, user entity.User) (uuid.UUID, error) {
+	ctx, end := q.start(ctx, "InsertUser")
+	id, err := q.querier.InsertUser(ctx, user)
+	end(err)
+	return id, err
+}
+
+func (q *TracingQuerier) UpdateUser(ctx context.Context, txn sqlx.Tx, user entity.User) error {
+	ctx, end := q.start(ctx, "UpdateUser")
+	err := q.querier.UpdateUser(ctx, txn, user)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) (*entity.User, error) {
+	ctx, end := q.start(ctx, "LockUserRow")
+	user, err := q.querier.LockUserRow(ctx, txn, userId)
+	end(err)
+	return user, err
+}
+
+func (q *TracingQuerier) SelectUser(ctx context.Context, userId uuid.UUID) (*entity.User, error) {
+	ctx, end := q.start(ctx, "SelectUser")
+	user, err := q.querier.SelectUser(ctx, userId)
+	end(err)
+	return user, err
+}
+
+func (q *TracingQuerier) SelectUsersByValidationStatus(ctx context.Context, validationStatus bool) ([]entity.User, error) {
+	ctx, end := q.start(ctx, "SelectUsersByValidationStatus")
+	users, err := q.querier.SelectUsersByValidationStatus(ctx, validationStatus)
+	end(err)
+	return users, err
+}
+
+func (q *TracingQuerier) ClaimUserForValidation(ctx context.Context, txn sqlx.Tx, excludedUserIds []uuid.UUID) (*entity.User, error) {
+	ctx, end := q.start(ctx, "ClaimUserForValidation")
+	user, err := q.querier.ClaimUserForValidation(ctx, txn, excludedUserIds)
+	end(err)
+	return user, err
+}
+
+func (q *TracingQuerier) ListenPendingValidations(ctx context.Context) (<-chan uuid.UUID, error) {
+	// Only the set up of the listener is recorded, it then lasts as long as the context
+	_, end := q.start(ctx, "ListenPendingValidations")
+	pendingValidations, err := q.querier.ListenPendingValidations(ctx)
+	end(err)
+	return pendingValidations, err
+}
+
+func (q *TracingQuerier) SumPendingAmountByUser(ctx context.Context, userId uuid.UUID) (entity.Money, error) {
+	ctx, end := q.start(ctx, "SumPendingAmountByUser")
+	amount, err := q.querier.SumPendingAmountByUser(ctx, userId)
+	end(err)
+	return amount, err
+}
+
+func (q *TracingQuerier) CheckTransactionID(ctx context.Context, transactionId string) (bool, error) {
+	ctx, end := q.start(ctx, "CheckTransactionID")
+	exists, err := q.querier.CheckTransactionID(ctx, transactionId)
+	end(err)
+	return exists, err
+}
+
+func (q *TracingQuerier) SelectGameResultByTransactionID(ctx context.Context, transactionId string) (*entity.GameResult, error) {
+	ctx, end := q.start(ctx, "SelectGameResultByTransactionID")
+	gameResult, err := q.querier.SelectGameResultByTransactionID(ctx, transactionId)
+	end(err)
+	return gameResult, err
+}
+
+func (q *TracingQuerier) SelectGameResultsByUser(ctx context.Context, userId uuid.UUID, validationStatus entity.ValidationStatus) ([]entity.GameResult, error) {
+	ctx, end := q.start(ctx, "SelectGameResultsByUser")
+	gameResults, err := q.querier.SelectGameResultsByUser(ctx, userId, validationStatus)
+	end(err)
+	return gameResults, err
+}
+
+func (q *TracingQuerier) SelectGameResultsByFilter(ctx context.Context, filter entity.GameResultFilter) ([]entity.GameResult, error) {
+	ctx, end := q.start(ctx, "SelectGameResultsByFilter")
+	gameResults, err := q.querier.SelectGameResultsByFilter(ctx, filter)
+	end(err)
+	return gameResults, err
+}
+
+func (q *TracingQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance entity.Money, validationStatus bool) error {
+	ctx, end := q.start(ctx, "UpdateUserBalance")
+	err := q.querier.UpdateUserBalance(ctx, txn, userId, balance, validationStatus)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) UpdateGameResult(ctx context.Context, txn sqlx.Tx, gameResultId int, validationStatus entity.ValidationStatus) error {
+	ctx, end := q.start(ctx, "UpdateGameResult")
+	err := q.querier.UpdateGameResult(ctx, txn, gameResultId, validationStatus)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) InsertValidationRun(ctx context.Context, validationRun entity.ValidationRun) (int, error) {
+	ctx, end := q.start(ctx, "InsertValidationRun")
+	id, err := q.querier.InsertValidationRun(ctx, validationRun)
+	end(err)
+	return id, err
+}
+
+func (q *TracingQuerier) UpdateValidationRun(ctx context.Context, validationRun entity.ValidationRun) error {
+	ctx, end := q.start(ctx, "UpdateValidationRun")
+	err := q.querier.UpdateValidationRun(ctx, validationRun)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) SelectValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error) {
+	ctx, end := q.start(ctx, "SelectValidationRun")
+	validationRun, err := q.querier.SelectValidationRun(ctx, validationRunId)
+	end(err)
+	return validationRun, err
+}
+
+func (q *TracingQuerier) SelectValidationRunsByFilter(ctx context.Context, filter entity.ValidationRunFilter) ([]entity.ValidationRun, error) {
+	ctx, end := q.start(ctx, "SelectValidationRunsByFilter")
+	validationRuns, err := q.querier.SelectValidationRunsByFilter(ctx, filter)
+	end(err)
+	return validationRuns, err
+}
+
+func (q *TracingQuerier) InsertValidationDecision(ctx context.Context, txn sqlx.Tx, validationDecision entity.ValidationDecision) (int, error) {
+	ctx, end := q.start(ctx, "InsertValidationDecision")
+	id, err := q.querier.InsertValidationDecision(ctx, txn, validationDecision)
+	end(err)
+	return id, err
+}
+
+func (q *TracingQuerier) SelectValidationDecisionsByGameResults(ctx context.Context, gameResultIds []int) ([]entity.ValidationDecision, error) {
+	ctx, end := q.start(ctx, "SelectValidationDecisionsByGameResults")
+	validationDecisions, err := q.querier.SelectValidationDecisionsByGameResults(ctx, gameResultIds)
+	end(err)
+	return validationDecisions, err
+}
+
+func (q *TracingQuerier) InsertAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) (int, error) {
+	ctx, end := q.start(ctx, "InsertAPIKey")
+	id, err := q.querier.InsertAPIKey(ctx, txn, apiKey)
+	end(err)
+	return id, err
+}
+
+func (q *TracingQuerier) UpdateAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) error {
+	ctx, end := q.start(ctx, "UpdateAPIKey")
+	err := q.querier.UpdateAPIKey(ctx, txn, apiKey)
+	end(err)
+	return err
+}
+
+func (q *TracingQuerier) LockAPIKeyRow(ctx context.Context, txn sqlx.Tx, apiKeyId int) (*entity.APIKey, error) {
+	ctx, end := q.start(ctx, "LockAPIKeyRow")
+	apiKey, err := q.querier.LockAPIKeyRow(ctx, txn, apiKeyId)
+	end(err)
+	return apiKey, err
+}
+
+func (q *TracingQuerier) SelectAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	ctx, end := q.start(ctx, "SelectAPIKeyByHash")
+	apiKey, err := q.querier.SelectAPIKeyByHash(ctx, keyHash)
+	end(err)
+	return apiKey, err
+}
+
+func (q *TracingQuerier) SelectAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	ctx, end := q.start(ctx, "SelectAPIKeys")
+	apiKeys, err := q.querier.SelectAPIKeys(ctx)
+	end(err)
+	return apiKeys, err
+}
+
+func (q *TracingQuerier) InsertRequestNonce(ctx context.Context, apiKeyId int, nonce string, expiresAt time.Time) (bool, error) {
+	ctx, end := q.start(ctx, "InsertRequestNonce")
+	inserted, err := q.querier.InsertRequestNonce(ctx, apiKeyId, nonce, expiresAt)
+	end(err)
+	return inserted, err
+}
+
+func (q *TracingQuerier) DeleteExpiredRequestNonces(ctx context.Context, before time.Time) (int64, error) {
+	ctx, end := q.start(ctx, "DeleteExpiredRequestNonces")
+	deleted, err := q.querier.DeleteExpiredRequestNonces(ctx, before)
+	end(err)
+	return deleted, err
+}
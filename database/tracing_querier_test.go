@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/test_helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestTracingQuerierRecordsSpan(t *testing.T) {
+	recorder := test_helpers.RecordSpans(t)
+
+	mockQuerier := test_helpers.NewMockQuerier()
+	mockQuerier.On("CheckTransactionID", mock.Anything, "tx1").Return(true, nil)
+
+	exists, err := NewTracingQuerier(mockQuerier).CheckTransactionID(context.Background(), "tx1")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Querier.CheckTransactionID", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Contains(t, spans[0].Attributes(), semconv.DBSystemPostgreSQL)
+	assert.Contains(t, spans[0].Attributes(), semconv.DBOperationName("CheckTransactionID"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	// The call is made within the span
+	ctx := mockQuerier.Calls[0].Arguments.Get(0).(context.Context)
+	assert.Equal(t, spans[0].SpanContext(), trace.SpanContextFromContext(ctx))
+}
+
+func TestTracingQuerierRecordsError(t *testing.T) {
+	recorder := test_helpers.RecordSpans(t)
+
+	userId := uuid.New()
+	mockQuerier := test_helpers.NewMockQuerier()
+	mockQuerier.On("SelectUser", mock.Anything, userId).Return(nil, errors.New("connection refused"))
+
+	_, err := NewTracingQuerier(mockQuerier).SelectUser(context.Background(), userId)
+	require.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "Querier.SelectUser", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "connection refused", spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1, "the error should be recorded")
+}
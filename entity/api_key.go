@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"database/sql"
+	"time"
+)
+
+// APIKey is the credential of a transaction source.
+// Only the hash of the key is stored, the key itself is handed out once, when created.
+// Prefix is the start of the key, telling the keys apart without revealing them.
+// SigningKey is the hex random key verifying the signed requests, independent of the key, and empty for the keys created before signing.
+// A key is no longer accepted once it expires, after being rotated, or once it is revoked.
+type APIKey struct {
+	ID                int               `db:"id"`
+	Prefix            string            `db:"prefix"`
+	KeyHash           string            `db:"key_hash"`
+	SigningKey        string            `db:"signing_key"`
+	TransactionSource TransactionSource `db:"transaction_source"`
+	Description       string            `db:"description"`
+	CreatedAt         time.Time         `db:"created_at"`
+	ExpiresAt         sql.NullTime      `db:"expires_at"`
+	RevokedAt         sql.NullTime      `db:"revoked_at"`
+}
+
+// Active tells if the key is accepted at the given time
+func (k *APIKey) Active(at time.Time) bool {
+	if k.RevokedAt.Valid {
+		return false
+	}
+	return !k.ExpiresAt.Valid || at.Before(k.ExpiresAt.Time)
+}
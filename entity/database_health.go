@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// DatabaseHealth is the state of the database, as seen by the service.
+// MigrationDirty tells a migration failed halfway, the schema then needs a manual fix.
+// The connection figures come from the connection pool of the service.
+type DatabaseHealth struct {
+	ResponseTime       time.Duration
+	MigrationVersion   uint
+	MigrationDirty     bool
+	OpenConnections    int
+	InUseConnections   int
+	IdleConnections    int
+	MaxOpenConnections int
+	WaitCount          int64
+	WaitDuration       time.Duration
+}
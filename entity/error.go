@@ -2,15 +2,49 @@ package entity
 
 import (
 	"errors"
+	"fmt"
 )
 
 var ErrUserNotFound = errors.New("user not found")
+var ErrUserDisabled = errors.New("user is disabled")
+var ErrUserEmailExists = errors.New("user email already exists")
+var ErrInvalidEmail = errors.New("invalid email")
+var ErrInvalidBalance = errors.New("invalid balance")
+var ErrCreatingUser = errors.New("error recording user")
 var ErrUserNegativeBalance = errors.New("negative balance not allowed")
 var ErrTransactionIdExists = errors.New("transaction id already exists")
+var ErrTransactionIdReplayed = errors.New("transaction id already recorded with the same payload")
+var ErrTransactionIdConflict = fmt.Errorf("%w with a different payload", ErrTransactionIdExists)
 var ErrInvalidGameStatus = errors.New("invalid game status")
 var ErrRequestPayload = errors.New("invalid request body")
+var ErrInvalidParameters = errors.New("invalid request parameters")
 var ErrInvalidAmount = errors.New("invalid amount format")
+var ErrInvalidMoney = errors.New("invalid money format")
+var ErrMoneyPrecision = errors.New("more than two decimal places")
+var ErrMoneyOutOfRange = errors.New("out of range, the maximum is 99999999.99")
+var ErrAmountNotPositive = errors.New("must be greater than zero")
 var ErrInvalidUser = errors.New("invalid user Id")
 var ErrInvalidTransactionSource = errors.New("invalid transaction source")
+var ErrInvalidValidationStatus = errors.New("invalid validation status")
+var ErrInvalidDateRange = errors.New("invalid created at range")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidLimit = errors.New("invalid limit")
+var ErrEmptyBatch = errors.New("empty batch")
+var ErrBatchTooLarge = errors.New("batch too large")
 var ErrCreatingGameResult = errors.New("error recording game result")
+var ErrValidationRunNotFound = errors.New("validation run not found")
+var ErrInvalidValidationRun = errors.New("invalid validation run Id")
+var ErrUnauthorized = errors.New("unauthorized")
+var ErrAPIKeyNotFound = errors.New("api key not found")
+var ErrAPIKeyRevoked = errors.New("api key is revoked")
+var ErrInvalidAPIKey = errors.New("invalid api key Id")
+var ErrInvalidGracePeriod = errors.New("invalid grace period")
+var ErrSignatureRequired = errors.New("request signature required")
+var ErrInvalidSignature = errors.New("invalid request signature")
+var ErrStaleSignature = errors.New("request signature timestamp out of the accepted window")
+var ErrNonceReused = errors.New("request signature nonce already used")
+var ErrRequestTooLarge = errors.New("request body too large")
+var ErrRateLimited = errors.New("rate limit exceeded")
+var ErrServerOverloaded = errors.New("server overloaded")
+var ErrValidationFailed = errors.New("validation failed")
 var ErrServerInternal = errors.New("internal server error")
@@ -27,6 +27,27 @@ const (
 	TransactionSourcePayment TransactionSource = "payment"
 )
 
+func ParseGameStatus(value interface{}) *GameStatus {
+	status := GameStatus(value.(string))
+
+	if status != GameStatusWin &&
+		status != GameStatusLost {
+		return nil
+	}
+	return &status
+}
+
+func ParseValidationStatus(value interface{}) *ValidationStatus {
+	status := ValidationStatus(value.(string))
+
+	if status != ValidationStatusPending &&
+		status != ValidationStatusAccepted &&
+		status != ValidationStatusCanceled {
+		return nil
+	}
+	return &status
+}
+
 func ParseTransactionSource(value interface{}) *TransactionSource {
 	source := TransactionSource(value.(string))
 
@@ -72,8 +93,21 @@ type GameResult struct {
 	ValidationStatus  ValidationStatus  `db:"validation_status"`
 	TransactionSource TransactionSource `db:"transaction_source"`
 	TransactionID     string            `db:"transaction_id"`
-	Amount            float64           `db:"amount" `
+	Amount            Money             `db:"amount" `
 	CreatedAt         time.Time         `db:"created_at"`
+
+	// Decision is the validation decision of the game result, when read along with it
+	Decision *ValidationDecision `db:"-"`
+}
+
+// SamePayload tells if both game results describe the same submission,
+// regardless of the fields set when recording it.
+func (dm *GameResult) SamePayload(other GameResult) bool {
+	return dm.UserID == other.UserID &&
+		dm.GameStatus == other.GameStatus &&
+		dm.TransactionSource == other.TransactionSource &&
+		dm.TransactionID == other.TransactionID &&
+		dm.Amount == other.Amount
 }
 
 func (dm *GameResult) ShouldBeCanceled() bool {
@@ -81,3 +115,25 @@ func (dm *GameResult) ShouldBeCanceled() bool {
 	// Check it the ID is odd
 	return dm.ID%2 != 0
 }
+
+// GameResultFilter narrows down the game results of a user.
+// Nil fields are not applied.
+// Results are ordered from the newest to the oldest, and Cursor, when set,
+// only keeps the results older than the game result with that ID.
+type GameResultFilter struct {
+	UserID            uuid.UUID
+	ValidationStatus  *ValidationStatus
+	GameStatus        *GameStatus
+	TransactionSource *TransactionSource
+	CreatedFrom       *time.Time
+	CreatedTo         *time.Time
+	Cursor            int
+	Limit             int
+}
+
+// GameResultPage is a page of game results.
+// NextCursor is zero when there are no more game results to fetch.
+type GameResultPage struct {
+	GameResults []GameResult
+	NextCursor  int
+}
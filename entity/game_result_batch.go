@@ -0,0 +1,34 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+)
+
+type BatchItemStatus string
+
+const (
+	BatchItemStatusCreated             BatchItemStatus = "created"
+	BatchItemStatusDuplicate           BatchItemStatus = "duplicate"
+	BatchItemStatusInsufficientBalance BatchItemStatus = "insufficient_balance"
+	BatchItemStatusUnknownUser         BatchItemStatus = "unknown_user"
+	BatchItemStatusUserDisabled        BatchItemStatus = "user_disabled"
+	BatchItemStatusInvalid             BatchItemStatus = "invalid"
+	BatchItemStatusFailed              BatchItemStatus = "failed"
+)
+
+// GameResultSubmission is a game result to be recorded as part of a batch.
+type GameResultSubmission struct {
+	UserID        uuid.UUID
+	GameStatus    GameStatus
+	Amount        Money
+	TransactionID string
+}
+
+// GameResultOutcome is the result of recording one GameResultSubmission.
+// GameResult is only set when the status is BatchItemStatusCreated,
+// Err holds the reason of any other status.
+type GameResultOutcome struct {
+	Status     BatchItemStatus
+	GameResult *GameResult
+	Err        error
+}
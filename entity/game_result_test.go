@@ -80,7 +80,7 @@ func TestGameResult(t *testing.T) {
 	validationStatus := ValidationStatusPending
 	transactionSource := TransactionSourceGame
 	transactionID := "tx123"
-	amount := 10.15
+	amount := Money(10_15)
 	createdAt := time.Now()
 
 	gameResult := GameResult{
@@ -143,6 +143,33 @@ func TestParseTransactionSource(t *testing.T) {
 	}
 }
 
+func TestGameResultSamePayload(t *testing.T) {
+	gameResult := GameResult{
+		ID:                1,
+		UserID:            uuid.New(),
+		GameStatus:        GameStatusWin,
+		ValidationStatus:  ValidationStatusAccepted,
+		TransactionSource: TransactionSourceGame,
+		TransactionID:     "tx123",
+		Amount:            10_15,
+		CreatedAt:         time.Now(),
+	}
+
+	// Fields set when recording are not part of the payload
+	submission := gameResult
+	submission.ID = 0
+	submission.ValidationStatus = ValidationStatusPending
+	submission.CreatedAt = time.Now().Add(time.Hour)
+	if !gameResult.SamePayload(submission) {
+		t.Errorf("SamePayload() = false, want true")
+	}
+
+	submission.Amount = Money(10_16)
+	if gameResult.SamePayload(submission) {
+		t.Errorf("SamePayload() = true, want false")
+	}
+}
+
 func TestGameResultShouldBeCanceled(t *testing.T) {
 	tests := []struct {
 		name string
@@ -166,3 +193,52 @@ func TestGameResultShouldBeCanceled(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGameStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  *GameStatus
+	}{
+		{"Win Status", "win", ptr(GameStatusWin)},
+		{"Lost Status", "lost", ptr(GameStatusLost)},
+		{"Invalid Status", "invalid", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseGameStatus(tt.input)
+
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ParseGameStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseValidationStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  *ValidationStatus
+	}{
+		{"Pending Status", "pending", ptr(ValidationStatusPending)},
+		{"Accepted Status", "accepted", ptr(ValidationStatusAccepted)},
+		{"Canceled Status", "canceled", ptr(ValidationStatusCanceled)},
+		{"Invalid Status", "invalid", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseValidationStatus(tt.input)
+
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("ParseValidationStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,139 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount of money, counted in cents.
+// It matches the DECIMAL(10,2) columns of the database, so no rounding happens on the way.
+type Money int64
+
+// MaxMoney is the largest amount a DECIMAL(10,2) column holds.
+const MaxMoney Money = 99999999_99
+
+// ParseMoney parses a decimal amount, such as "10.15".
+// It returns ErrMoneyPrecision when the amount has more than two decimal places,
+// and ErrMoneyOutOfRange when it does not fit in a DECIMAL(10,2) column.
+func ParseMoney(value string) (Money, error) {
+	money, err := parseDecimal(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if money > MaxMoney || money < -MaxMoney {
+		return 0, ErrMoneyOutOfRange
+	}
+	return money, nil
+}
+
+// parseDecimal parses a decimal amount without range restrictions.
+func parseDecimal(value string) (Money, error) {
+	digits, negative := strings.CutPrefix(value, "-")
+
+	units, cents, hasCents := strings.Cut(digits, ".")
+	if !isDigits(units) || (hasCents && !isDigits(cents)) {
+		return 0, ErrInvalidMoney
+	}
+
+	// Trailing zeros do not change the amount
+	if len(cents) > 2 {
+		if strings.TrimRight(cents[2:], "0") != "" {
+			return 0, ErrMoneyPrecision
+		}
+		cents = cents[:2]
+	}
+	cents += strings.Repeat("0", 2-len(cents))
+
+	// Leading zeros do not change the amount either
+	units = strings.TrimLeft(units, "0")
+	if len(units) > 16 {
+		return 0, ErrMoneyOutOfRange
+	}
+
+	amount, err := strconv.ParseInt(units+cents, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidMoney
+	}
+
+	if negative {
+		return Money(-amount), nil
+	}
+	return Money(amount), nil
+}
+
+// isDigits tells if the value is made of decimal digits only.
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount with two decimal places, such as "10.15".
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimal places.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON decodes the amount from a JSON number or string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	money, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// Scan reads the amount from a DECIMAL column.
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case string:
+		return m.scanDecimal(v)
+	case []byte:
+		return m.scanDecimal(string(v))
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = Money(math.Round(v * 100))
+	default:
+		return fmt.Errorf("unsupported money type %T", value)
+	}
+	return nil
+}
+
+// scanDecimal reads the amount from its text representation.
+func (m *Money) scanDecimal(value string) error {
+	money, err := parseDecimal(value)
+	if err != nil {
+		return fmt.Errorf("scanning money %q: %w", value, err)
+	}
+	*m = money
+	return nil
+}
+
+// Value writes the amount to a DECIMAL column.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
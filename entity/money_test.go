@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Money
+		err      error
+	}{
+		{"10.15", 10_15, nil},
+		{"10", 10_00, nil},
+		{"10.5", 10_50, nil},
+		{"0.01", 1, nil},
+		{"-3.05", -3_05, nil},
+		{"007.10", 7_10, nil},
+		{"10.150", 10_15, nil},
+		{"99999999.99", MaxMoney, nil},
+		{"10.155", 0, ErrMoneyPrecision},
+		{"0.001", 0, ErrMoneyPrecision},
+		{"100000000", 0, ErrMoneyOutOfRange},
+		{"-100000000.00", 0, ErrMoneyOutOfRange},
+		{"99999999999999999999", 0, ErrMoneyOutOfRange},
+		{"", 0, ErrInvalidMoney},
+		{"abc", 0, ErrInvalidMoney},
+		{"10.", 0, ErrInvalidMoney},
+		{".5", 0, ErrInvalidMoney},
+		{"+1", 0, ErrInvalidMoney},
+		{"1e3", 0, ErrInvalidMoney},
+		{"NaN", 0, ErrInvalidMoney},
+		{"1,000.00", 0, ErrInvalidMoney},
+	}
+
+	for _, test := range tests {
+		money, err := ParseMoney(test.value)
+		if !errors.Is(err, test.err) {
+			t.Errorf("ParseMoney(%q): expected error %v, got %v", test.value, test.err, err)
+		}
+		if money != test.expected {
+			t.Errorf("ParseMoney(%q): expected %d, got %d", test.value, test.expected, money)
+		}
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := map[Money]string{
+		0:         "0.00",
+		1:         "0.01",
+		10_15:     "10.15",
+		-3_05:     "-3.05",
+		MaxMoney:  "99999999.99",
+		-MaxMoney: "-99999999.99",
+	}
+
+	for money, expected := range tests {
+		if money.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, money.String())
+		}
+	}
+}
+
+func TestMoney_NoRoundingDrift(t *testing.T) {
+	// 0.1 + 0.2 is not 0.3 with float64
+	tenCents, _ := ParseMoney("0.10")
+	twentyCents, _ := ParseMoney("0.20")
+	if tenCents+twentyCents != Money(30) {
+		t.Errorf("Expected 0.30, got %v", tenCents+twentyCents)
+	}
+
+	balance := Money(0)
+	for i := 0; i < 1000; i++ {
+		balance += tenCents
+	}
+	if balance.String() != "100.00" {
+		t.Errorf("Expected 100.00, got %v", balance)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	bytes, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{Amount: 10_15})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if string(bytes) != `{"amount":10.15}` {
+		t.Errorf("Expected a JSON number, got %s", bytes)
+	}
+
+	var decoded struct {
+		Number Money `json:"number"`
+		String Money `json:"string"`
+	}
+	err = json.Unmarshal([]byte(`{"number": 10.15, "string": "20.5"}`), &decoded)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if decoded.Number != 10_15 || decoded.String != 20_50 {
+		t.Errorf("Expected 10.15 and 20.50, got %v and %v", decoded.Number, decoded.String)
+	}
+
+	err = json.Unmarshal([]byte(`{"number": 10.155}`), &decoded)
+	if !errors.Is(err, ErrMoneyPrecision) {
+		t.Errorf("Expected %v, got %v", ErrMoneyPrecision, err)
+	}
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		expected Money
+	}{
+		{"10.15", 10_15},
+		{[]byte("-3.05"), -3_05},
+		{int64(10), 10_00},
+		{10.15, 10_15},
+		{nil, 0},
+	}
+
+	for _, test := range tests {
+		var money Money
+		if err := money.Scan(test.value); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if money != test.expected {
+			t.Errorf("Scan(%v): expected %d, got %d", test.value, test.expected, money)
+		}
+	}
+
+	var money Money
+	if err := money.Scan(true); err == nil {
+		t.Errorf("Expected an error for an unsupported type")
+	}
+}
+
+func TestMoney_Value(t *testing.T) {
+	val, err := Money(10_15).Value()
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if val != "10.15" {
+		t.Errorf("Expected '10.15', got %v", val)
+	}
+}
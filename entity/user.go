@@ -10,8 +10,25 @@ import (
 type User struct {
 	ID                   uuid.UUID    `db:"id"`
 	Email                string       `db:"email"`
-	Balance              float64      `db:"balance"`
+	Balance              Money        `db:"balance"`
 	LastGameResultAt     sql.NullTime `db:"last_game_result_at"`
 	GamesResultValidated sql.NullBool `db:"games_result_validated"`
+	Disabled             bool         `db:"disabled"`
 	CreatedAt            time.Time    `db:"created_at"`
 }
+
+// UserUpdate holds the changes to apply to a user.
+// Nil fields are left untouched.
+type UserUpdate struct {
+	Email    *string
+	Disabled *bool
+}
+
+// UserAccount is a user along with the breakdown of its balance.
+// PendingBalance is the net amount of the game results waiting for validation,
+// SettledBalance is the remaining part of the balance.
+type UserAccount struct {
+	User
+	PendingBalance Money
+	SettledBalance Money
+}
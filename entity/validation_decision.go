@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// ValidationReason tells why a validation decision was taken.
+type ValidationReason string
+
+const (
+	// ValidationReasonCanceledByPolicy is set when the cancellation policy canceled the game result
+	ValidationReasonCanceledByPolicy ValidationReason = "canceled_by_policy"
+	// ValidationReasonAcceptedByPolicy is set when the cancellation policy accepted the game result
+	ValidationReasonAcceptedByPolicy ValidationReason = "accepted_by_policy"
+	// ValidationReasonCancellationLimitReached is set when the cancellation policy would have canceled
+	// the game result, but the limit of game results to cancel per user was already reached
+	ValidationReasonCancellationLimitReached ValidationReason = "cancellation_limit_reached"
+)
+
+// ValidationDecision is the audit record of the validation of a game result.
+// The balances are the ones of the user, right before and after the decision.
+type ValidationDecision struct {
+	ID              int              `db:"id"`
+	GameResultID    int              `db:"game_result_id"`
+	Decision        ValidationStatus `db:"decision"`
+	Policy          string           `db:"policy"`
+	Reason          ValidationReason `db:"reason_code"`
+	BalanceBefore   Money            `db:"balance_before"`
+	BalanceAfter    Money            `db:"balance_after"`
+	ValidationRunID int              `db:"validation_run_id"`
+	CreatedAt       time.Time        `db:"created_at"`
+}
@@ -0,0 +1,52 @@
+package entity
+
+import (
+	"database/sql"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ValidationRun is the record of a validation round.
+// BalanceDelta is the net change of the balances of the users processed,
+// Error is the error which stopped the round, if any.
+// A dry run is not recorded, and holds the evaluation of each user instead.
+type ValidationRun struct {
+	ID              int            `db:"id"`
+	StartedAt       time.Time      `db:"started_at"`
+	FinishedAt      time.Time      `db:"finished_at"`
+	UsersProcessed  int            `db:"users_processed"`
+	ResultsApproved int            `db:"results_approved"`
+	ResultsCanceled int            `db:"results_canceled"`
+	BalanceDelta    Money          `db:"balance_delta"`
+	Error           sql.NullString `db:"error"`
+
+	DryRun bool             `db:"-"`
+	Users  []UserValidation `db:"-"`
+}
+
+// UserValidation is the evaluation of the pending game results of a user by a dry run.
+// Decisions are in the order they would be applied, BalanceAfter is the balance the user would end up with.
+type UserValidation struct {
+	UserID        uuid.UUID
+	BalanceBefore Money
+	BalanceAfter  Money
+	Decisions     []ValidationDecision
+}
+
+// ValidationRunFilter narrows down the validation runs.
+// Nil fields are not applied.
+// Runs are ordered from the newest to the oldest, and Cursor, when set,
+// only keeps the runs older than the validation run with that ID.
+type ValidationRunFilter struct {
+	StartedFrom *time.Time
+	StartedTo   *time.Time
+	Cursor      int
+	Limit       int
+}
+
+// ValidationRunPage is a page of validation runs.
+// NextCursor is zero when there are no more validation runs to fetch.
+type ValidationRunPage struct {
+	ValidationRuns []ValidationRun
+	NextCursor     int
+}
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Formats of the logs
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options is the configuration of the logs of a component.
+// The Component, if any, is added to every record.
+type Options struct {
+	Level     slog.Level
+	Format    string
+	Component string
+}
+
+// ParseLevel parses the name of a log level: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// Setup sets up the default logger, writing to the standard error
+// The standard log package is redirected to it as well
+func Setup(options Options) {
+	slog.SetDefault(NewLogger(os.Stderr, options))
+}
+
+// NewLogger creates a logger writing to the given output, in the given format
+// Every record logged with a context carries the request ID and the trace of the context, if any
+func NewLogger(output io.Writer, options Options) *slog.Logger {
+	handlerOptions := &slog.HandlerOptions{Level: options.Level}
+
+	var handler slog.Handler
+	if options.Format == FormatText {
+		handler = slog.NewTextHandler(output, handlerOptions)
+	} else {
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	}
+
+	logger := slog.New(contextHandler{Handler: handler})
+	if options.Component != "" {
+		logger = logger.With("component", options.Component)
+	}
+	return logger
+}
+
+// Fatal logs the message as an error, then exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context carrying the given request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by the context, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request ID and the trace carried by the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()), slog.String("span_id", spanContext.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("debug")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level)
+
+	level, err = ParseLevel("WARN")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestNewLoggerAddsContext(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, Options{Level: slog.LevelInfo, Format: FormatJSON, Component: "validator"})
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("test").Start(WithRequestID(context.Background(), "request-1"), "operation")
+	defer span.End()
+
+	logger.InfoContext(ctx, "game result created", "user_id", "user-1")
+
+	output := buf.String()
+	assert.Contains(t, output, `"msg":"game result created"`)
+	assert.Contains(t, output, `"component":"validator"`)
+	assert.Contains(t, output, `"user_id":"user-1"`)
+	assert.Contains(t, output, `"request_id":"request-1"`)
+	assert.Contains(t, output, `"trace_id":"`+span.SpanContext().TraceID().String()+`"`)
+}
+
+func TestNewLoggerLevelAndFormat(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := NewLogger(buf, Options{Level: slog.LevelWarn, Format: FormatText})
+
+	logger.Info("skipped")
+	logger.Warn("kept", "user_id", "user-1")
+
+	assert.NotContains(t, buf.String(), "skipped")
+	assert.Contains(t, buf.String(), "level=WARN msg=kept user_id=user-1")
+}
+
+func TestRequestID(t *testing.T) {
+	assert.Empty(t, RequestID(context.Background()))
+	assert.Equal(t, "request-1", RequestID(WithRequestID(context.Background(), "request-1")))
+}
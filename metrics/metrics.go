@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const (
+	// Namespace prefixes the name of every metric of the service
+	Namespace = "cceab"
+
+	DefaultListenAddress = 9100
+)
+
+// Registry holds the metrics of the service, along with the Go runtime and process ones
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	// HTTPRequests counts the HTTP requests served, by route template, method and status code
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "The number of HTTP requests served, by route, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes the latency of the HTTP requests, by route template, method and status code
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "The latency of the HTTP requests, by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// HTTPThrottledRequests counts the HTTP requests turned down by the rate limits and the load shedding, by reason
+	HTTPThrottledRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "throttled_requests_total",
+		Help:      "The number of HTTP requests turned down by the rate limits and the load shedding, by reason.",
+	}, []string{"reason"})
+
+	// GameResults counts the game results recorded, by game status and transaction source
+	GameResults = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "game_results_total",
+		Help:      "The number of game results recorded, by game status and transaction source.",
+	}, []string{"game_status", "source"})
+
+	// GameResultRejections counts the game results rejected by the balance and transaction checks, by reason and transaction source
+	GameResultRejections = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Name:      "game_result_rejections_total",
+		Help:      "The number of game results rejected, by reason and transaction source.",
+	}, []string{"reason", "source"})
+
+	// ValidationRunDuration observes the duration of the validation runs recorded, by outcome
+	ValidationRunDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "validation",
+		Name:      "run_duration_seconds",
+		Help:      "The duration of the validation runs, by outcome.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"outcome"})
+
+	// ValidationDecisions counts the decisions taken on the game results validated, by decision and reason
+	ValidationDecisions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "validation",
+		Name:      "decisions_total",
+		Help:      "The number of game results validated, by decision and reason.",
+	}, []string{"decision", "reason"})
+)
+
+// Reasons of the game result rejections
+const (
+	RejectionNegativeBalance     = "negative_balance"
+	RejectionTransactionIdExists = "transaction_id_exists"
+)
+
+// Reasons of the HTTP requests throttled
+const (
+	ThrottleUserRateLimit   = "user_rate_limit"
+	ThrottleSourceRateLimit = "source_rate_limit"
+	ThrottleLoadShedding    = "load_shedding"
+)
+
+// Outcomes of the validation runs
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// RegisterDBStats exposes the statistics of the database connection pool on the registerer, usually the Registry
+// They are read from the given function on each scrape
+// It returns an error if they are already exposed
+func RegisterDBStats(registerer prometheus.Registerer, stats func() sql.DBStats) error {
+	gauge := func(name string, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(stats()) })
+	}
+	counter := func(name string, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "db",
+			Name:      name,
+			Help:      help,
+		}, func() float64 { return value(stats()) })
+	}
+
+	dbCollectors := []prometheus.Collector{
+		gauge("max_open_connections", "The maximum number of open connections to the database.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("open_connections", "The number of established connections, both in use and idle.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("in_use_connections", "The number of connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("idle_connections", "The number of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("wait_count_total", "The total number of connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("wait_duration_seconds_total", "The total time blocked waiting for a new connection.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+	}
+
+	for _, collector := range dbCollectors {
+		if err := registerer.Register(collector); err != nil {
+			return fmt.Errorf("registering database metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Serve exposes the metrics on the given port, under /metrics, until the context is cancelled
+func Serve(ctx context.Context, port int) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	httpServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 15 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		httpServer.Close() //nolint:all
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
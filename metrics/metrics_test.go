@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterDBStats(t *testing.T) {
+	stats := sql.DBStats{MaxOpenConnections: 10, OpenConnections: 4, InUse: 3, Idle: 1, WaitCount: 2, WaitDuration: 1500 * time.Millisecond}
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, RegisterDBStats(registry, func() sql.DBStats { return stats }))
+	require.Error(t, RegisterDBStats(registry, func() sql.DBStats { return stats }), "the database metrics should only be exposed once")
+
+	expected := `
+# HELP cceab_db_in_use_connections The number of connections currently in use.
+# TYPE cceab_db_in_use_connections gauge
+cceab_db_in_use_connections 3
+# HELP cceab_db_wait_count_total The total number of connections waited for.
+# TYPE cceab_db_wait_count_total counter
+cceab_db_wait_count_total 2
+# HELP cceab_db_wait_duration_seconds_total The total time blocked waiting for a new connection.
+# TYPE cceab_db_wait_duration_seconds_total counter
+cceab_db_wait_duration_seconds_total 1.5
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"cceab_db_in_use_connections", "cceab_db_wait_count_total", "cceab_db_wait_duration_seconds_total")
+	assert.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	GameResults.WithLabelValues("win", "game").Inc()
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `cceab_game_results_total{game_status="win",source="game"}`)
+	assert.Contains(t, recorder.Body.String(), "go_goroutines")
+}
+
+func TestServe(t *testing.T) {
+	// Reserve a free port
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- Serve(ctx, port)
+	}()
+
+	url := fmt.Sprintf("http://localhost:%d/metrics", port)
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode == http.StatusOK && strings.Contains(string(body), "go_goroutines")
+	}, 5*time.Second, 50*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the metrics server did not stop")
+	}
+}
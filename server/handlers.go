@@ -1,19 +1,45 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/ildomm/cceab/dao"
 	"github.com/ildomm/cceab/entity"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	DefaultGameResultsLimit = 50
+	MaxGameResultsLimit     = 100
+	MaxGameResultsBatchSize = 500
+
+	DefaultValidationRunsLimit = 50
+	MaxValidationRunsLimit     = 100
+
+	DefaultReadinessTimeout = time.Second * 2
 )
 
 // HealthHandler evaluates the health of the service and writes a standardized response.
+// The service fails the health check while draining its requests before shutting down.
 func (s *Server) HealthHandler(response http.ResponseWriter, request *http.Request) {
+	if s.Draining() {
+		WriteAPIResponse(response, http.StatusServiceUnavailable, HealthResponse{
+			Status:  "fail",
+			Version: "v1",
+		})
+		return
+	}
+
 	health := HealthResponse{
 		Status:  "pass",
 		Version: "v1",
@@ -22,6 +48,132 @@ func (s *Server) HealthHandler(response http.ResponseWriter, request *http.Reque
 	WriteAPIResponse(response, http.StatusOK, health)
 }
 
+// LiveHandler tells the service is running, regardless of its dependencies.
+func (s *Server) LiveHandler(response http.ResponseWriter, request *http.Request) {
+	WriteHealthResponse(response, http.StatusOK, HealthCheckResponse{
+		Status:    HealthStatusPass,
+		Version:   s.semVer,
+		ReleaseID: s.gitSha,
+	})
+}
+
+// ReadyHandler tells the service can serve requests: it is not shutting down, and its database is reachable and migrated.
+func (s *Server) ReadyHandler(response http.ResponseWriter, request *http.Request) {
+	health := HealthCheckResponse{
+		Status:    HealthStatusPass,
+		Version:   s.semVer,
+		ReleaseID: s.gitSha,
+		Checks:    make(map[string][]HealthCheckDetail),
+	}
+
+	if s.Draining() {
+		health.Status = HealthStatusFail
+		health.Output = "shutting down"
+	}
+
+	if s.healthManager != nil {
+		ctx, cancel := context.WithTimeout(request.Context(), DefaultReadinessTimeout)
+		defer cancel()
+
+		databaseHealth, err := s.healthManager.CheckDatabase(ctx)
+		checkedAt := time.Now()
+		if err != nil {
+			// The probe is public, the connection and driver details are only logged
+			slog.ErrorContext(ctx, "error checking database", "error", err)
+
+			health.Status = HealthStatusFail
+			health.Checks["postgres:responseTime"] = []HealthCheckDetail{{
+				ComponentType: "datastore",
+				Status:        HealthStatusFail,
+				Time:          checkedAt,
+				Output:        "database unreachable",
+			}}
+		} else {
+			if databaseHealth.MigrationDirty {
+				health.Status = HealthStatusFail
+			}
+			for name, check := range transformDatabaseHealthChecks(*databaseHealth, checkedAt) {
+				health.Checks[name] = []HealthCheckDetail{check}
+			}
+		}
+	}
+
+	code := http.StatusOK
+	if health.Status == HealthStatusFail {
+		code = http.StatusServiceUnavailable
+	}
+	WriteHealthResponse(response, code, health)
+}
+
+// Transform entity.DatabaseHealth to the server.HealthCheckDetail of each database check
+func transformDatabaseHealthChecks(databaseHealth entity.DatabaseHealth, checkedAt time.Time) map[string]HealthCheckDetail {
+	migrationStatus := HealthStatusPass
+	migrationOutput := ""
+	if databaseHealth.MigrationDirty {
+		migrationStatus = HealthStatusFail
+		migrationOutput = "migration failed halfway, the schema needs a manual fix"
+	}
+
+	return map[string]HealthCheckDetail{
+		"postgres:responseTime": {
+			ComponentType: "datastore",
+			ObservedValue: float64(databaseHealth.ResponseTime.Microseconds()) / 1000,
+			ObservedUnit:  "ms",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+		"postgres:migrationVersion": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.MigrationVersion,
+			Status:        migrationStatus,
+			Time:          checkedAt,
+			Output:        migrationOutput,
+		},
+		"postgres:openConnections": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.OpenConnections,
+			ObservedUnit:  "connections",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+		"postgres:inUseConnections": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.InUseConnections,
+			ObservedUnit:  "connections",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+		"postgres:idleConnections": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.IdleConnections,
+			ObservedUnit:  "connections",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+		"postgres:maxOpenConnections": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.MaxOpenConnections,
+			ObservedUnit:  "connections",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+		"postgres:waitCount": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.WaitCount,
+			ObservedUnit:  "waits",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+		"postgres:waitDuration": {
+			ComponentType: "datastore",
+			ObservedValue: databaseHealth.WaitDuration.Milliseconds(),
+			ObservedUnit:  "ms",
+			Status:        HealthStatusPass,
+			Time:          checkedAt,
+		},
+	}
+}
+
 // gameResultHandler handles all requests related to game results.
 type gameResultHandler struct {
 	gameResultDAO dao.GameResultDAO
@@ -35,72 +187,780 @@ func NewGameResultHandler(gameResultDAO dao.GameResultDAO) *gameResultHandler {
 
 // CreateGameResultFunc handles the request to create a new game result.
 func (h *gameResultHandler) CreateGameResultFunc(w http.ResponseWriter, r *http.Request) {
-	// Validate the headers.
-	transactionSource := entity.ParseTransactionSource(strings.ToLower(r.Header.Get("Source-Type")))
-	if transactionSource == nil {
-		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidTransactionSource.Error()})
+	// The transaction source is the one bound to the api key.
+	transactionSource, found := TransactionSource(r.Context())
+	if !found {
+		writeAPIKeyUnauthorized(w)
 		return
-
 	}
 
 	// Validate the request body.
 	var req CreateGameResultRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrRequestPayload.Error()})
+		WriteProblemResponse(w, entity.ErrRequestPayload)
 		return
 	}
 
-	// Validate amount type cast
-	amount, err := strconv.ParseFloat(req.Amount, 64)
+	// Validate the user ID from the request path, the amount type cast and the game status, all at once.
+	var params invalidParams
+	userId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		params.add("id", entity.ErrInvalidUser)
+	}
+	amount := parseGameResultRequest(req, &params)
+	if err := params.errOrNil(); err != nil {
+		WriteProblemResponse(w, err)
+		return
+	}
+
+	// Perform the business logic.
+	gameResult, err := h.gameResultDAO.CreateGameResult(r.Context(), userId, req.GameStatus, amount, transactionSource, req.TransactionID)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidAmount.Error()})
+		if errors.Is(err, entity.ErrTransactionIdReplayed) {
+			// A retry of an already recorded transaction, answer as the original request
+			WriteAPIResponse(w, http.StatusOK, transformGameResultResponse(*gameResult))
+			return
+		}
+
+		// A conflicting transaction is answered along with the recorded game result
+		var data interface{}
+		if gameResult != nil {
+			data = transformGameResultResponse(*gameResult)
+		}
+		WriteProblemResponseWithData(w, err, data)
 		return
 	}
 
-	// Validate the game status.
+	gameResultResponse := transformGameResultResponse(*gameResult)
+	WriteAPIResponse(w, http.StatusCreated, gameResultResponse)
+}
+
+// CreateGameResultsBatchFunc handles the request to create many game results at once, possibly for different users.
+// Each game result gets its own status, so a rejected item does not fail the whole batch.
+func (h *gameResultHandler) CreateGameResultsBatchFunc(w http.ResponseWriter, r *http.Request) {
+	// The transaction source is the one bound to the api key.
+	transactionSource, found := TransactionSource(r.Context())
+	if !found {
+		writeAPIKeyUnauthorized(w)
+		return
+	}
+
+	// Validate the request body.
+	var req CreateGameResultsBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteProblemResponse(w, entity.ErrRequestPayload)
+		return
+	}
+	if len(req.GameResults) == 0 {
+		WriteProblemResponse(w, entity.ErrEmptyBatch)
+		return
+	}
+	if len(req.GameResults) > MaxGameResultsBatchSize {
+		WriteProblemResponse(w, entity.ErrBatchTooLarge)
+		return
+	}
+
+	// Validate each item, only the valid ones are submitted
+	outcomes := make([]entity.GameResultOutcome, len(req.GameResults))
+	var submissions []entity.GameResultSubmission
+	var submitted []int
+
+	for i, item := range req.GameResults {
+		var params invalidParams
+		userId, err := uuid.Parse(item.UserID)
+		if err != nil {
+			params.add("userId", entity.ErrInvalidUser)
+		}
+		amount := parseGameResultRequest(item.CreateGameResultRequest, &params)
+		if err := params.errOrNil(); err != nil {
+			outcomes[i] = entity.GameResultOutcome{Status: entity.BatchItemStatusInvalid, Err: err}
+			continue
+		}
+
+		submissions = append(submissions, entity.GameResultSubmission{
+			UserID:        userId,
+			GameStatus:    item.GameStatus,
+			Amount:        amount,
+			TransactionID: item.TransactionID,
+		})
+		submitted = append(submitted, i)
+	}
+
+	// Perform the business logic.
+	if len(submissions) > 0 {
+		for j, outcome := range h.gameResultDAO.CreateGameResults(r.Context(), transactionSource, submissions) {
+			outcomes[submitted[j]] = outcome
+		}
+	}
+
+	batchResponse := CreateGameResultsBatchResponse{
+		Results: make([]GameResultBatchItemResponse, 0, len(outcomes)),
+	}
+	for i, outcome := range outcomes {
+		batchResponse.Results = append(batchResponse.Results, transformGameResultBatchItemResponse(i, req.GameResults[i].TransactionID, outcome))
+	}
+
+	WriteAPIResponse(w, http.StatusOK, batchResponse)
+}
+
+// parseGameResultRequest validates the amount type cast and the game status of a game result request,
+// adding the invalid ones to the params.
+func parseGameResultRequest(req CreateGameResultRequest, params *invalidParams) entity.Money {
+	amount, err := parseMoney(req.Amount, entity.ErrInvalidAmount)
+	switch {
+	case err != nil:
+		params.add("amount", err)
+	case amount <= 0:
+		// The game status tells the direction, a negative or zero amount would turn a loss into a gain
+		params.add("amount", fmt.Errorf("%w: %w", entity.ErrInvalidAmount, entity.ErrAmountNotPositive))
+	}
+
 	if req.GameStatus != entity.GameStatusWin && req.GameStatus != entity.GameStatusLost {
-		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidGameStatus.Error()})
+		params.add("state", entity.ErrInvalidGameStatus)
 	}
 
-	// Extract the user ID from the request path.
-	vars := mux.Vars(r)
-	userId, err := uuid.Parse(vars["id"])
+	return amount
+}
+
+// parseMoney parses a money field of a request, reporting any issue as the given field error.
+// The precision and range issues are detailed along with the field error.
+func parseMoney(value string, fieldErr error) (entity.Money, error) {
+	money, err := entity.ParseMoney(value)
+	switch {
+	case err == nil:
+		return money, nil
+	case errors.Is(err, entity.ErrInvalidMoney):
+		return 0, fieldErr
+	default:
+		return 0, fmt.Errorf("%w: %w", fieldErr, err)
+	}
+}
+
+// ListGameResultsFunc handles the request to list the game results of a user.
+func (h *gameResultHandler) ListGameResultsFunc(w http.ResponseWriter, r *http.Request) {
+	// Validate the user ID from the request path and the query parameters, all at once.
+	var params invalidParams
+	userId, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		params.add("id", entity.ErrInvalidUser)
+	}
+	filter := parseGameResultFilter(userId, r.URL.Query(), &params)
+	if err := params.errOrNil(); err != nil {
+		WriteProblemResponse(w, err)
 		return
 	}
 
 	// Perform the business logic.
-	gameResult, err := h.gameResultDAO.CreateGameResult(r.Context(), userId, req.GameStatus, amount, *transactionSource, req.TransactionID)
+	page, err := h.gameResultDAO.ListGameResults(r.Context(), *filter)
 	if err != nil {
+		WriteProblemResponse(w, err)
+		return
+	}
+
+	gameResultsResponse := GameResultListResponse{
+		GameResults: make([]GameResultResponse, 0, len(page.GameResults)),
+		NextCursor:  page.NextCursor,
+	}
+	for _, gameResult := range page.GameResults {
+		gameResultsResponse.GameResults = append(gameResultsResponse.GameResults, transformGameResultResponse(gameResult))
+	}
 
-		switch {
-		case errors.Is(err, entity.ErrUserNotFound):
-			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+	WriteAPIResponse(w, http.StatusOK, gameResultsResponse)
+}
 
-		case errors.Is(err, entity.ErrTransactionIdExists) || errors.Is(err, entity.ErrUserNegativeBalance):
-			WriteErrorResponse(w, http.StatusNotAcceptable, []string{err.Error()})
+// parseGameResultFilter builds an entity.GameResultFilter out of the query parameters, adding the invalid ones to the params.
+func parseGameResultFilter(userId uuid.UUID, query url.Values, params *invalidParams) *entity.GameResultFilter {
+	filter := entity.GameResultFilter{
+		UserID: userId,
+		Limit:  DefaultGameResultsLimit,
+	}
 
-		default:
-			WriteErrorResponse(w, http.StatusInternalServerError, []string{err.Error()})
+	if value := query.Get("validation_status"); value != "" {
+		filter.ValidationStatus = entity.ParseValidationStatus(strings.ToLower(value))
+		if filter.ValidationStatus == nil {
+			params.add("validation_status", entity.ErrInvalidValidationStatus)
 		}
+	}
 
-		return
+	if value := query.Get("game_status"); value != "" {
+		filter.GameStatus = entity.ParseGameStatus(strings.ToLower(value))
+		if filter.GameStatus == nil {
+			params.add("game_status", entity.ErrInvalidGameStatus)
+		}
 	}
 
-	gameResultResponse := transformGameResultResponse(*gameResult)
-	WriteAPIResponse(w, http.StatusCreated, gameResultResponse)
+	if value := query.Get("transaction_source"); value != "" {
+		filter.TransactionSource = entity.ParseTransactionSource(strings.ToLower(value))
+		if filter.TransactionSource == nil {
+			params.add("transaction_source", entity.ErrInvalidTransactionSource)
+		}
+	}
+
+	filter.CreatedFrom, filter.CreatedTo = parseDateRange(query, "created_from", "created_to", params)
+	filter.Cursor, filter.Limit = parsePage(query, DefaultGameResultsLimit, MaxGameResultsLimit, params)
+
+	return &filter
+}
+
+// parseDateRange parses the optional bounds of a date range out of the query parameters, adding the invalid ones to the params.
+func parseDateRange(query url.Values, fromParam string, toParam string, params *invalidParams) (*time.Time, *time.Time) {
+	var from, to *time.Time
+
+	if value := query.Get(fromParam); value != "" {
+		date, err := parseDateParameter(value)
+		if err != nil {
+			params.add(fromParam, entity.ErrInvalidDateRange)
+		} else {
+			from = &date
+		}
+	}
+
+	if value := query.Get(toParam); value != "" {
+		date, err := parseDateUpperBound(value)
+		if err != nil {
+			params.add(toParam, entity.ErrInvalidDateRange)
+		} else {
+			to = &date
+		}
+	}
+
+	if from != nil && to != nil && from.After(*to) {
+		params.add(fromParam, entity.ErrInvalidDateRange)
+	}
+
+	return from, to
+}
+
+// parsePage parses the cursor and the limit of a page out of the query parameters, adding the invalid ones to the params.
+func parsePage(query url.Values, defaultLimit int, maxLimit int, params *invalidParams) (int, int) {
+	cursor, limit := 0, defaultLimit
+
+	if value := query.Get("cursor"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			params.add("cursor", entity.ErrInvalidCursor)
+		} else {
+			cursor = parsed
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxLimit {
+			params.add("limit", entity.ErrInvalidLimit)
+		} else {
+			limit = parsed
+		}
+	}
+
+	return cursor, limit
+}
+
+// parseDateParameter accepts both full RFC 3339 timestamps and plain dates.
+func parseDateParameter(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseDateUpperBound accepts the same values as parseDateParameter, as an inclusive upper bound.
+// A plain date covers the whole day: it ends right before the next day, the database timestamps
+// having a microsecond precision.
+func parseDateUpperBound(value string) (time.Time, error) {
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		return date.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
 
 // Transform entity.GameResult to server.GameResultResponse
 func transformGameResultResponse(gameResult entity.GameResult) GameResultResponse {
-	return GameResultResponse{
+	gameResultResponse := GameResultResponse{
 		ID:                gameResult.ID,
 		UserID:            gameResult.UserID,
 		GameStatus:        gameResult.GameStatus,
+		ValidationStatus:  gameResult.ValidationStatus,
 		Amount:            gameResult.Amount,
 		TransactionSource: gameResult.TransactionSource,
 		TransactionID:     gameResult.TransactionID,
 		CreatedAt:         gameResult.CreatedAt,
 	}
+
+	if gameResult.Decision != nil {
+		gameResultResponse.Decision = &ValidationDecisionResponse{
+			Decision:        gameResult.Decision.Decision,
+			Policy:          gameResult.Decision.Policy,
+			Reason:          gameResult.Decision.Reason,
+			BalanceBefore:   gameResult.Decision.BalanceBefore,
+			BalanceAfter:    gameResult.Decision.BalanceAfter,
+			ValidationRunID: gameResult.Decision.ValidationRunID,
+			CreatedAt:       gameResult.Decision.CreatedAt,
+		}
+	}
+
+	return gameResultResponse
+}
+
+// Transform entity.GameResultOutcome to server.GameResultBatchItemResponse
+func transformGameResultBatchItemResponse(index int, transactionID string, outcome entity.GameResultOutcome) GameResultBatchItemResponse {
+	itemResponse := GameResultBatchItemResponse{
+		Index:         index,
+		TransactionID: transactionID,
+		Status:        outcome.Status,
+	}
+
+	if outcome.GameResult != nil {
+		gameResultResponse := transformGameResultResponse(*outcome.GameResult)
+		itemResponse.GameResult = &gameResultResponse
+	}
+	if outcome.Err != nil {
+		problemResponse := transformProblemResponse(outcome.Err)
+		itemResponse.Error = outcome.Err.Error()
+		itemResponse.Code = problemResponse.Code
+		itemResponse.InvalidParams = problemResponse.InvalidParams
+
+		// The invalid items are described by the reasons of their parameters
+		if len(problemResponse.InvalidParams) > 0 {
+			reasons := make([]string, 0, len(problemResponse.InvalidParams))
+			for _, param := range problemResponse.InvalidParams {
+				reasons = append(reasons, param.Reason)
+			}
+			itemResponse.Error = strings.Join(reasons, ", ")
+		}
+	}
+
+	return itemResponse
+}
+
+// userHandler handles all requests related to users.
+type userHandler struct {
+	userDAO dao.UserDAO
+}
+
+func NewUserHandler(userDAO dao.UserDAO) *userHandler {
+	return &userHandler{
+		userDAO: userDAO,
+	}
+}
+
+// CreateUserFunc handles the request to create a new user.
+func (h *userHandler) CreateUserFunc(w http.ResponseWriter, r *http.Request) {
+	// Validate the request body.
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteProblemResponse(w, entity.ErrRequestPayload)
+		return
+	}
+
+	// Validate the initial balance type cast, when given
+	balance := entity.Money(0)
+	if req.Balance != "" {
+		var err error
+		balance, err = parseMoney(req.Balance, entity.ErrInvalidBalance)
+		if err != nil {
+			WriteProblemResponse(w, asInvalidParam("balance", err))
+			return
+		}
+	}
+
+	// Perform the business logic.
+	user, err := h.userDAO.CreateUser(r.Context(), req.Email, balance)
+	if err != nil {
+		writeUserErrorResponse(w, err)
+		return
+	}
+
+	userResponse := transformUserResponse(entity.UserAccount{User: *user, SettledBalance: user.Balance})
+	WriteAPIResponse(w, http.StatusCreated, userResponse)
+}
+
+// UpdateUserFunc handles the request to change the email of a user, or to disable it.
+func (h *userHandler) UpdateUserFunc(w http.ResponseWriter, r *http.Request) {
+	// Extract the user ID from the request path.
+	vars := mux.Vars(r)
+	userId, err := uuid.Parse(vars["id"])
+	if err != nil {
+		WriteProblemResponse(w, asInvalidParam("id", entity.ErrInvalidUser))
+		return
+	}
+
+	// Validate the request body.
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Email == nil && req.Disabled == nil) {
+		WriteProblemResponse(w, entity.ErrRequestPayload)
+		return
+	}
+
+	// Perform the business logic.
+	user, err := h.userDAO.UpdateUser(r.Context(), userId, entity.UserUpdate{Email: req.Email, Disabled: req.Disabled})
+	if err != nil {
+		writeUserErrorResponse(w, err)
+		return
+	}
+
+	// Read the account back, to include the balance breakdown
+	account, err := h.userDAO.GetUserAccount(r.Context(), user.ID)
+	if err != nil {
+		writeUserErrorResponse(w, err)
+		return
+	}
+
+	userResponse := transformUserResponse(*account)
+	WriteAPIResponse(w, http.StatusOK, userResponse)
+}
+
+// writeUserErrorResponse writes the errors of the user operations, the invalid fields being reported as such.
+func writeUserErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrInvalidEmail):
+		err = asInvalidParam("email", err)
+
+	case errors.Is(err, entity.ErrInvalidBalance):
+		err = asInvalidParam("balance", err)
+	}
+
+	WriteProblemResponse(w, err)
+}
+
+// GetUserFunc handles the request to read a user account.
+func (h *userHandler) GetUserFunc(w http.ResponseWriter, r *http.Request) {
+	// Extract the user ID from the request path.
+	vars := mux.Vars(r)
+	userId, err := uuid.Parse(vars["id"])
+	if err != nil {
+		WriteProblemResponse(w, asInvalidParam("id", entity.ErrInvalidUser))
+		return
+	}
+
+	// Perform the business logic.
+	account, err := h.userDAO.GetUserAccount(r.Context(), userId)
+	if err != nil {
+		writeUserErrorResponse(w, err)
+		return
+	}
+
+	userResponse := transformUserResponse(*account)
+	WriteAPIResponse(w, http.StatusOK, userResponse)
+}
+
+// Transform entity.UserAccount to server.UserResponse
+func transformUserResponse(account entity.UserAccount) UserResponse {
+	userResponse := UserResponse{
+		ID:             account.ID,
+		Email:          account.Email,
+		Balance:        account.Balance,
+		PendingBalance: account.PendingBalance,
+		SettledBalance: account.SettledBalance,
+		Disabled:       account.Disabled,
+		CreatedAt:      account.CreatedAt,
+	}
+
+	if account.LastGameResultAt.Valid {
+		userResponse.LastGameResultAt = &account.LastGameResultAt.Time
+	}
+	if account.GamesResultValidated.Valid {
+		userResponse.GamesResultValidated = &account.GamesResultValidated.Bool
+	}
+
+	return userResponse
+}
+
+// validationRunHandler handles all requests related to validation runs.
+type validationRunHandler struct {
+	validationRunDAO dao.ValidationRunDAO
+}
+
+func NewValidationRunHandler(validationRunDAO dao.ValidationRunDAO) *validationRunHandler {
+	return &validationRunHandler{
+		validationRunDAO: validationRunDAO,
+	}
+}
+
+// ListValidationRunsFunc handles the request to list the validation runs.
+func (h *validationRunHandler) ListValidationRunsFunc(w http.ResponseWriter, r *http.Request) {
+	// Validate the query parameters.
+	var params invalidParams
+	filter := parseValidationRunFilter(r.URL.Query(), &params)
+	if err := params.errOrNil(); err != nil {
+		WriteProblemResponse(w, err)
+		return
+	}
+
+	// Perform the business logic.
+	page, err := h.validationRunDAO.ListValidationRuns(r.Context(), *filter)
+	if err != nil {
+		WriteProblemResponse(w, err)
+		return
+	}
+
+	validationRunsResponse := ValidationRunListResponse{
+		ValidationRuns: make([]ValidationRunResponse, 0, len(page.ValidationRuns)),
+		NextCursor:     page.NextCursor,
+	}
+	for _, validationRun := range page.ValidationRuns {
+		validationRunsResponse.ValidationRuns = append(validationRunsResponse.ValidationRuns, transformValidationRunResponse(validationRun))
+	}
+
+	WriteAPIResponse(w, http.StatusOK, validationRunsResponse)
+}
+
+// GetValidationRunFunc handles the request to read a validation run.
+func (h *validationRunHandler) GetValidationRunFunc(w http.ResponseWriter, r *http.Request) {
+	// Extract the validation run ID from the request path.
+	vars := mux.Vars(r)
+	validationRunId, err := strconv.Atoi(vars["id"])
+	if err != nil || validationRunId <= 0 {
+		WriteProblemResponse(w, asInvalidParam("id", entity.ErrInvalidValidationRun))
+		return
+	}
+
+	// Perform the business logic.
+	validationRun, err := h.validationRunDAO.GetValidationRun(r.Context(), validationRunId)
+	if err != nil {
+		WriteProblemResponse(w, err)
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, transformValidationRunResponse(*validationRun))
+}
+
+// parseValidationRunFilter builds an entity.ValidationRunFilter out of the query parameters, adding the invalid ones to the params.
+func parseValidationRunFilter(query url.Values, params *invalidParams) *entity.ValidationRunFilter {
+	filter := entity.ValidationRunFilter{}
+
+	filter.StartedFrom, filter.StartedTo = parseDateRange(query, "started_from", "started_to", params)
+	filter.Cursor, filter.Limit = parsePage(query, DefaultValidationRunsLimit, MaxValidationRunsLimit, params)
+
+	return &filter
+}
+
+// Transform entity.ValidationRun to server.ValidationRunResponse
+func transformValidationRunResponse(validationRun entity.ValidationRun) ValidationRunResponse {
+	validationRunResponse := ValidationRunResponse{
+		ID:              validationRun.ID,
+		StartedAt:       validationRun.StartedAt,
+		FinishedAt:      validationRun.FinishedAt,
+		UsersProcessed:  validationRun.UsersProcessed,
+		ResultsApproved: validationRun.ResultsApproved,
+		ResultsCanceled: validationRun.ResultsCanceled,
+		BalanceDelta:    validationRun.BalanceDelta,
+	}
+
+	if validationRun.Error.Valid {
+		validationRunResponse.Error = &validationRun.Error.String
+	}
+
+	return validationRunResponse
+}
+
+// adminHandler handles all the operations requests.
+type adminHandler struct {
+	gameResultDAO dao.GameResultDAO
+}
+
+func NewAdminHandler(gameResultDAO dao.GameResultDAO) *adminHandler {
+	return &adminHandler{
+		gameResultDAO: gameResultDAO,
+	}
+}
+
+// ValidateUserFunc handles the request to validate the game results of a user right away.
+func (h *adminHandler) ValidateUserFunc(w http.ResponseWriter, r *http.Request) {
+	// Extract the user ID from the request path.
+	vars := mux.Vars(r)
+	userId, err := uuid.Parse(vars["id"])
+	if err != nil {
+		WriteProblemResponse(w, asInvalidParam("id", entity.ErrInvalidUser))
+		return
+	}
+
+	// Perform the business logic.
+	validationRun, err := h.gameResultDAO.ValidateUserGameResults(r.Context(), userId, dao.DefaultTotalGamesToCancel)
+	if err != nil {
+		if errors.Is(err, entity.ErrUserNotFound) {
+			WriteProblemResponse(w, err)
+			return
+		}
+
+		writeValidationRunErrorResponse(w, validationRun)
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, transformValidationRunResponse(*validationRun))
+}
+
+// ValidateGameResultsFunc handles the request to run a validation round right away.
+func (h *adminHandler) ValidateGameResultsFunc(w http.ResponseWriter, r *http.Request) {
+	// Perform the business logic.
+	validationRun, err := h.gameResultDAO.ValidateGameResults(r.Context(), dao.DefaultTotalGamesToCancel)
+	if err != nil {
+		writeValidationRunErrorResponse(w, validationRun)
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, transformValidationRunResponse(*validationRun))
+}
+
+// writeValidationRunErrorResponse writes the failure of a validation run, along with the run itself when recorded.
+func writeValidationRunErrorResponse(w http.ResponseWriter, validationRun *entity.ValidationRun) {
+	if validationRun == nil {
+		WriteProblemResponse(w, entity.ErrServerInternal)
+		return
+	}
+
+	WriteProblemResponseWithData(w, entity.ErrValidationFailed, transformValidationRunResponse(*validationRun))
+}
+
+// apiKeyHandler handles all requests related to the api keys of the transaction sources.
+type apiKeyHandler struct {
+	apiKeyDAO dao.APIKeyDAO
+}
+
+func NewAPIKeyHandler(apiKeyDAO dao.APIKeyDAO) *apiKeyHandler {
+	return &apiKeyHandler{
+		apiKeyDAO: apiKeyDAO,
+	}
+}
+
+// CreateAPIKeyFunc handles the request to create a new api key for a transaction source.
+func (h *apiKeyHandler) CreateAPIKeyFunc(w http.ResponseWriter, r *http.Request) {
+	// Validate the request body.
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteProblemResponse(w, entity.ErrRequestPayload)
+		return
+	}
+
+	// Perform the business logic.
+	apiKey, key, err := h.apiKeyDAO.CreateAPIKey(r.Context(), entity.TransactionSource(strings.ToLower(string(req.TransactionSource))), req.Description)
+	if err != nil {
+		writeAPIKeyErrorResponse(w, err)
+		return
+	}
+
+	apiKeyResponse := transformAPIKeyResponse(*apiKey, time.Now())
+	apiKeyResponse.Key = key
+	apiKeyResponse.SigningKey = apiKey.SigningKey
+	WriteAPIResponse(w, http.StatusCreated, apiKeyResponse)
+}
+
+// ListAPIKeysFunc handles the request to list the api keys, including the expired and revoked ones.
+func (h *apiKeyHandler) ListAPIKeysFunc(w http.ResponseWriter, r *http.Request) {
+	// Perform the business logic.
+	apiKeys, err := h.apiKeyDAO.ListAPIKeys(r.Context())
+	if err != nil {
+		writeAPIKeyErrorResponse(w, err)
+		return
+	}
+
+	now := time.Now()
+	listResponse := APIKeyListResponse{
+		APIKeys: make([]APIKeyResponse, 0, len(apiKeys)),
+	}
+	for _, apiKey := range apiKeys {
+		listResponse.APIKeys = append(listResponse.APIKeys, transformAPIKeyResponse(apiKey, now))
+	}
+
+	WriteAPIResponse(w, http.StatusOK, listResponse)
+}
+
+// RotateAPIKeyFunc handles the request to replace an api key by a new one.
+// The replaced key is still accepted during the grace period.
+func (h *apiKeyHandler) RotateAPIKeyFunc(w http.ResponseWriter, r *http.Request) {
+	apiKeyId, err := parseAPIKeyId(r)
+	if err != nil {
+		WriteProblemResponse(w, asInvalidParam("id", err))
+		return
+	}
+
+	// Validate the request body, which is optional.
+	var req RotateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteProblemResponse(w, entity.ErrRequestPayload)
+		return
+	}
+
+	gracePeriod := dao.DefaultAPIKeyGracePeriod
+	if req.GracePeriod != "" {
+		gracePeriod, err = time.ParseDuration(req.GracePeriod)
+		if err != nil {
+			WriteProblemResponse(w, asInvalidParam("gracePeriod", entity.ErrInvalidGracePeriod))
+			return
+		}
+	}
+
+	// Perform the business logic.
+	apiKey, key, err := h.apiKeyDAO.RotateAPIKey(r.Context(), apiKeyId, gracePeriod)
+	if err != nil {
+		writeAPIKeyErrorResponse(w, err)
+		return
+	}
+
+	apiKeyResponse := transformAPIKeyResponse(*apiKey, time.Now())
+	apiKeyResponse.Key = key
+	apiKeyResponse.SigningKey = apiKey.SigningKey
+	WriteAPIResponse(w, http.StatusCreated, apiKeyResponse)
+}
+
+// RevokeAPIKeyFunc handles the request to revoke an api key right away.
+func (h *apiKeyHandler) RevokeAPIKeyFunc(w http.ResponseWriter, r *http.Request) {
+	apiKeyId, err := parseAPIKeyId(r)
+	if err != nil {
+		WriteProblemResponse(w, asInvalidParam("id", err))
+		return
+	}
+
+	// Perform the business logic.
+	apiKey, err := h.apiKeyDAO.RevokeAPIKey(r.Context(), apiKeyId)
+	if err != nil {
+		writeAPIKeyErrorResponse(w, err)
+		return
+	}
+
+	WriteAPIResponse(w, http.StatusOK, transformAPIKeyResponse(*apiKey, time.Now()))
+}
+
+// parseAPIKeyId extracts the api key ID from the request path.
+func parseAPIKeyId(r *http.Request) (int, error) {
+	vars := mux.Vars(r)
+	apiKeyId, err := strconv.Atoi(vars["id"])
+	if err != nil || apiKeyId <= 0 {
+		return 0, entity.ErrInvalidAPIKey
+	}
+	return apiKeyId, nil
+}
+
+// writeAPIKeyErrorResponse writes the errors of the api key operations, the invalid fields being reported as such.
+func writeAPIKeyErrorResponse(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, entity.ErrInvalidTransactionSource):
+		err = asInvalidParam("source", err)
+
+	case errors.Is(err, entity.ErrInvalidGracePeriod):
+		err = asInvalidParam("gracePeriod", err)
+	}
+
+	WriteProblemResponse(w, err)
+}
+
+// transformAPIKeyResponse transforms an entity.APIKey into an APIKeyResponse, never holding the key hash.
+func transformAPIKeyResponse(apiKey entity.APIKey, now time.Time) APIKeyResponse {
+	apiKeyResponse := APIKeyResponse{
+		ID:                apiKey.ID,
+		Prefix:            apiKey.Prefix,
+		TransactionSource: apiKey.TransactionSource,
+		Description:       apiKey.Description,
+		Active:            apiKey.Active(now),
+		CreatedAt:         apiKey.CreatedAt,
+	}
+
+	if apiKey.ExpiresAt.Valid {
+		apiKeyResponse.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+	if apiKey.RevokedAt.Valid {
+		apiKeyResponse.RevokedAt = &apiKey.RevokedAt.Time
+	}
+
+	return apiKeyResponse
 }
@@ -2,10 +2,16 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/ildomm/cceab/dao"
 	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/signing"
 	"github.com/ildomm/cceab/test_helpers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,6 +24,35 @@ import (
 	"time"
 )
 
+const (
+	// testAPIKey is the api key accepted by the servers of the tests, created before the signing, so without a signing key
+	testAPIKey = "cceab_test"
+
+	// testSignedAPIKey is the api key accepted by the servers of the tests, holding the testSigningKey
+	testSignedAPIKey = "cceab_signed"
+)
+
+// testSigningKey is the signing key of the testSignedAPIKey
+var testSigningKey = []byte("0123456789abcdef0123456789abcdef")
+
+// withTestAPIKey makes the server accept the testAPIKey and the testSignedAPIKey, on behalf of the given transaction source
+func withTestAPIKey(server *Server, transactionSource entity.TransactionSource) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, testAPIKey).Return(&entity.APIKey{
+		ID:                1,
+		TransactionSource: transactionSource,
+	}, nil)
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, testSignedAPIKey).Return(&entity.APIKey{
+		ID:                2,
+		TransactionSource: transactionSource,
+		SigningKey:        hex.EncodeToString(testSigningKey),
+	}, nil)
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(nil, entity.ErrUnauthorized)
+	mockAPIKeyDAO.On("RecordNonce", mock.Anything, 2, mock.Anything, mock.Anything).Return(nil)
+
+	server.WithAPIKeyManager(mockAPIKeyDAO)
+}
+
 // TestHealthHandlerSuccess tests the Health function for a successful response.
 func TestHealthHandlerSuccess(t *testing.T) {
 	req, err := http.NewRequest(http.MethodGet, "/health", nil)
@@ -46,6 +81,122 @@ func TestHealthHandlerSuccess(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// decodeHealthCheckResponse executes the health check request and decodes its health+json response.
+func decodeHealthCheckResponse(t *testing.T, server *Server, path string) (int, HealthCheckResponse) {
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp, err := http.Get(testServer.URL + path)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/health+json", resp.Header.Get("Content-Type"))
+
+	var health HealthCheckResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	return resp.StatusCode, health
+}
+
+// TestLiveHandler tests the LiveHandler reports the service version.
+func TestLiveHandler(t *testing.T) {
+	server := NewServer()
+	server.WithVersion("1.2.3", "abc1234")
+
+	code, health := decodeHealthCheckResponse(t, server, "/api/v1/health/live")
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusPass, health.Status)
+	assert.Equal(t, "1.2.3", health.Version)
+	assert.Equal(t, "abc1234", health.ReleaseID)
+}
+
+// TestReadyHandlerSuccess tests the ReadyHandler reports the database checks.
+func TestReadyHandlerSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockHealthDAO()
+	mockDAO.On("CheckDatabase", mock.Anything).Return(&entity.DatabaseHealth{
+		ResponseTime:     1500 * time.Microsecond,
+		MigrationVersion: 11,
+		OpenConnections:  3,
+	}, nil)
+
+	server := NewServer()
+	server.WithHealthManager(mockDAO)
+	server.WithVersion("1.2.3", "abc1234")
+
+	code, health := decodeHealthCheckResponse(t, server, "/api/v1/health/ready")
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, HealthStatusPass, health.Status)
+	assert.Equal(t, "1.2.3", health.Version)
+	require.Len(t, health.Checks["postgres:responseTime"], 1)
+	assert.Equal(t, 1.5, health.Checks["postgres:responseTime"][0].ObservedValue)
+	assert.Equal(t, float64(11), health.Checks["postgres:migrationVersion"][0].ObservedValue)
+	assert.Equal(t, float64(3), health.Checks["postgres:openConnections"][0].ObservedValue)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestReadyHandlerFailures tests the ReadyHandler fails when the database is not ready, or the server is draining.
+func TestReadyHandlerFailures(t *testing.T) {
+	tests := []struct {
+		name           string
+		databaseHealth *entity.DatabaseHealth
+		err            error
+		draining       bool
+		failedCheck    string
+	}{
+		{"Database down", nil, errors.New("dial tcp 10.0.0.5:5432: connection refused"), false, "postgres:responseTime"},
+		{"Dirty migration", &entity.DatabaseHealth{MigrationVersion: 11, MigrationDirty: true}, nil, false, "postgres:migrationVersion"},
+		{"Draining", &entity.DatabaseHealth{MigrationVersion: 11}, nil, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDAO := test_helpers.NewMockHealthDAO()
+			if tt.err != nil {
+				mockDAO.On("CheckDatabase", mock.Anything).Return(nil, tt.err)
+			} else {
+				mockDAO.On("CheckDatabase", mock.Anything).Return(tt.databaseHealth, nil)
+			}
+
+			server := NewServer()
+			server.WithHealthManager(mockDAO)
+			if tt.draining {
+				require.NoError(t, server.Shutdown(context.Background()))
+			}
+
+			code, health := decodeHealthCheckResponse(t, server, "/api/v1/health/ready")
+
+			assert.Equal(t, http.StatusServiceUnavailable, code)
+			assert.Equal(t, HealthStatusFail, health.Status)
+			if tt.failedCheck != "" {
+				require.Len(t, health.Checks[tt.failedCheck], 1)
+				assert.Equal(t, HealthStatusFail, health.Checks[tt.failedCheck][0].Status)
+				assert.NotEmpty(t, health.Checks[tt.failedCheck][0].Output)
+			}
+			if tt.err != nil {
+				assert.Equal(t, "database unreachable", health.Checks[tt.failedCheck][0].Output, "the database error should not be disclosed")
+			}
+		})
+	}
+}
+
+// TestHealthHandlerDraining tests the Health function fails while the server drains its requests.
+func TestHealthHandlerDraining(t *testing.T) {
+	server := NewServer()
+	require.NoError(t, server.Shutdown(context.Background()))
+
+	rr := httptest.NewRecorder()
+	server.HealthHandler(rr, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code, "handler returned wrong status code")
+
+	var actual struct {
+		Data HealthResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&actual))
+	assert.Equal(t, "fail", actual.Data.Status)
+}
+
 // TestGameResultFuncSuccess tests the CreateGameResultFunc for a successful response using a real server.
 func TestGameResultFuncSuccess(t *testing.T) {
 	mockDAO := test_helpers.NewMockGameResultDAO()
@@ -55,7 +206,7 @@ func TestGameResultFuncSuccess(t *testing.T) {
 		ID:            1,
 		UserID:        uuid.New(),
 		GameStatus:    "win",
-		Amount:        100,
+		Amount:        100_00,
 		TransactionID: "123",
 		CreatedAt:     time.Now(),
 	}
@@ -70,6 +221,7 @@ func TestGameResultFuncSuccess(t *testing.T) {
 
 	// Create the server and set the mock manager
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithGameResultManager(mockDAO)
 	server.WithListenAddress(port)
@@ -91,7 +243,7 @@ func TestGameResultFuncSuccess(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, testGameResult.UserID.String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -115,6 +267,7 @@ func TestGameResultFuncSuccess(t *testing.T) {
 // TestGameResultFuncInvalidRequest tests the CreateGameResultFunc with an invalid request body.
 func TestGameResultFuncInvalidRequest(t *testing.T) {
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithListenAddress(port)
 
@@ -128,7 +281,7 @@ func TestGameResultFuncInvalidRequest(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -145,6 +298,7 @@ func TestGameResultFuncInvalidRequest(t *testing.T) {
 // TestGameResultFuncInvalidUserID tests the CreateGameResultFunc with an invalid user ID.
 func TestGameResultFuncInvalidUserID(t *testing.T) {
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithListenAddress(port)
 
@@ -165,7 +319,7 @@ func TestGameResultFuncInvalidUserID(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/invalid-user-id/game_results", port)
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -195,6 +349,7 @@ func TestGameResultFuncUserNotFound(t *testing.T) {
 
 	// Create the server and set the mock manager
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithGameResultManager(mockDAO)
 	server.WithListenAddress(port)
@@ -216,7 +371,7 @@ func TestGameResultFuncUserNotFound(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -246,6 +401,7 @@ func TestGameResultFuncTransactionIDExists(t *testing.T) {
 
 	// Create the server and set the mock manager
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithGameResultManager(mockDAO)
 	server.WithListenAddress(port)
@@ -267,7 +423,7 @@ func TestGameResultFuncTransactionIDExists(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -297,6 +453,7 @@ func TestGameResultFuncUserNegativeBalance(t *testing.T) {
 
 	// Create the server and set the mock manager
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithGameResultManager(mockDAO)
 	server.WithListenAddress(port)
@@ -318,7 +475,7 @@ func TestGameResultFuncUserNegativeBalance(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -348,6 +505,7 @@ func TestGameResultFuncInvalidGameStatus(t *testing.T) {
 
 	// Create the server and set the mock manager
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithGameResultManager(mockDAO)
 	server.WithListenAddress(port)
@@ -369,7 +527,7 @@ func TestGameResultFuncInvalidGameStatus(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -383,55 +541,214 @@ func TestGameResultFuncInvalidGameStatus(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "CreateDeviceFunc returned wrong status code for invalid game status")
 }
 
-// TestGameResultFuncInvalidTransactionSource tests the CreateGameResultFunc with an invalid transaction source.
-func TestGameResultFuncInvalidTransactionSource(t *testing.T) {
+// TestGameResultFuncUnauthenticated tests the game results are rejected without a valid api key,
+// whatever the Source-Type header tells.
+func TestGameResultFuncUnauthenticated(t *testing.T) {
 	mockDAO := test_helpers.NewMockGameResultDAO()
 
-	// Set up mock expectations
-	mockDAO.On("CreateGameResult",
-		mock.Anything,
-		mock.Anything,
-		mock.Anything,
-		mock.Anything,
-		mock.Anything,
-		mock.Anything,
-	).Return(nil, entity.ErrInvalidGameStatus)
-
 	// Create the server and set the mock manager
 	server := NewServer()
-	port := rand.Intn(1000) + 8000
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	server.WithGameResultManager(mockDAO)
-	server.WithListenAddress(port)
 
-	go func() {
-		err := server.Run()
-		assert.NoError(t, err, "server failed to run")
-	}()
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
 
-	// Create the request body
-	reqBody := CreateGameResultRequest{
-		GameStatus:    "invalid-status",
-		Amount:        "100",
-		TransactionID: "123",
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"Missing api key", "Source-Type", string(entity.TransactionSourceGame)},
+		{"Unknown api key", APIKeyHeader, "cceab_unknown"},
 	}
-	body, _ := json.Marshal(reqBody)
+
+	for _, tt := range tests {
+		for _, path := range []string{fmt.Sprintf("/api/v1/users/%s/game_results", uuid.New()), "/api/v1/game_results:batch"} {
+			t.Run(tt.name+" "+path, func(t *testing.T) {
+				req, err := http.NewRequest(http.MethodPost, testServer.URL+path, bytes.NewReader(body))
+				require.NoError(t, err)
+				req.Header.Set(tt.header, tt.value)
+
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err, "request to server failed")
+				defer resp.Body.Close()
+
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+				assert.Equal(t, "APIKey", resp.Header.Get("WWW-Authenticate"))
+			})
+		}
+	}
+
+	mockDAO.AssertNotCalled(t, "CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockDAO.AssertNotCalled(t, "CreateGameResults", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGameResultFuncSourceFromAPIKey tests the transaction source is the one bound to the api key, not the Source-Type header.
+func TestGameResultFuncSourceFromAPIKey(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	userId := uuid.New()
+	mockDAO.On("CreateGameResult", mock.Anything, userId, entity.GameStatusWin, entity.Money(100_00), entity.TransactionSourcePayment, "123").
+		Return(&entity.GameResult{ID: 1, UserID: userId, TransactionSource: entity.TransactionSourcePayment}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourcePayment)
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"})
 
 	// Create the request
-	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
+	url := fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, userId)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, testAPIKey)
+	req.Header.Set("Source-Type", string(entity.TransactionSourceGame))
+
+	// Execute the request
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestGameResultFuncSignedRequest tests the CreateGameResultFunc requires the signature of the api keys holding a signing key, as sent by the signing package.
+// The signatures are not required by the server options, the signing key of the api key is enough.
+func TestGameResultFuncSignedRequest(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	userId := uuid.New()
+	mockDAO.On("CreateGameResult", mock.Anything, userId, entity.GameStatusWin, entity.Money(10_15), entity.TransactionSourceGame, "123").
+		Return(&entity.GameResult{ID: 1, UserID: userId}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: "10.15", TransactionID: "123"})
+	url := fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, userId)
+
+	// Without the signature, the request is rejected
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", "invalid-source")
+	req.Header.Set(APIKeyHeader, testSignedAPIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Signed with another key, the request is rejected
+	client := &http.Client{Transport: &signing.Transport{APIKey: testSignedAPIKey, SigningKey: []byte("fedcba9876543210fedcba9876543210")}}
+	req, err = http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	resp, err = client.Do(req)
+	require.NoError(t, err, "request to server failed")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Signed, the request goes through
+	client = &http.Client{Transport: &signing.Transport{APIKey: testSignedAPIKey, SigningKey: testSigningKey}}
+	req, err = http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+
+	resp, err = client.Do(req)
+	require.NoError(t, err, "request to server failed")
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestGameResultFuncRateLimited tests the CreateGameResultFunc is rate limited by user, without affecting the other users.
+func TestGameResultFuncRateLimited(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+	mockDAO.On("CreateGameResult", mock.Anything, mock.Anything, entity.GameStatusWin, entity.Money(1_00), entity.TransactionSourceGame, "123").
+		Return(&entity.GameResult{ID: 1}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+	server.WithGameResultManager(mockDAO)
+	server.WithRateLimits(RateLimit{Rate: 0.001, Burst: 1}, RateLimit{})
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: "1", TransactionID: "123"})
+	send := func(userId uuid.UUID) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, userId), bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(APIKeyHeader, testAPIKey)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "request to server failed")
+		resp.Body.Close()
+		return resp
+	}
+
+	userId := uuid.New()
+	assert.Equal(t, http.StatusCreated, send(userId).StatusCode)
+
+	resp := send(userId)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	assert.Equal(t, http.StatusCreated, send(uuid.New()).StatusCode)
+	mockDAO.AssertNumberOfCalls(t, "CreateGameResult", 2)
+}
+
+// TestGameResultFuncInvalidParams tests the CreateGameResultFunc reports all the invalid parameters at once, as a problem.
+func TestGameResultFuncInvalidParams(t *testing.T) {
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
 	defer testServer.Close()
 
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "draw", Amount: "ten", TransactionID: "123"})
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/users/not-a-uuid/game_results", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, testAPIKey)
+
 	// Execute the request
 	resp, err := http.DefaultClient.Do(req)
-	assert.NoError(t, err, "request to server failed")
+	require.NoError(t, err, "request to server failed")
 	defer resp.Body.Close()
 
-	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "CreateDeviceFunc returned wrong status code for invalid game source")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+
+	var respBody ProblemResponse
+	err = json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, "invalid_parameters", respBody.Code)
+	assert.Equal(t, []InvalidParamResponse{
+		{Name: "id", Code: "invalid_user_id", Reason: entity.ErrInvalidUser.Error()},
+		{Name: "amount", Code: "invalid_amount", Reason: entity.ErrInvalidAmount.Error()},
+		{Name: "state", Code: "invalid_game_status", Reason: entity.ErrInvalidGameStatus.Error()},
+	}, respBody.InvalidParams)
 }
 
 // TestGameResultFuncInvalidAmountFormat tests the CreateGameResultFunc with an invalid amount
@@ -450,6 +767,7 @@ func TestGameResultFuncInvalidAmountFormat(t *testing.T) {
 
 	// Create the server and set the mock manager
 	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
 	port := rand.Intn(1000) + 8000
 	server.WithGameResultManager(mockDAO)
 	server.WithListenAddress(port)
@@ -471,7 +789,7 @@ func TestGameResultFuncInvalidAmountFormat(t *testing.T) {
 	url := fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New().String())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
-	req.Header.Set("source-type", string(entity.TransactionSourceGame))
+	req.Header.Set(APIKeyHeader, testAPIKey)
 
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
@@ -484,3 +802,1408 @@ func TestGameResultFuncInvalidAmountFormat(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+// TestGameResultFuncAmountPrecision tests the CreateGameResultFunc with amounts the database can not hold exactly.
+func TestGameResultFuncAmountPrecision(t *testing.T) {
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := map[string]error{
+		"10.155":    entity.ErrMoneyPrecision,
+		"100000000": entity.ErrMoneyOutOfRange,
+	}
+
+	for amount, expectedErr := range tests {
+		body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: amount, TransactionID: "123"})
+
+		// Create the request
+		url := fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, uuid.New().String())
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(APIKeyHeader, testAPIKey)
+
+		// Execute the request
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "request to server failed")
+
+		var respBody ProblemResponse
+		err = json.NewDecoder(resp.Body).Decode(&respBody)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "invalid_parameters", respBody.Code)
+		assert.Equal(t, []InvalidParamResponse{{
+			Name:   "amount",
+			Code:   "invalid_amount",
+			Reason: fmt.Sprintf("%s: %s", entity.ErrInvalidAmount, expectedErr),
+		}}, respBody.InvalidParams)
+	}
+}
+
+// TestGameResultFuncAmountNotPositive tests the CreateGameResultFunc with negative and zero amounts, which would credit the lost games.
+func TestGameResultFuncAmountNotPositive(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	for _, amount := range []string{"-1", "0", "0.00", "-100"} {
+		body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "lost", Amount: amount, TransactionID: "123"})
+
+		// Create the request
+		url := fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, uuid.New().String())
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set(APIKeyHeader, testAPIKey)
+
+		// Execute the request
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "request to server failed")
+
+		var respBody ProblemResponse
+		err = json.NewDecoder(resp.Body).Decode(&respBody)
+		resp.Body.Close()
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, amount)
+		assert.Equal(t, "invalid_parameters", respBody.Code)
+		assert.Equal(t, []InvalidParamResponse{{
+			Name:   "amount",
+			Code:   "invalid_amount",
+			Reason: fmt.Sprintf("%s: %s", entity.ErrInvalidAmount, entity.ErrAmountNotPositive),
+		}}, respBody.InvalidParams, amount)
+	}
+
+	// The game results are never recorded
+	mockDAO.AssertNotCalled(t, "CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestListGameResultsFuncSuccess tests the ListGameResultsFunc for a successful response.
+func TestListGameResultsFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	userId := uuid.New()
+	validationStatus := entity.ValidationStatusAccepted
+	source := entity.TransactionSourceGame
+	expectedFilter := entity.GameResultFilter{
+		UserID:            userId,
+		ValidationStatus:  &validationStatus,
+		TransactionSource: &source,
+		Cursor:            40,
+		Limit:             2,
+	}
+
+	// Set up mock expectations
+	mockDAO.On("ListGameResults", mock.Anything, expectedFilter).Return(&entity.GameResultPage{
+		GameResults: []entity.GameResult{
+			{ID: 31, UserID: userId, GameStatus: entity.GameStatusWin, ValidationStatus: validationStatus},
+			{ID: 30, UserID: userId, GameStatus: entity.GameStatusLost, ValidationStatus: validationStatus},
+		},
+		NextCursor: 30,
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	url := fmt.Sprintf("%s/api/v1/users/%s/game_results?validation_status=accepted&transaction_source=game&cursor=40&limit=2", testServer.URL, userId)
+	resp := adminRequestWithBody(t, http.MethodGet, url, "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "ListGameResultsFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data GameResultListResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Len(t, respBody.Data.GameResults, 2)
+	assert.Equal(t, 30, respBody.Data.NextCursor)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestListGameResultsFuncDateRange tests the ListGameResultsFunc includes the whole day of a plain date upper bound.
+func TestListGameResultsFuncDateRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		expectedFrom time.Time
+		expectedTo   time.Time
+	}{
+		{"Plain dates", "created_from=2024-01-31&created_to=2024-01-31",
+			time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 23, 59, 59, 999_999_000, time.UTC)},
+		{"Timestamps", "created_from=2024-01-01T10:00:00Z&created_to=2024-01-31T10:00:00Z",
+			time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDAO := test_helpers.NewMockGameResultDAO()
+
+			userId := uuid.New()
+			mockDAO.On("ListGameResults", mock.Anything, mock.MatchedBy(func(filter entity.GameResultFilter) bool {
+				return filter.CreatedFrom.Equal(tt.expectedFrom) && filter.CreatedTo.Equal(tt.expectedTo)
+			})).Return(&entity.GameResultPage{}, nil)
+
+			// Create the server and set the mock manager
+			server := NewServer()
+			server.WithAdminToken("admin-token")
+			server.WithGameResultManager(mockDAO)
+
+			// Use httptest to create a server
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			// Execute the request
+			resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s/game_results?%s", testServer.URL, userId, tt.query), "")
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			mockDAO.AssertExpectations(t)
+		})
+	}
+}
+
+// TestListGameResultsFuncValidationDecision tests the ListGameResultsFunc exposes the validation decisions.
+func TestListGameResultsFuncValidationDecision(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	userId := uuid.New()
+
+	// Set up mock expectations
+	mockDAO.On("ListGameResults", mock.Anything, mock.Anything).Return(&entity.GameResultPage{
+		GameResults: []entity.GameResult{
+			{ID: 31, UserID: userId, ValidationStatus: entity.ValidationStatusCanceled, Decision: &entity.ValidationDecision{
+				GameResultID:    31,
+				Decision:        entity.ValidationStatusCanceled,
+				Policy:          "odd_id",
+				Reason:          entity.ValidationReasonCanceledByPolicy,
+				BalanceBefore:   100_00,
+				BalanceAfter:    90_00,
+				ValidationRunID: 5,
+			}},
+			{ID: 30, UserID: userId, ValidationStatus: entity.ValidationStatusPending},
+		},
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, userId), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "ListGameResultsFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data GameResultListResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	require.Len(t, respBody.Data.GameResults, 2)
+	decision := respBody.Data.GameResults[0].Decision
+	require.NotNil(t, decision)
+	assert.Equal(t, "odd_id", decision.Policy)
+	assert.Equal(t, entity.ValidationReasonCanceledByPolicy, decision.Reason)
+	assert.Equal(t, entity.Money(100_00), decision.BalanceBefore)
+	assert.Equal(t, entity.Money(90_00), decision.BalanceAfter)
+	assert.Equal(t, 5, decision.ValidationRunID)
+	assert.Nil(t, respBody.Data.GameResults[1].Decision)
+}
+
+// TestListGameResultsFuncInvalidFilters tests the ListGameResultsFunc with invalid query parameters.
+func TestListGameResultsFuncInvalidFilters(t *testing.T) {
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"Invalid validation status", "validation_status=unknown"},
+		{"Invalid game status", "game_status=draw"},
+		{"Invalid transaction source", "transaction_source=bank"},
+		{"Invalid date", "created_from=yesterday"},
+		{"Inverted date range", "created_from=2024-02-01&created_to=2024-01-01"},
+		{"Invalid cursor", "cursor=-1"},
+		{"Limit too big", fmt.Sprintf("limit=%d", MaxGameResultsLimit+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/api/v1/users/%s/game_results?%s", testServer.URL, uuid.New(), tt.query)
+			resp := adminRequestWithBody(t, http.MethodGet, url, "")
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "ListGameResultsFunc returned wrong status code")
+		})
+	}
+}
+
+// TestListGameResultsFuncUserNotFound tests the ListGameResultsFunc when the user is not found.
+func TestListGameResultsFuncUserNotFound(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Set up mock expectations
+	mockDAO.On("ListGameResults", mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, uuid.New()), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "ListGameResultsFunc returned wrong status code for user not found")
+}
+
+// TestListGameResultsFuncUnauthorized tests the game results are only listed to the admin token holders.
+func TestListGameResultsFuncUnauthorized(t *testing.T) {
+	server := NewServer()
+	server.WithGameResultManager(test_helpers.NewMockGameResultDAO())
+	server.WithAdminToken("admin-token")
+	withTestAPIKey(server, entity.TransactionSourceGame)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Neither without credentials, nor with the api key of a transaction source
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, uuid.New()), nil)
+	require.NoError(t, err)
+	for _, apiKey := range []string{"", testAPIKey} {
+		req.Header.Set(APIKeyHeader, apiKey)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "request to server failed")
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+}
+
+// TestGetUserFuncSuccess tests the GetUserFunc for a successful response.
+func TestGetUserFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	userId := uuid.New()
+
+	// Set up mock expectations
+	mockDAO.On("GetUserAccount", mock.Anything, userId).Return(&entity.UserAccount{
+		User: entity.User{
+			ID:                   userId,
+			Email:                "user@example.com",
+			Balance:              150_00,
+			GamesResultValidated: sql.NullBool{Bool: false, Valid: true},
+			CreatedAt:            time.Now(),
+		},
+		PendingBalance: 50_00,
+		SettledBalance: 100_00,
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, userId), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "GetUserFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data UserResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, userId, respBody.Data.ID)
+	assert.Equal(t, entity.Money(150_00), respBody.Data.Balance)
+	assert.Equal(t, entity.Money(50_00), respBody.Data.PendingBalance)
+	assert.Equal(t, entity.Money(100_00), respBody.Data.SettledBalance)
+	assert.Nil(t, respBody.Data.LastGameResultAt)
+	require.NotNil(t, respBody.Data.GamesResultValidated)
+	assert.False(t, *respBody.Data.GamesResultValidated)
+}
+
+// TestGetUserFuncInvalidUserID tests the GetUserFunc with an invalid user ID.
+func TestGetUserFuncInvalidUserID(t *testing.T) {
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/users/invalid-user-id", testServer.URL), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "GetUserFunc returned wrong status code for invalid user ID")
+}
+
+// TestGetUserFuncUserNotFound tests the GetUserFunc when the user is not found.
+func TestGetUserFuncUserNotFound(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	// Set up mock expectations
+	mockDAO.On("GetUserAccount", mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, uuid.New()), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "GetUserFunc returned wrong status code for user not found")
+}
+
+// TestCreateUserFuncSuccess tests the CreateUserFunc for a successful response.
+func TestCreateUserFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	userId := uuid.New()
+
+	// Set up mock expectations
+	mockDAO.On("CreateUser", mock.Anything, "user@example.com", entity.Money(12_50)).Return(&entity.User{
+		ID:        userId,
+		Email:     "user@example.com",
+		Balance:   12_50,
+		CreatedAt: time.Now(),
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body, _ := json.Marshal(CreateUserRequest{Email: "user@example.com", Balance: "12.5"})
+	resp := adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/users", string(body))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "CreateUserFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data UserResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, userId, respBody.Data.ID)
+	assert.Equal(t, entity.Money(12_50), respBody.Data.SettledBalance)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestCreateUserFuncInvalidBalance tests the CreateUserFunc with a malformed balance.
+func TestCreateUserFuncInvalidBalance(t *testing.T) {
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body, _ := json.Marshal(CreateUserRequest{Email: "user@example.com", Balance: "ab.x.e"})
+	resp := adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/users", string(body))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "CreateUserFunc returned wrong status code for invalid balance")
+}
+
+// TestCreateUserFuncBalancePrecision tests the CreateUserFunc with a balance having more than two decimal places.
+func TestCreateUserFuncBalancePrecision(t *testing.T) {
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body, _ := json.Marshal(CreateUserRequest{Email: "user@example.com", Balance: "12.505"})
+	resp := adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/users", string(body))
+	defer resp.Body.Close()
+
+	var respBody ProblemResponse
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "CreateUserFunc returned wrong status code for a balance too precise")
+	assert.Equal(t, []InvalidParamResponse{{Name: "balance", Code: "invalid_balance", Reason: "invalid balance: more than two decimal places"}}, respBody.InvalidParams)
+}
+
+// TestCreateUserFuncEmailExists tests the CreateUserFunc when the email is already taken.
+func TestCreateUserFuncEmailExists(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	// Set up mock expectations
+	mockDAO.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserEmailExists)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body, _ := json.Marshal(CreateUserRequest{Email: "user1@example.com"})
+	resp := adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/users", string(body))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "CreateUserFunc returned wrong status code for existing email")
+}
+
+// TestUpdateUserFuncSuccess tests the UpdateUserFunc for a successful response.
+func TestUpdateUserFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	userId := uuid.New()
+	disabled := true
+	user := entity.User{ID: userId, Email: "user@example.com", Disabled: true}
+
+	// Set up mock expectations
+	mockDAO.On("UpdateUser", mock.Anything, userId, entity.UserUpdate{Disabled: &disabled}).Return(&user, nil)
+	mockDAO.On("GetUserAccount", mock.Anything, userId).Return(&entity.UserAccount{User: user}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	url := fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, userId)
+	resp := adminRequestWithBody(t, http.MethodPatch, url, `{"disabled": true}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "UpdateUserFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data UserResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.True(t, respBody.Data.Disabled)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestUpdateUserFuncEmptyPayload tests the UpdateUserFunc without any change requested.
+func TestUpdateUserFuncEmptyPayload(t *testing.T) {
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	url := fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, uuid.New())
+	resp := adminRequestWithBody(t, http.MethodPatch, url, `{}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "UpdateUserFunc returned wrong status code for empty payload")
+}
+
+// TestUpdateUserFuncEmailExists tests the UpdateUserFunc when the new email is already taken.
+func TestUpdateUserFuncEmailExists(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	// Set up mock expectations
+	mockDAO.On("UpdateUser", mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserEmailExists)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	url := fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, uuid.New())
+	resp := adminRequestWithBody(t, http.MethodPatch, url, `{"email": "user2@example.com"}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "UpdateUserFunc returned wrong status code for existing email")
+}
+
+// TestUserFuncsUnauthenticated tests the creation, the read and the update of the users are restricted to the admin token holders.
+func TestUserFuncsUnauthenticated(t *testing.T) {
+	mockDAO := test_helpers.NewMockUserDAO()
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+	server.WithUserManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	requests := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{http.MethodPost, testServer.URL + "/api/v1/users", `{"email": "user@example.com", "balance": "1000000"}`},
+		{http.MethodGet, fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, uuid.New()), ""},
+		{http.MethodPatch, fmt.Sprintf("%s/api/v1/users/%s", testServer.URL, uuid.New()), `{"disabled": true}`},
+	}
+
+	for _, request := range requests {
+		for name, authorization := range map[string]string{"Missing token": "", "Wrong token": "Bearer wrong-token"} {
+			t.Run(request.method+" "+name, func(t *testing.T) {
+				req, err := http.NewRequest(request.method, request.url, bytes.NewReader([]byte(request.body)))
+				require.NoError(t, err)
+				if authorization != "" {
+					req.Header.Set("Authorization", authorization)
+				}
+
+				resp, err := http.DefaultClient.Do(req)
+				require.NoError(t, err, "request to server failed")
+				resp.Body.Close()
+
+				assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			})
+		}
+	}
+
+	// The users are never touched
+	mockDAO.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything, mock.Anything)
+	mockDAO.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+	mockDAO.AssertNotCalled(t, "GetUserAccount", mock.Anything, mock.Anything)
+}
+
+// TestCreateGameResultsBatchFuncSuccess tests the CreateGameResultsBatchFunc with valid and invalid items.
+func TestCreateGameResultsBatchFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	userId := uuid.New()
+	expectedSubmissions := []entity.GameResultSubmission{
+		{UserID: userId, GameStatus: entity.GameStatusWin, Amount: 10_00, TransactionID: "tx1"},
+		{UserID: userId, GameStatus: entity.GameStatusLost, Amount: 20_00, TransactionID: "tx3"},
+	}
+
+	// Set up mock expectations
+	mockDAO.On("CreateGameResults", mock.Anything, entity.TransactionSourceServer, expectedSubmissions).Return([]entity.GameResultOutcome{
+		{Status: entity.BatchItemStatusCreated, GameResult: &entity.GameResult{ID: 1, UserID: userId, TransactionID: "tx1"}},
+		{Status: entity.BatchItemStatusInsufficientBalance, Err: entity.ErrUserNegativeBalance},
+	})
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceServer)
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Create the request body
+	reqBody := CreateGameResultsBatchRequest{
+		GameResults: []CreateGameResultBatchItemRequest{
+			{UserID: userId.String(), CreateGameResultRequest: CreateGameResultRequest{GameStatus: "win", Amount: "10", TransactionID: "tx1"}},
+			{UserID: userId.String(), CreateGameResultRequest: CreateGameResultRequest{GameStatus: "win", Amount: "ab.x.e", TransactionID: "tx2"}},
+			{UserID: userId.String(), CreateGameResultRequest: CreateGameResultRequest{GameStatus: "lost", Amount: "20", TransactionID: "tx3"}},
+		},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	// Create the request
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/game_results:batch", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, testAPIKey)
+
+	// Execute the request
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "CreateGameResultsBatchFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data CreateGameResultsBatchResponse `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	require.Len(t, respBody.Data.Results, 3)
+	assert.Equal(t, entity.BatchItemStatusCreated, respBody.Data.Results[0].Status)
+	assert.NotNil(t, respBody.Data.Results[0].GameResult)
+	assert.Equal(t, entity.BatchItemStatusInvalid, respBody.Data.Results[1].Status)
+	assert.Equal(t, entity.ErrInvalidAmount.Error(), respBody.Data.Results[1].Error)
+	assert.Equal(t, "invalid_parameters", respBody.Data.Results[1].Code)
+	assert.Equal(t, []InvalidParamResponse{{Name: "amount", Code: "invalid_amount", Reason: entity.ErrInvalidAmount.Error()}}, respBody.Data.Results[1].InvalidParams)
+	assert.Equal(t, entity.BatchItemStatusInsufficientBalance, respBody.Data.Results[2].Status)
+	assert.Equal(t, "negative_balance", respBody.Data.Results[2].Code)
+	assert.Equal(t, 2, respBody.Data.Results[2].Index)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestCreateGameResultsBatchFuncInvalidRequest tests the CreateGameResultsBatchFunc with invalid batches.
+func TestCreateGameResultsBatchFuncInvalidRequest(t *testing.T) {
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"Invalid body", `not a json`},
+		{"Empty batch", `{"gameResults": []}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/game_results:batch", bytes.NewReader([]byte(tt.body)))
+			require.NoError(t, err)
+			req.Header.Set(APIKeyHeader, testAPIKey)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, "request to server failed")
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "CreateGameResultsBatchFunc returned wrong status code")
+		})
+	}
+}
+
+// TestGameResultFuncTransactionIDReplayed tests the CreateGameResultFunc when the same transaction is submitted again.
+func TestGameResultFuncTransactionIDReplayed(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Set up mock expectations
+	recorded := &entity.GameResult{ID: 42, UserID: uuid.New(), GameStatus: entity.GameStatusWin, Amount: 100_00, TransactionID: "123"}
+	mockDAO.On("CreateGameResult",
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(recorded, entity.ErrTransactionIdReplayed)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Create the request
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"})
+	url := fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, recorded.UserID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, testAPIKey)
+
+	// Execute the request
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "CreateGameResultFunc returned wrong status code for replayed transaction")
+
+	// Decode the response
+	var respBody struct {
+		Data GameResultResponse `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, recorded.ID, respBody.Data.ID, "CreateGameResultFunc should return the recorded game result")
+}
+
+// TestGameResultFuncTransactionIDConflict tests the CreateGameResultFunc when the transaction ID is reused with another payload.
+func TestGameResultFuncTransactionIDConflict(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Set up mock expectations
+	recorded := &entity.GameResult{ID: 42, UserID: uuid.New(), GameStatus: entity.GameStatusWin, Amount: 50_00, TransactionID: "123"}
+	mockDAO.On("CreateGameResult",
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+		mock.Anything,
+	).Return(recorded, entity.ErrTransactionIdConflict)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	withTestAPIKey(server, entity.TransactionSourceGame)
+	server.WithGameResultManager(mockDAO)
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Create the request
+	body, _ := json.Marshal(CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"})
+	url := fmt.Sprintf("%s/api/v1/users/%s/game_results", testServer.URL, recorded.UserID)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set(APIKeyHeader, testAPIKey)
+
+	// Execute the request
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "CreateGameResultFunc returned wrong status code for conflicting transaction")
+
+	// Decode the response
+	var respBody struct {
+		ProblemResponse
+		Data GameResultResponse `json:"data"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, "transaction_id_conflict", respBody.Code)
+	assert.Equal(t, entity.ErrTransactionIdConflict.Error(), respBody.Title)
+	assert.Equal(t, recorded.ID, respBody.Data.ID, "CreateGameResultFunc should return the recorded game result")
+	assert.Equal(t, entity.Money(50_00), respBody.Data.Amount)
+}
+
+// TestListValidationRunsFuncSuccess tests the ListValidationRunsFunc for a successful response.
+func TestListValidationRunsFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockValidationRunDAO()
+
+	startedFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	expectedFilter := entity.ValidationRunFilter{
+		StartedFrom: &startedFrom,
+		Cursor:      40,
+		Limit:       2,
+	}
+
+	// Set up mock expectations
+	mockDAO.On("ListValidationRuns", mock.Anything, expectedFilter).Return(&entity.ValidationRunPage{
+		ValidationRuns: []entity.ValidationRun{
+			{ID: 31, UsersProcessed: 3, ResultsApproved: 10, ResultsCanceled: 2, BalanceDelta: -20_50},
+			{ID: 30, Error: sql.NullString{String: entity.ErrValidationFailed.Error(), Valid: true}},
+		},
+		NextCursor: 30,
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithValidationRunManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/validation_runs?started_from=2024-01-01&cursor=40&limit=2", testServer.URL), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "ListValidationRunsFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data ValidationRunListResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	require.Len(t, respBody.Data.ValidationRuns, 2)
+	assert.Equal(t, entity.Money(-20_50), respBody.Data.ValidationRuns[0].BalanceDelta)
+	assert.Nil(t, respBody.Data.ValidationRuns[0].Error)
+	assert.Equal(t, "validation failed", *respBody.Data.ValidationRuns[1].Error)
+	assert.Equal(t, 30, respBody.Data.NextCursor)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestListValidationRunsFuncInvalidFilters tests the ListValidationRunsFunc with invalid query parameters.
+func TestListValidationRunsFuncInvalidFilters(t *testing.T) {
+	server := NewServer()
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"Invalid date", "started_from=yesterday"},
+		{"Inverted date range", "started_from=2024-02-01&started_to=2024-01-01"},
+		{"Invalid cursor", "cursor=0"},
+		{"Limit too big", fmt.Sprintf("limit=%d", MaxValidationRunsLimit+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/validation_runs?%s", testServer.URL, tt.query), "")
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "ListValidationRunsFunc returned wrong status code")
+		})
+	}
+}
+
+// TestGetValidationRunFuncSuccess tests the GetValidationRunFunc for a successful response.
+func TestGetValidationRunFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockValidationRunDAO()
+
+	// Set up mock expectations
+	mockDAO.On("GetValidationRun", mock.Anything, 7).Return(&entity.ValidationRun{ID: 7, UsersProcessed: 4}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithValidationRunManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/validation_runs/7", testServer.URL), "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "GetValidationRunFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data ValidationRunResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, respBody.Data.ID)
+	assert.Equal(t, 4, respBody.Data.UsersProcessed)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestGetValidationRunFuncErrors tests the GetValidationRunFunc for invalid or unknown validation runs.
+func TestGetValidationRunFuncErrors(t *testing.T) {
+	mockDAO := test_helpers.NewMockValidationRunDAO()
+
+	// Set up mock expectations
+	mockDAO.On("GetValidationRun", mock.Anything, 8).Return(nil, entity.ErrValidationRunNotFound)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithValidationRunManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+	}{
+		{"Invalid ID", "abc", http.StatusBadRequest},
+		{"Unknown ID", "8", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := adminRequestWithBody(t, http.MethodGet, fmt.Sprintf("%s/api/v1/validation_runs/%s", testServer.URL, tt.id), "")
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode, "GetValidationRunFunc returned wrong status code")
+		})
+	}
+}
+
+// TestValidationRunsFuncUnauthorized tests the validation runs are only served to the admin token holders.
+func TestValidationRunsFuncUnauthorized(t *testing.T) {
+	server := NewServer()
+	server.WithValidationRunManager(test_helpers.NewMockValidationRunDAO())
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	for _, path := range []string{"/api/v1/validation_runs", "/api/v1/validation_runs/7"} {
+		t.Run(path, func(t *testing.T) {
+			resp, err := http.Get(testServer.URL + path)
+			require.NoError(t, err, "request to server failed")
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		})
+	}
+}
+
+// adminRequest executes an admin request against the test server.
+func adminRequest(t *testing.T, url string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer admin-token")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	return resp
+}
+
+// TestValidateUserFuncSuccess tests the ValidateUserFunc returns the summary of the validation run.
+func TestValidateUserFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	userId := uuid.New()
+
+	// Set up mock expectations
+	mockDAO.On("ValidateUserGameResults", mock.Anything, userId, dao.DefaultTotalGamesToCancel).Return(&entity.ValidationRun{
+		ID:              9,
+		UsersProcessed:  1,
+		ResultsCanceled: 2,
+		BalanceDelta:    -15_00,
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithGameResultManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequest(t, fmt.Sprintf("%s/api/v1/admin/users/%s/validate", testServer.URL, userId))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "ValidateUserFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data ValidationRunResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9, respBody.Data.ID)
+	assert.Equal(t, 2, respBody.Data.ResultsCanceled)
+	assert.Equal(t, entity.Money(-15_00), respBody.Data.BalanceDelta)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestValidateUserFuncErrors tests the ValidateUserFunc for invalid, unknown users and failed validations.
+func TestValidateUserFuncErrors(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	unknownUserId := uuid.New()
+	failingUserId := uuid.New()
+
+	// Set up mock expectations
+	mockDAO.On("ValidateUserGameResults", mock.Anything, unknownUserId, mock.Anything).Return(nil, entity.ErrUserNotFound)
+	mockDAO.On("ValidateUserGameResults", mock.Anything, failingUserId, mock.Anything).Return(&entity.ValidationRun{
+		ID:    10,
+		Error: sql.NullString{String: "select error", Valid: true},
+	}, errors.New("select error"))
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithGameResultManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+	}{
+		{"Invalid ID", "abc", http.StatusBadRequest},
+		{"Unknown user", unknownUserId.String(), http.StatusNotFound},
+		{"Failed validation", failingUserId.String(), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := adminRequest(t, fmt.Sprintf("%s/api/v1/admin/users/%s/validate", testServer.URL, tt.id))
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+}
+
+// TestValidateGameResultsFuncSuccess tests the ValidateGameResultsFunc returns the summary of the validation run.
+func TestValidateGameResultsFuncSuccess(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Set up mock expectations
+	mockDAO.On("ValidateGameResults", mock.Anything, dao.DefaultTotalGamesToCancel).Return(&entity.ValidationRun{
+		ID:             11,
+		UsersProcessed: 5,
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithGameResultManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequest(t, fmt.Sprintf("%s/api/v1/admin/validations", testServer.URL))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "ValidateGameResultsFunc returned wrong status code")
+
+	// Decode the response
+	var respBody struct {
+		Data ValidationRunResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, 11, respBody.Data.ID)
+	assert.Equal(t, 5, respBody.Data.UsersProcessed)
+	mockDAO.AssertExpectations(t)
+}
+
+// TestValidateGameResultsFuncFailure tests the ValidateGameResultsFunc returns the failed validation run along with the error.
+func TestValidateGameResultsFuncFailure(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Set up mock expectations
+	mockDAO.On("ValidateGameResults", mock.Anything, mock.Anything).Return(&entity.ValidationRun{
+		ID:    12,
+		Error: sql.NullString{String: "claim error", Valid: true},
+	}, errors.New("claim error"))
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithGameResultManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp := adminRequest(t, fmt.Sprintf("%s/api/v1/admin/validations", testServer.URL))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	// Decode the response
+	var respBody struct {
+		ProblemResponse
+		Data ValidationRunResponse `json:"data"`
+	}
+	err := json.NewDecoder(resp.Body).Decode(&respBody)
+	require.NoError(t, err)
+
+	assert.Equal(t, "validation_failed", respBody.Code)
+	assert.Equal(t, 12, respBody.Data.ID)
+	require.NotNil(t, respBody.Data.Error)
+	assert.Equal(t, "claim error", *respBody.Data.Error)
+}
+
+// TestAdminRoutesUnauthorized tests the admin routes are rejected without the admin token.
+func TestAdminRoutesUnauthorized(t *testing.T) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithGameResultManager(mockDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	for _, path := range []string{"/api/v1/admin/validations", "/api/v1/admin/api_keys", fmt.Sprintf("/api/v1/admin/users/%s/validate", uuid.New())} {
+		resp, err := http.Post(testServer.URL+path, "application/json", nil)
+		require.NoError(t, err, "request to server failed")
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, path)
+	}
+
+	mockDAO.AssertNotCalled(t, "ValidateGameResults", mock.Anything, mock.Anything)
+	mockDAO.AssertNotCalled(t, "ValidateUserGameResults", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// adminRequestWithBody executes an admin request with a body against the test server.
+func adminRequestWithBody(t *testing.T, method string, url string, body string) *http.Response {
+	req, err := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer admin-token")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	return resp
+}
+
+// TestCreateAPIKeyFuncSuccess tests the CreateAPIKeyFunc hands out the key once, along with its details.
+func TestCreateAPIKeyFuncSuccess(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+
+	// Set up mock expectations
+	mockAPIKeyDAO.On("CreateAPIKey", mock.Anything, entity.TransactionSourcePayment, "checkout").Return(&entity.APIKey{
+		ID:                5,
+		Prefix:            "cceab_abcdefgh",
+		KeyHash:           "hash",
+		TransactionSource: entity.TransactionSourcePayment,
+		SigningKey:        "5369676e696e67",
+		Description:       "checkout",
+		CreatedAt:         time.Now(),
+	}, "cceab_abcdefghsecret", nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAPIKeyManager(mockAPIKeyDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp := adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/admin/api_keys", `{"source": "PAYMENT", "description": "checkout"}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "CreateAPIKeyFunc returned wrong status code")
+
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "hash", "the key hash should never be exposed")
+
+	var respBody struct {
+		Data APIKeyResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &respBody))
+	assert.Equal(t, 5, respBody.Data.ID)
+	assert.Equal(t, "cceab_abcdefghsecret", respBody.Data.Key)
+	assert.Equal(t, "5369676e696e67", respBody.Data.SigningKey)
+	assert.True(t, respBody.Data.Active)
+	mockAPIKeyDAO.AssertExpectations(t)
+}
+
+// TestListAPIKeysFuncSuccess tests the ListAPIKeysFunc lists the keys, without the keys themselves.
+func TestListAPIKeysFuncSuccess(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+
+	revokedAt := time.Now()
+	mockAPIKeyDAO.On("ListAPIKeys", mock.Anything).Return([]entity.APIKey{
+		{ID: 1, TransactionSource: entity.TransactionSourceGame, RevokedAt: sql.NullTime{Time: revokedAt, Valid: true}},
+		{ID: 2, TransactionSource: entity.TransactionSourceGame, SigningKey: "5369676e696e67"},
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAPIKeyManager(mockAPIKeyDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp := adminRequestWithBody(t, http.MethodGet, testServer.URL+"/api/v1/admin/api_keys", "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "ListAPIKeysFunc returned wrong status code")
+
+	var respBody struct {
+		Data APIKeyListResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&respBody))
+	require.Len(t, respBody.Data.APIKeys, 2)
+	assert.False(t, respBody.Data.APIKeys[0].Active)
+	assert.NotNil(t, respBody.Data.APIKeys[0].RevokedAt)
+	assert.True(t, respBody.Data.APIKeys[1].Active)
+	assert.Empty(t, respBody.Data.APIKeys[1].Key)
+	assert.Empty(t, respBody.Data.APIKeys[1].SigningKey)
+}
+
+// TestRotateAPIKeyFuncSuccess tests the RotateAPIKeyFunc hands out the new key, applying the grace period requested.
+func TestRotateAPIKeyFuncSuccess(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+
+	mockAPIKeyDAO.On("RotateAPIKey", mock.Anything, 3, 90*time.Minute).Return(&entity.APIKey{ID: 4, SigningKey: "6e6577"}, "cceab_new", nil)
+	mockAPIKeyDAO.On("RotateAPIKey", mock.Anything, 5, dao.DefaultAPIKeyGracePeriod).Return(&entity.APIKey{ID: 6}, "cceab_other", nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAPIKeyManager(mockAPIKeyDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp := adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/admin/api_keys/3/rotate", `{"gracePeriod": "1h30m"}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "RotateAPIKeyFunc returned wrong status code")
+
+	var respBody struct {
+		Data APIKeyResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&respBody))
+	assert.Equal(t, 4, respBody.Data.ID)
+	assert.Equal(t, "cceab_new", respBody.Data.Key)
+	assert.Equal(t, "6e6577", respBody.Data.SigningKey)
+
+	// Without a body, the default grace period applies
+	resp = adminRequestWithBody(t, http.MethodPost, testServer.URL+"/api/v1/admin/api_keys/5/rotate", "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "RotateAPIKeyFunc returned wrong status code")
+	mockAPIKeyDAO.AssertExpectations(t)
+}
+
+// TestRevokeAPIKeyFuncSuccess tests the RevokeAPIKeyFunc returns the revoked key.
+func TestRevokeAPIKeyFuncSuccess(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+
+	mockAPIKeyDAO.On("RevokeAPIKey", mock.Anything, 3).Return(&entity.APIKey{
+		ID:        3,
+		RevokedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAPIKeyManager(mockAPIKeyDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp := adminRequestWithBody(t, http.MethodDelete, testServer.URL+"/api/v1/admin/api_keys/3", "")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "RevokeAPIKeyFunc returned wrong status code")
+
+	var respBody struct {
+		Data APIKeyResponse `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&respBody))
+	assert.False(t, respBody.Data.Active)
+	mockAPIKeyDAO.AssertExpectations(t)
+}
+
+// TestAPIKeyFuncErrors tests the api key operations map their errors to HTTP responses.
+func TestAPIKeyFuncErrors(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+
+	mockAPIKeyDAO.On("CreateAPIKey", mock.Anything, entity.TransactionSource("casino"), "").Return(nil, "", entity.ErrInvalidTransactionSource)
+	mockAPIKeyDAO.On("RotateAPIKey", mock.Anything, 8, mock.Anything).Return(nil, "", entity.ErrAPIKeyNotFound)
+	mockAPIKeyDAO.On("RotateAPIKey", mock.Anything, 9, mock.Anything).Return(nil, "", entity.ErrAPIKeyRevoked)
+	mockAPIKeyDAO.On("RevokeAPIKey", mock.Anything, 10).Return(nil, errors.New("connection refused"))
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAPIKeyManager(mockAPIKeyDAO)
+	server.WithAdminToken("admin-token")
+
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"Invalid source", http.MethodPost, "/api/v1/admin/api_keys", `{"source": "casino"}`, http.StatusBadRequest},
+		{"Invalid body", http.MethodPost, "/api/v1/admin/api_keys", `not a json`, http.StatusBadRequest},
+		{"Invalid ID", http.MethodPost, "/api/v1/admin/api_keys/abc/rotate", "", http.StatusBadRequest},
+		{"Invalid grace period", http.MethodPost, "/api/v1/admin/api_keys/8/rotate", `{"gracePeriod": "a day"}`, http.StatusBadRequest},
+		{"Unknown key", http.MethodPost, "/api/v1/admin/api_keys/8/rotate", "", http.StatusNotFound},
+		{"Revoked key", http.MethodPost, "/api/v1/admin/api_keys/9/rotate", "", http.StatusConflict},
+		{"Database failure", http.MethodDelete, "/api/v1/admin/api_keys/10", "", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := adminRequestWithBody(t, tt.method, testServer.URL+tt.path, tt.body)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+		})
+	}
+}
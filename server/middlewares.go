@@ -1,13 +1,33 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ildomm/cceab/dao"
 	"github.com/ildomm/cceab/entity"
-	"log"
+	"github.com/ildomm/cceab/logging"
+	"github.com/ildomm/cceab/metrics"
+	"github.com/ildomm/cceab/signing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -61,7 +81,7 @@ func (rm RecoverMiddleware) perform(next http.Handler) http.Handler {
 				}
 
 				// Log the error and stack trace
-				log.Printf("ERROR: recovering from error. Error: %s. StackTrace: %s \n", err, stackTrace)
+				slog.ErrorContext(r.Context(), "recovering from error", "error", err, "stack_trace", string(stackTrace))
 
 				rm.response(r.Context(), w)
 			}
@@ -79,7 +99,7 @@ func (rm RecoverMiddleware) perform(next http.Handler) http.Handler {
 // that implements the same signature as RecoveryResponse
 func defaultRecoveryResponse() RecoveryResponse {
 	return func(ctx context.Context, w http.ResponseWriter) {
-		WriteErrorResponse(w, http.StatusInternalServerError, []string{entity.ErrServerInternal.Error()})
+		WriteProblemResponse(w, entity.ErrServerInternal)
 	}
 }
 
@@ -98,6 +118,51 @@ func (r *StatusRecorder) WriteHeader(status int) {
 	}
 }
 
+// RequestIDHeader is the header carrying the ID of a request, correlating its logs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the length of the request IDs accepted from the callers
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware is a middleware that identifies each request, carrying its ID through the context
+// The ID given by the caller in the X-Request-ID header is kept, otherwise a new one is generated
+// The ID is sent back in the X-Request-ID header of the response
+type RequestIDMiddleware struct{}
+
+// NewRequestIDMiddleware initializes a new RequestIDMiddleware
+func NewRequestIDMiddleware() func(next http.Handler) http.Handler {
+	return RequestIDMiddleware{}.perform
+}
+
+// perform is the middleware handler itself
+func (rm RequestIDMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// validRequestID tells if the request ID given by a caller can be kept
+// It must be short and printable, so it can not forge log entries
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 // LoggingMiddleware is a middleware that logs the request
 type LoggingMiddleware struct{}
 
@@ -113,15 +178,517 @@ func (lm LoggingMiddleware) perform(next http.Handler) http.Handler {
 			ResponseWriter: w,
 		}
 
-		start := time.Now().UnixNano()
+		start := time.Now()
 
 		// Call the next handler as a normal flow execution
 		next.ServeHTTP(recorder, r)
 
-		end := time.Now().UnixNano()
-		duration := (end - start) / int64(time.Millisecond)
+		// Handlers writing the body straight away reply with an implicit 200
+		status := recorder.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
 
 		// Logs execution time of the request and other details
-		log.Printf("INFO: %s \"%s %s\" %d %dms\n", r.RemoteAddr, r.Method, r.URL.Path, recorder.Status, duration)
+		slog.InfoContext(r.Context(), "request served",
+			"remote_addr", r.RemoteAddr,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routeTemplate(r),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// routeTemplate returns the template of the route matching the request, falling back to its path
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// MetricsMiddleware is a middleware that counts the requests and observes their latency
+// The requests are labelled by route template rather than path, keeping the labels bounded
+type MetricsMiddleware struct{}
+
+// NewMetricsMiddleware initializes a new MetricsMiddleware
+func NewMetricsMiddleware() func(next http.Handler) http.Handler {
+	return MetricsMiddleware{}.perform
+}
+
+// perform is the middleware handler itself
+func (mm MetricsMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &StatusRecorder{
+			ResponseWriter: w,
+		}
+
+		start := time.Now()
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(recorder, r)
+
+		route := routeTemplate(r)
+
+		// Handlers writing the body straight away reply with an implicit 200
+		status := recorder.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		labels := []string{route, r.Method, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
+
+// TracingMiddleware is a middleware that records a span for each request
+// The span continues the trace given in the W3C traceparent header, if any
+type TracingMiddleware struct {
+	tracer trace.Tracer
+}
+
+// NewTracingMiddleware initializes a new TracingMiddleware, recording the spans with the global tracer provider
+func NewTracingMiddleware() func(next http.Handler) http.Handler {
+	return TracingMiddleware{
+		tracer: otel.Tracer("github.com/ildomm/cceab/server"),
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (tm TracingMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+
+		ctx, span := tm.tracer.Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		recorder := &StatusRecorder{
+			ResponseWriter: w,
+		}
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	})
 }
+
+// AdminAuthMiddleware is a middleware that restricts the requests to the holders of the admin token
+// The token is expected as a bearer token in the Authorization header
+type AdminAuthMiddleware struct {
+	adminToken string
+}
+
+// NewAdminAuthMiddleware initializes a new AdminAuthMiddleware
+// An empty admin token rejects every request, disabling the routes behind it
+func NewAdminAuthMiddleware(adminToken string) func(next http.Handler) http.Handler {
+	return AdminAuthMiddleware{
+		adminToken: adminToken,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (am AdminAuthMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		// Constant time comparison, so the token can not be guessed from the response times
+		if !found || am.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(am.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			WriteProblemResponse(w, entity.ErrUnauthorized)
+			return
+		}
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIKeyHeader is the header carrying the api key of the transaction sources
+const APIKeyHeader = signing.APIKeyHeader
+
+type apiKeyContextKey struct{}
+
+// APIKeyAuthMiddleware is a middleware that restricts the requests to the holders of an api key
+// The transaction source bound to the key is carried through the context, the Source-Type header is not trusted
+type APIKeyAuthMiddleware struct {
+	apiKeyDAO dao.APIKeyDAO
+}
+
+// NewAPIKeyAuthMiddleware initializes a new APIKeyAuthMiddleware
+// A missing api key manager rejects every request, disabling the routes behind it
+func NewAPIKeyAuthMiddleware(apiKeyDAO dao.APIKeyDAO) func(next http.Handler) http.Handler {
+	return APIKeyAuthMiddleware{
+		apiKeyDAO: apiKeyDAO,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (am APIKeyAuthMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" || am.apiKeyDAO == nil {
+			writeAPIKeyUnauthorized(w)
+			return
+		}
+
+		apiKey, err := am.apiKeyDAO.AuthenticateAPIKey(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, entity.ErrUnauthorized) {
+				writeAPIKeyUnauthorized(w)
+				return
+			}
+			slog.ErrorContext(r.Context(), "error authenticating api key", "error", err)
+			WriteProblemResponse(w, entity.ErrServerInternal)
+			return
+		}
+
+		// Call the next handler as a normal flow execution, on behalf of the transaction source
+		ctx := context.WithValue(r.Context(), apiKeyContextKey{}, apiKey)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// writeAPIKeyUnauthorized rejects a request without a valid api key
+func writeAPIKeyUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "APIKey")
+	WriteProblemResponse(w, entity.ErrUnauthorized)
+}
+
+// authenticatedAPIKey returns the api key authenticated by the APIKeyAuthMiddleware
+func authenticatedAPIKey(ctx context.Context) (*entity.APIKey, bool) {
+	apiKey, found := ctx.Value(apiKeyContextKey{}).(*entity.APIKey)
+	return apiKey, found
+}
+
+// TransactionSource returns the transaction source authenticated by the APIKeyAuthMiddleware
+func TransactionSource(ctx context.Context) (entity.TransactionSource, bool) {
+	apiKey, found := authenticatedAPIKey(ctx)
+	if !found {
+		return "", false
+	}
+	return apiKey.TransactionSource, true
+}
+
+const (
+	// DefaultSignatureMaxAge is how far the timestamp of a signed request can be from the server time, by default
+	DefaultSignatureMaxAge = 5 * time.Minute
+
+	// maxSignedBodySize bounds the body read to verify a signature
+	maxSignedBodySize = 5 << 20
+)
+
+// SignatureMiddleware is a middleware that verifies the HMAC signature of the requests, see the signing package
+// It must run after the APIKeyAuthMiddleware, the signature being keyed by the signing key of the api key
+//
+// A request is rejected when its timestamp is off by more than the max age, or when its nonce was already used.
+// The nonces are kept for twice the max age, covering the requests signed ahead as well as behind the server time.
+type SignatureMiddleware struct {
+	apiKeyDAO dao.APIKeyDAO
+	required  bool
+	maxAge    time.Duration
+}
+
+// NewSignatureMiddleware initializes a new SignatureMiddleware
+// The api keys holding a signing key always have to sign. Unless the signatures are required,
+// the unsigned requests of the keys created before the signing are let through.
+func NewSignatureMiddleware(apiKeyDAO dao.APIKeyDAO, required bool, maxAge time.Duration) func(next http.Handler) http.Handler {
+	return SignatureMiddleware{
+		apiKeyDAO: apiKeyDAO,
+		required:  required,
+		maxAge:    maxAge,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (sm SignatureMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, found := authenticatedAPIKey(r.Context())
+		if !found {
+			writeAPIKeyUnauthorized(w)
+			return
+		}
+
+		signature := r.Header.Get(signing.SignatureHeader)
+		if signature == "" {
+			if sm.required || apiKey.SigningKey != "" {
+				WriteProblemResponse(w, entity.ErrSignatureRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The cheap checks come first, the nonce is only recorded for a valid signature
+		timestamp := r.Header.Get(signing.TimestampHeader)
+		nonce := r.Header.Get(signing.NonceHeader)
+		signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || len(nonce) < signing.MinNonceLength || len(nonce) > signing.MaxNonceLength {
+			WriteProblemResponse(w, entity.ErrInvalidSignature)
+			return
+		}
+
+		skew := time.Since(time.Unix(signedAt, 0))
+		if skew > sm.maxAge || skew < -sm.maxAge {
+			WriteProblemResponse(w, entity.ErrStaleSignature)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		if err != nil {
+			WriteProblemResponse(w, entity.ErrRequestPayload)
+			return
+		}
+		if len(body) > maxSignedBodySize {
+			WriteProblemResponse(w, entity.ErrRequestTooLarge)
+			return
+		}
+
+		// The keys created before the signing have no signing key, they have to be rotated to sign
+		signingKey, err := hex.DecodeString(apiKey.SigningKey)
+		if err != nil || len(signingKey) == 0 || !signing.Verify(signingKey, r.Method, signing.Target(r.URL), timestamp, nonce, body, signature) {
+			WriteProblemResponse(w, entity.ErrInvalidSignature)
+			return
+		}
+
+		err = sm.apiKeyDAO.RecordNonce(r.Context(), apiKey.ID, nonce, 2*sm.maxAge)
+		if err != nil {
+			if errors.Is(err, entity.ErrNonceReused) {
+				WriteProblemResponse(w, err)
+				return
+			}
+			slog.ErrorContext(r.Context(), "error recording request nonce", "error", err)
+			WriteProblemResponse(w, entity.ErrServerInternal)
+			return
+		}
+
+		// Call the next handler as a normal flow execution, with the body read again
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimit is a token bucket limit: Rate requests per second, with bursts of up to Burst requests
+// A zero Rate disables the limit, a zero Burst allows bursts of one second worth of requests
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled tells if the limit applies
+func (rl RateLimit) Enabled() bool {
+	return rl.Rate > 0
+}
+
+// rateLimiterSweepInterval is how often the idle buckets are dropped, keeping the memory bounded
+const rateLimiterSweepInterval = time.Minute
+
+// tokenBucket holds the tokens left to a key, as of the last time it was refilled
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per key
+type rateLimiter struct {
+	limit RateLimit
+	now   func() time.Time
+
+	lock      sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	// A burst below one request would reject every request
+	if limit.Burst < 1 {
+		limit.Burst = max(1, int(math.Ceil(limit.Rate)))
+	}
+
+	return &rateLimiter{
+		limit:   limit,
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the bucket of the key
+// It returns false along with the time until the next token when the bucket is empty
+func (rl *rateLimiter) allow(key string) (bool, time.Duration) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	now := rl.now()
+	rl.sweep(now)
+
+	bucket, found := rl.buckets[key]
+	if !found {
+		bucket = &tokenBucket{tokens: float64(rl.limit.Burst), last: now}
+		rl.buckets[key] = bucket
+	}
+
+	bucket.tokens = rl.refill(bucket, now)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rl.limit.Rate * float64(time.Second))
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// refill returns the tokens of the bucket at the given time, up to the burst
+func (rl *rateLimiter) refill(bucket *tokenBucket, now time.Time) float64 {
+	tokens := bucket.tokens + now.Sub(bucket.last).Seconds()*rl.limit.Rate
+	return min(tokens, float64(rl.limit.Burst))
+}
+
+// sweep drops the buckets refilled up to the burst, which are the same as new ones
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateLimiterSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+
+	for key, bucket := range rl.buckets {
+		if rl.refill(bucket, now) >= float64(rl.limit.Burst) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// RateLimitMiddleware is a middleware that limits the rate of the requests sharing a key, with a token bucket per key
+// The requests over the limit are rejected with a 429, along with a Retry-After header
+type RateLimitMiddleware struct {
+	limiter *rateLimiter
+	key     func(r *http.Request) (string, bool)
+	reason  string
+}
+
+// NewUserRateLimitMiddleware initializes a new RateLimitMiddleware keyed by the user ID of the route
+// The user ID is normalized, so all its spellings share the same limit
+// The requests without a valid user ID are not limited, the handler rejects them
+func NewUserRateLimitMiddleware(limit RateLimit) func(next http.Handler) http.Handler {
+	return RateLimitMiddleware{
+		limiter: newRateLimiter(limit),
+		key: func(r *http.Request) (string, bool) {
+			userId, err := uuid.Parse(mux.Vars(r)["id"])
+			if err != nil {
+				return "", false
+			}
+			return userId.String(), true
+		},
+		reason: metrics.ThrottleUserRateLimit,
+	}.perform
+}
+
+// NewSourceRateLimitMiddleware initializes a new RateLimitMiddleware keyed by the transaction source
+// It must run after the APIKeyAuthMiddleware, the requests without a transaction source are not limited
+func NewSourceRateLimitMiddleware(limit RateLimit) func(next http.Handler) http.Handler {
+	return RateLimitMiddleware{
+		limiter: newRateLimiter(limit),
+		key: func(r *http.Request) (string, bool) {
+			source, found := TransactionSource(r.Context())
+			return string(source), found
+		},
+		reason: metrics.ThrottleSourceRateLimit,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (rm RateLimitMiddleware) perform(next http.Handler) http.Handler {
+	if !rm.limiter.limit.Enabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, found := rm.key(r)
+		if found {
+			allowed, retryAfter := rm.limiter.allow(key)
+			if !allowed {
+				metrics.HTTPThrottledRequests.WithLabelValues(rm.reason).Inc()
+				slog.WarnContext(r.Context(), "request rate limited", "reason", rm.reason, "key", key)
+
+				setRetryAfter(w, retryAfter)
+				WriteProblemResponse(w, entity.ErrRateLimited)
+				return
+			}
+		}
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ShedRetryAfter is how long the clients are asked to wait when their request is shed
+const ShedRetryAfter = time.Second
+
+// LoadSheddingMiddleware is a middleware that rejects the requests with a 503 while too many are in flight,
+// so the server keeps serving the requests it already took rather than slowing down for all of them
+type LoadSheddingMiddleware struct {
+	maxInFlight int64
+	inFlight    *atomic.Int64
+}
+
+// NewLoadSheddingMiddleware initializes a new LoadSheddingMiddleware
+// A zero maximum of requests in flight disables the load shedding
+func NewLoadSheddingMiddleware(maxInFlight int) func(next http.Handler) http.Handler {
+	return LoadSheddingMiddleware{
+		maxInFlight: int64(maxInFlight),
+		inFlight:    &atomic.Int64{},
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (lm LoadSheddingMiddleware) perform(next http.Handler) http.Handler {
+	if lm.maxInFlight <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer lm.inFlight.Add(-1)
+
+		if lm.inFlight.Add(1) > lm.maxInFlight {
+			metrics.HTTPThrottledRequests.WithLabelValues(metrics.ThrottleLoadShedding).Inc()
+			slog.WarnContext(r.Context(), "request shed", "max_in_flight", lm.maxInFlight)
+
+			setRetryAfter(w, ShedRetryAfter)
+			WriteProblemResponse(w, entity.ErrServerOverloaded)
+			return
+		}
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRetryAfter tells the client how long to wait before retrying, in whole seconds
+func setRetryAfter(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
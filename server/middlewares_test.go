@@ -1,10 +1,29 @@
 package server
 
 import (
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/ildomm/cceab/dao"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/logging"
+	"github.com/ildomm/cceab/metrics"
+	"github.com/ildomm/cceab/signing"
 	"github.com/ildomm/cceab/test_helpers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -55,14 +74,438 @@ func TestLoggingMiddleware(t *testing.T) {
 	})
 	loggingMiddleware := NewLoggingMiddleware()
 
-	testServer := httptest.NewServer(loggingMiddleware(testHandler))
+	testServer := httptest.NewServer(NewRequestIDMiddleware()(loggingMiddleware(testHandler)))
 	defer testServer.Close()
 
-	_, err := http.Get(testServer.URL)
+	req, err := http.NewRequest(http.MethodGet, testServer.URL, nil)
+	assert.NoError(t, err)
+	req.Header.Set(RequestIDHeader, "request-1")
+
+	_, err = http.DefaultClient.Do(req)
 	assert.NoError(t, err)
 
 	logOutput := logBuf.String()
 	assert.Contains(t, logOutput, "INFO", "log does not contain info level")
 	assert.Contains(t, logOutput, "202", "log does not contain correct status code")
 	assert.Contains(t, logOutput, "ms", "log does not contain execution time")
+	assert.Contains(t, logOutput, `"request_id":"request-1"`, "log does not contain the request ID")
+}
+
+// TestRequestIDMiddleware tests the RequestIDMiddleware keeps the request IDs given, or generates them.
+func TestRequestIDMiddleware(t *testing.T) {
+	var requestID string
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID = logging.RequestID(r.Context())
+	})
+
+	tests := []struct {
+		name      string
+		given     string
+		generated bool
+	}{
+		{"Given", "4f5a2b1e-request", false},
+		{"Missing", "", true},
+		{"Too long", strings.Repeat("a", 129), true},
+		{"Not printable", "line\nbreak", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.given != "" {
+				req.Header.Set(RequestIDHeader, tt.given)
+			}
+			rr := httptest.NewRecorder()
+
+			NewRequestIDMiddleware()(testHandler).ServeHTTP(rr, req)
+
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, rr.Header().Get(RequestIDHeader), "the request ID should be sent back")
+			if tt.generated {
+				assert.NotEqual(t, tt.given, requestID)
+			} else {
+				assert.Equal(t, tt.given, requestID)
+			}
+		})
+	}
+}
+
+// TestMetricsMiddleware tests the MetricsMiddleware counts the requests by route template and status.
+func TestMetricsMiddleware(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(NewMetricsMiddleware())
+	r.HandleFunc("/api/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}")) //nolint:all
+	}).Methods(http.MethodGet)
+
+	testServer := httptest.NewServer(r)
+	defer testServer.Close()
+
+	notFound := metrics.HTTPRequests.WithLabelValues("/api/v1/users/{id}", http.MethodGet, "404")
+	ok := metrics.HTTPRequests.WithLabelValues("/api/v1/users", http.MethodGet, "200")
+	notFoundBefore, okBefore := testutil.ToFloat64(notFound), testutil.ToFloat64(ok)
+
+	for _, path := range []string{"/api/v1/users/1", "/api/v1/users/2", "/api/v1/users"} {
+		resp, err := http.Get(testServer.URL + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, notFoundBefore+2, testutil.ToFloat64(notFound), "requests should be counted by route template")
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(ok), "implicit statuses should be counted as 200")
+}
+
+// TestTracingMiddleware tests the TracingMiddleware records a span per request, continuing the trace of the caller.
+func TestTracingMiddleware(t *testing.T) {
+	recorder := test_helpers.RecordSpans(t)
+
+	var handlerSpan trace.SpanContext
+	r := mux.NewRouter()
+	r.Use(NewTracingMiddleware())
+	r.HandleFunc("/api/v1/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods(http.MethodGet)
+
+	testServer := httptest.NewServer(r)
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/v1/users/1", nil)
+	assert.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		span := spans[0]
+		assert.Equal(t, "GET /api/v1/users/{id}", span.Name())
+		assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), "the trace of the caller should be continued")
+		assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+		assert.Equal(t, span.SpanContext(), handlerSpan, "the handler should run within the span")
+		assert.Contains(t, span.Attributes(), semconv.HTTPRoute("/api/v1/users/{id}"))
+		assert.Contains(t, span.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+		assert.Equal(t, codes.Error, span.Status().Code)
+	}
+}
+
+// TestAdminAuthMiddleware tests the AdminAuthMiddleware only lets the admin token holders through.
+func TestAdminAuthMiddleware(t *testing.T) {
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		adminToken    string
+		authorization string
+		expected      int
+	}{
+		{"Valid token", "secret", "Bearer secret", http.StatusOK},
+		{"Wrong token", "secret", "Bearer guess", http.StatusUnauthorized},
+		{"Missing token", "secret", "", http.StatusUnauthorized},
+		{"Not a bearer token", "secret", "Basic secret", http.StatusUnauthorized},
+		{"Admin API disabled", "", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testServer := httptest.NewServer(NewAdminAuthMiddleware(tt.adminToken)(testHandler))
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL, nil)
+			assert.NoError(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expected, resp.StatusCode)
+			if tt.expected == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", resp.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// TestAPIKeyAuthMiddleware tests the APIKeyAuthMiddleware only lets the api key holders through, on behalf of their transaction source.
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, "cceab_valid").Return(&entity.APIKey{ID: 1, TransactionSource: entity.TransactionSourceServer}, nil)
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, "cceab_failing").Return(nil, errors.New("connection refused"))
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(nil, entity.ErrUnauthorized)
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transactionSource, found := TransactionSource(r.Context())
+		assert.True(t, found)
+		w.Header().Set("Source-Type", string(transactionSource))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name      string
+		apiKeyDAO dao.APIKeyDAO
+		apiKey    string
+		expected  int
+	}{
+		{"Valid key", mockAPIKeyDAO, "cceab_valid", http.StatusOK},
+		{"Unknown key", mockAPIKeyDAO, "cceab_unknown", http.StatusUnauthorized},
+		{"Missing key", mockAPIKeyDAO, "", http.StatusUnauthorized},
+		{"Database failure", mockAPIKeyDAO, "cceab_failing", http.StatusInternalServerError},
+		{"Api keys disabled", nil, "cceab_valid", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := NewAPIKeyAuthMiddleware(tt.apiKeyDAO)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			recorder := httptest.NewRecorder()
+
+			middleware(testHandler).ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expected, recorder.Code)
+			switch tt.expected {
+			case http.StatusOK:
+				assert.Equal(t, string(entity.TransactionSourceServer), recorder.Header().Get("Source-Type"))
+			case http.StatusUnauthorized:
+				assert.Equal(t, "APIKey", recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// TestSignatureMiddleware tests the SignatureMiddleware only lets the requests signed with the signing key of their api key through, once.
+// The unsigned requests only go through for the api keys without a signing key, when the signatures are not required.
+func TestSignatureMiddleware(t *testing.T) {
+	signingKey, err := signing.NewKey()
+	require.NoError(t, err)
+
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, "cceab_valid").Return(&entity.APIKey{ID: 1, SigningKey: hex.EncodeToString(signingKey)}, nil)
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, "cceab_unsigned").Return(&entity.APIKey{ID: 2}, nil)
+	mockAPIKeyDAO.On("RecordNonce", mock.Anything, 1, "used-nonce-0123456789", 2*time.Minute).Return(entity.ErrNonceReused)
+	mockAPIKeyDAO.On("RecordNonce", mock.Anything, 1, "failing-nonce-0123456789", 2*time.Minute).Return(errors.New("connection refused"))
+	mockAPIKeyDAO.On("RecordNonce", mock.Anything, 1, mock.Anything, 2*time.Minute).Return(nil)
+
+	// The handler echoes the body, which should be read again
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body) //nolint:errcheck
+	})
+
+	body := `{"state": "win"}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10)
+	sign := func(timestamp string, nonce string) string {
+		return signing.Sign(signingKey, http.MethodPost, "/api/v1/game_results:batch", timestamp, nonce, []byte(body))
+	}
+
+	tests := []struct {
+		name      string
+		required  bool
+		apiKey    string
+		timestamp string
+		nonce     string
+		signature string
+		expected  int
+	}{
+		{"Valid signature", true, "cceab_valid", now, "fresh-nonce-0123456789", sign(now, "fresh-nonce-0123456789"), http.StatusOK},
+		{"Unsigned, with a signing key", false, "cceab_valid", "", "", "", http.StatusUnauthorized},
+		{"Unsigned, without a signing key", false, "cceab_unsigned", "", "", "", http.StatusOK},
+		{"Unsigned, without a signing key, required", true, "cceab_unsigned", "", "", "", http.StatusUnauthorized},
+		{"Wrong signature", false, "cceab_valid", now, "fresh-nonce-0123456789", sign(now, "other-nonce-0123456789"), http.StatusUnauthorized},
+		{"Stale timestamp", false, "cceab_valid", stale, "fresh-nonce-0123456789", sign(stale, "fresh-nonce-0123456789"), http.StatusUnauthorized},
+		{"Invalid timestamp", false, "cceab_valid", "yesterday", "fresh-nonce-0123456789", sign("yesterday", "fresh-nonce-0123456789"), http.StatusUnauthorized},
+		{"Short nonce", false, "cceab_valid", now, "nonce", sign(now, "nonce"), http.StatusUnauthorized},
+		{"Reused nonce", false, "cceab_valid", now, "used-nonce-0123456789", sign(now, "used-nonce-0123456789"), http.StatusUnauthorized},
+		{"Key without signing key", false, "cceab_unsigned", now, "fresh-nonce-0123456789", sign(now, "fresh-nonce-0123456789"), http.StatusUnauthorized},
+		{"Database failure", false, "cceab_valid", now, "failing-nonce-0123456789", sign(now, "failing-nonce-0123456789"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			middleware := NewSignatureMiddleware(mockAPIKeyDAO, tt.required, time.Minute)
+			handler := NewAPIKeyAuthMiddleware(mockAPIKeyDAO)(middleware(testHandler))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/game_results:batch", strings.NewReader(body))
+			req.Header.Set(APIKeyHeader, tt.apiKey)
+			if tt.signature != "" {
+				req.Header.Set(signing.TimestampHeader, tt.timestamp)
+				req.Header.Set(signing.NonceHeader, tt.nonce)
+				req.Header.Set(signing.SignatureHeader, tt.signature)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, tt.expected, recorder.Code)
+			if tt.expected == http.StatusOK {
+				assert.Equal(t, body, recorder.Body.String())
+			}
+		})
+	}
+}
+
+// TestRateLimiter tests the token buckets of the rateLimiter, refilled over time and kept apart by key.
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(RateLimit{Rate: 2, Burst: 3})
+	limiter.now = func() time.Time { return now }
+
+	// The burst goes through, then the bucket is empty
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.allow("user-1")
+		assert.True(t, allowed, "request %d should be allowed", i)
+	}
+	allowed, retryAfter := limiter.allow("user-1")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// The other keys have their own bucket
+	allowed, _ = limiter.allow("user-2")
+	assert.True(t, allowed)
+
+	// A token is back after half a second, at 2 requests per second
+	now = now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.allow("user-1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.allow("user-1")
+	assert.False(t, allowed)
+
+	// The idle buckets are dropped once refilled
+	now = now.Add(rateLimiterSweepInterval)
+	allowed, _ = limiter.allow("user-3")
+	assert.True(t, allowed)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+// TestUserRateLimitMiddleware tests the RateLimitMiddleware keyed by the user ID of the route.
+func TestUserRateLimitMiddleware(t *testing.T) {
+	throttled := testutil.ToFloat64(metrics.HTTPThrottledRequests.WithLabelValues(metrics.ThrottleUserRateLimit))
+
+	r := mux.NewRouter()
+	r.Handle("/api/v1/users/{id}/game_results", NewUserRateLimitMiddleware(RateLimit{Rate: 0.001, Burst: 1})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	send := func(userId string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/users/"+userId+"/game_results", nil))
+		return recorder
+	}
+
+	userId := uuid.New()
+	assert.Equal(t, http.StatusCreated, send(userId.String()).Code)
+
+	recorder := send(userId.String())
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "1000", recorder.Header().Get("Retry-After"))
+	assert.Contains(t, recorder.Body.String(), entity.ErrRateLimited.Error())
+
+	// The other spellings of the same user ID share its limit
+	assert.Equal(t, http.StatusTooManyRequests, send(strings.ToUpper(userId.String())).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send(strings.ReplaceAll(userId.String(), "-", "")).Code)
+
+	assert.Equal(t, http.StatusCreated, send(uuid.NewString()).Code, "the other users should not be limited")
+	assert.Equal(t, throttled+3, testutil.ToFloat64(metrics.HTTPThrottledRequests.WithLabelValues(metrics.ThrottleUserRateLimit)))
+
+	// The invalid user IDs are left to the handler to reject
+	assert.Equal(t, http.StatusCreated, send("user-1").Code)
+	assert.Equal(t, http.StatusCreated, send("user-1").Code)
+}
+
+// TestSourceRateLimitMiddleware tests the RateLimitMiddleware keyed by the transaction source of the api key.
+func TestSourceRateLimitMiddleware(t *testing.T) {
+	mockAPIKeyDAO := test_helpers.NewMockAPIKeyDAO()
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, "cceab_game").Return(&entity.APIKey{ID: 1, TransactionSource: entity.TransactionSourceGame}, nil)
+	mockAPIKeyDAO.On("AuthenticateAPIKey", mock.Anything, "cceab_server").Return(&entity.APIKey{ID: 2, TransactionSource: entity.TransactionSourceServer}, nil)
+
+	limited := NewAPIKeyAuthMiddleware(mockAPIKeyDAO)(NewSourceRateLimitMiddleware(RateLimit{Rate: 1, Burst: 2})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	send := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(APIKeyHeader, apiKey)
+		recorder := httptest.NewRecorder()
+		limited.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, send("cceab_game"))
+	assert.Equal(t, http.StatusCreated, send("cceab_game"))
+	assert.Equal(t, http.StatusTooManyRequests, send("cceab_game"))
+	assert.Equal(t, http.StatusCreated, send("cceab_server"), "the other sources should not be limited")
+}
+
+// TestRateLimitMiddlewareDisabled tests the RateLimitMiddleware lets every request through without a rate.
+func TestRateLimitMiddlewareDisabled(t *testing.T) {
+	r := mux.NewRouter()
+	r.Handle("/api/v1/users/{id}/game_results", NewUserRateLimitMiddleware(RateLimit{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	userId := uuid.NewString()
+	for i := 0; i < 10; i++ {
+		recorder := httptest.NewRecorder()
+		r.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/users/"+userId+"/game_results", nil))
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+	}
+}
+
+// TestLoadSheddingMiddleware tests the LoadSheddingMiddleware sheds the requests over the maximum in flight, and only those.
+func TestLoadSheddingMiddleware(t *testing.T) {
+	throttled := testutil.ToFloat64(metrics.HTTPThrottledRequests.WithLabelValues(metrics.ThrottleLoadShedding))
+
+	// The handler holds the requests until released
+	started := make(chan struct{})
+	release := make(chan struct{})
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	shedding := NewLoadSheddingMiddleware(2)(testHandler)
+
+	done := make(chan int)
+	for i := 0; i < 2; i++ {
+		go func() {
+			recorder := httptest.NewRecorder()
+			shedding.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+			done <- recorder.Code
+		}()
+		<-started
+	}
+
+	// A third request in flight is shed
+	recorder := httptest.NewRecorder()
+	shedding.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+	assert.Contains(t, recorder.Body.String(), entity.ErrServerOverloaded.Error())
+	assert.Equal(t, throttled+1, testutil.ToFloat64(metrics.HTTPThrottledRequests.WithLabelValues(metrics.ThrottleLoadShedding)))
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-done)
+	assert.Equal(t, http.StatusOK, <-done)
+
+	// Once the requests finished, the new ones go through again
+	go func() { <-started }()
+	recorder = httptest.NewRecorder()
+	shedding.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
 }
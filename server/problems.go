@@ -0,0 +1,143 @@
+package server
+
+import (
+	"errors"
+	"github.com/ildomm/cceab/entity"
+	"net/http"
+	"strings"
+)
+
+// ProblemTypePrefix prefixes the code of a problem into its type URI
+const ProblemTypePrefix = "urn:cceab:problem:"
+
+// problem is the HTTP rendering of an entity error: its status, and the code the clients can rely on
+type problem struct {
+	err    error
+	status int
+	code   string
+}
+
+// internalProblem renders the errors missing from the problems, their message not being disclosed
+var internalProblem = problem{err: entity.ErrServerInternal, status: http.StatusInternalServerError, code: "internal_error"}
+
+// problems maps the entity errors to their HTTP status and code, the first match winning.
+// The codes are part of the API: they can be added, never changed.
+// The errors wrapping others come first, such as ErrTransactionIdConflict wrapping ErrTransactionIdExists,
+// and the field errors before the money ones they wrap, such as ErrInvalidAmount wrapping ErrMoneyPrecision.
+var problems = []problem{
+	// Requests
+	{entity.ErrInvalidParameters, http.StatusBadRequest, "invalid_parameters"},
+	{entity.ErrRequestPayload, http.StatusBadRequest, "invalid_request_body"},
+	{entity.ErrRequestTooLarge, http.StatusRequestEntityTooLarge, "request_too_large"},
+	{entity.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+	{entity.ErrServerOverloaded, http.StatusServiceUnavailable, "server_overloaded"},
+
+	// Authentication
+	{entity.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{entity.ErrSignatureRequired, http.StatusUnauthorized, "signature_required"},
+	{entity.ErrInvalidSignature, http.StatusUnauthorized, "invalid_signature"},
+	{entity.ErrStaleSignature, http.StatusUnauthorized, "stale_signature"},
+	{entity.ErrNonceReused, http.StatusUnauthorized, "nonce_reused"},
+
+	// Users
+	{entity.ErrInvalidUser, http.StatusBadRequest, "invalid_user_id"},
+	{entity.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+	{entity.ErrUserDisabled, http.StatusForbidden, "user_disabled"},
+	{entity.ErrUserEmailExists, http.StatusConflict, "user_email_exists"},
+	{entity.ErrInvalidEmail, http.StatusBadRequest, "invalid_email"},
+	{entity.ErrInvalidBalance, http.StatusBadRequest, "invalid_balance"},
+	{entity.ErrUserNegativeBalance, http.StatusNotAcceptable, "negative_balance"},
+	{entity.ErrCreatingUser, http.StatusInternalServerError, "user_not_recorded"},
+
+	// Game results
+	{entity.ErrTransactionIdConflict, http.StatusConflict, "transaction_id_conflict"},
+	{entity.ErrTransactionIdExists, http.StatusNotAcceptable, "transaction_id_exists"},
+	{entity.ErrInvalidGameStatus, http.StatusBadRequest, "invalid_game_status"},
+	{entity.ErrInvalidAmount, http.StatusBadRequest, "invalid_amount"},
+	{entity.ErrInvalidTransactionSource, http.StatusBadRequest, "invalid_transaction_source"},
+	{entity.ErrInvalidValidationStatus, http.StatusBadRequest, "invalid_validation_status"},
+	{entity.ErrEmptyBatch, http.StatusBadRequest, "empty_batch"},
+	{entity.ErrBatchTooLarge, http.StatusBadRequest, "batch_too_large"},
+	{entity.ErrCreatingGameResult, http.StatusInternalServerError, "game_result_not_recorded"},
+
+	// Money
+	{entity.ErrInvalidMoney, http.StatusBadRequest, "invalid_money"},
+	{entity.ErrMoneyPrecision, http.StatusBadRequest, "money_precision"},
+	{entity.ErrMoneyOutOfRange, http.StatusBadRequest, "money_out_of_range"},
+
+	// Listings
+	{entity.ErrInvalidDateRange, http.StatusBadRequest, "invalid_date_range"},
+	{entity.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+	{entity.ErrInvalidLimit, http.StatusBadRequest, "invalid_limit"},
+
+	// Validation runs
+	{entity.ErrInvalidValidationRun, http.StatusBadRequest, "invalid_validation_run_id"},
+	{entity.ErrValidationRunNotFound, http.StatusNotFound, "validation_run_not_found"},
+	{entity.ErrValidationFailed, http.StatusInternalServerError, "validation_failed"},
+
+	// Api keys
+	{entity.ErrInvalidAPIKey, http.StatusBadRequest, "invalid_api_key_id"},
+	{entity.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found"},
+	{entity.ErrAPIKeyRevoked, http.StatusConflict, "api_key_revoked"},
+	{entity.ErrInvalidGracePeriod, http.StatusBadRequest, "invalid_grace_period"},
+
+	{entity.ErrServerInternal, http.StatusInternalServerError, "internal_error"},
+}
+
+// problemDetails explains some problems further, the same way on every occurrence.
+// The messages of the errors are never disclosed, as they may wrap internal ones.
+var problemDetails = map[string]string{
+	"rate_limited":       "too many game results, retry once the Retry-After delay has passed",
+	"server_overloaded":  "too many requests in flight, retry once the Retry-After delay has passed",
+	"signature_required": "the game results must be signed with the signing key handed out along with the api key",
+	"stale_signature":    "the clock of the client is too far from the server one, or the request was delayed",
+}
+
+// problemFor returns the problem of an error, the internal one when it is not mapped
+func problemFor(err error) problem {
+	for _, p := range problems {
+		if errors.Is(err, p.err) {
+			return p
+		}
+	}
+	return internalProblem
+}
+
+// invalidParam is a request parameter failing the validation, along with the entity error it fails with
+type invalidParam struct {
+	name string
+	err  error
+}
+
+// invalidParams gathers all the parameters of a request failing the validation, so they are reported at once
+type invalidParams []invalidParam
+
+// add records a parameter failing the validation
+func (ip *invalidParams) add(name string, err error) {
+	*ip = append(*ip, invalidParam{name: name, err: err})
+}
+
+// errOrNil returns the invalid parameters as an error, nil when all of them are valid
+func (ip invalidParams) errOrNil() error {
+	if len(ip) == 0 {
+		return nil
+	}
+	return ip
+}
+
+func (ip invalidParams) Error() string {
+	messages := make([]string, 0, len(ip))
+	for _, param := range ip {
+		messages = append(messages, param.name+": "+param.err.Error())
+	}
+	return entity.ErrInvalidParameters.Error() + ": " + strings.Join(messages, ", ")
+}
+
+func (ip invalidParams) Unwrap() error {
+	return entity.ErrInvalidParameters
+}
+
+// asInvalidParam reports an error as a parameter failing the validation, for the errors returned by the DAOs
+func asInvalidParam(name string, err error) error {
+	return invalidParams{{name: name, err: err}}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"fmt"
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+// TestProblemsCodes tests the codes of the problems are unique, so the clients can tell the errors apart.
+func TestProblemsCodes(t *testing.T) {
+	codes := make(map[string]error)
+	for _, p := range problems {
+		assert.NotEmpty(t, p.code)
+		assert.NotZero(t, p.status)
+
+		existing, found := codes[p.code]
+		assert.False(t, found, "code %q of %q already used by %q", p.code, p.err, existing)
+		codes[p.code] = p.err
+	}
+}
+
+// TestProblemFor tests the entity errors are mapped to their problem, the wrapping ones first.
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{entity.ErrUserNotFound, http.StatusNotFound, "user_not_found"},
+		{entity.ErrUserNegativeBalance, http.StatusNotAcceptable, "negative_balance"},
+		{entity.ErrTransactionIdExists, http.StatusNotAcceptable, "transaction_id_exists"},
+		{entity.ErrTransactionIdConflict, http.StatusConflict, "transaction_id_conflict"},
+		{entity.ErrUserDisabled, http.StatusForbidden, "user_disabled"},
+		{fmt.Errorf("%w: %w", entity.ErrInvalidAmount, entity.ErrMoneyPrecision), http.StatusBadRequest, "invalid_amount"},
+		{entity.ErrMoneyPrecision, http.StatusBadRequest, "money_precision"},
+		{invalidParams{{name: "id", err: entity.ErrInvalidUser}}, http.StatusBadRequest, "invalid_parameters"},
+		{fmt.Errorf("%w: %q", entity.ErrInvalidTransactionSource, "arcade"), http.StatusBadRequest, "invalid_transaction_source"},
+		{fmt.Errorf("connection refused"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			p := problemFor(tt.err)
+			assert.Equal(t, tt.status, p.status)
+			assert.Equal(t, tt.code, p.code)
+		})
+	}
+}
@@ -4,6 +4,34 @@ import "github.com/ildomm/cceab/entity"
 
 type CreateGameResultRequest struct {
 	GameStatus    entity.GameStatus `json:"state"`
-	Amount        string            `json:"amount"` // TODO: This could be float64
+	Amount        string            `json:"amount"` // A decimal string, parsed as entity.Money
 	TransactionID string            `json:"transactionId"`
 }
+
+type CreateGameResultsBatchRequest struct {
+	GameResults []CreateGameResultBatchItemRequest `json:"gameResults"`
+}
+
+type CreateGameResultBatchItemRequest struct {
+	UserID string `json:"userId"`
+	CreateGameResultRequest
+}
+
+type CreateUserRequest struct {
+	Email   string `json:"email"`
+	Balance string `json:"balance"` // Optional, defaults to zero
+}
+
+type UpdateUserRequest struct {
+	Email    *string `json:"email"`
+	Disabled *bool   `json:"disabled"`
+}
+
+type CreateAPIKeyRequest struct {
+	TransactionSource entity.TransactionSource `json:"source"`
+	Description       string                   `json:"description"`
+}
+
+type RotateAPIKeyRequest struct {
+	GracePeriod string `json:"gracePeriod"` // A duration such as "1h30m", optional
+}
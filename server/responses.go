@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/ildomm/cceab/entity"
 	"net/http"
@@ -13,9 +14,24 @@ type Response struct {
 	Data interface{} `json:"data"`
 }
 
-// ErrorResponse is the generic error API response container.
-type ErrorResponse struct {
-	Errors []string `json:"errors"`
+// ProblemResponse is the error API response, in the problem+json format of RFC 7807.
+// Code is stable, unlike Title and Detail, which are meant for humans.
+// The parameters failing the validation are listed in InvalidParams, and the resource the problem relates to in Data.
+type ProblemResponse struct {
+	Type          string                 `json:"type"`
+	Title         string                 `json:"title"`
+	Status        int                    `json:"status"`
+	Detail        string                 `json:"detail,omitempty"`
+	Code          string                 `json:"code"`
+	InvalidParams []InvalidParamResponse `json:"invalidParams,omitempty"`
+	Data          interface{}            `json:"data,omitempty"`
+}
+
+// InvalidParamResponse is a request parameter failing the validation.
+type InvalidParamResponse struct {
+	Name   string `json:"name"`
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
 }
 
 // WriteInternalError writes a default internal error message as an HTTP response.
@@ -24,23 +40,55 @@ func WriteInternalError(w http.ResponseWriter) {
 	w.Write([]byte(http.StatusText(http.StatusInternalServerError))) //nolint:all
 }
 
-// WriteErrorResponse takes an HTTP status code and a slice of errors
-// and writes those as an HTTP error response in a structured format.
-func WriteErrorResponse(w http.ResponseWriter, code int, errors []string) {
-	w.WriteHeader(code)
+// WriteProblemResponse takes an error and writes it as an HTTP error response in the problem+json format,
+// with the status and code the error is mapped to.
+func WriteProblemResponse(w http.ResponseWriter, err error) {
+	WriteProblemResponseWithData(w, err, nil)
+}
 
-	errorResponse := ErrorResponse{
-		Errors: errors,
-	}
+// WriteProblemResponseWithData takes an error and the resource it relates to
+// and writes those as an HTTP error response in the problem+json format.
+func WriteProblemResponseWithData(w http.ResponseWriter, err error, data interface{}) {
+	response := transformProblemResponse(err)
+	response.Data = data
 
-	bytes, err := json.Marshal(errorResponse)
+	bytes, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
 		WriteInternalError(w)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(response.Status)
 	w.Write(bytes) //nolint:all
 }
 
+// transformProblemResponse transforms an error into a ProblemResponse, only the message of its mapped entity error being disclosed.
+func transformProblemResponse(err error) ProblemResponse {
+	p := problemFor(err)
+
+	response := ProblemResponse{
+		Type:   ProblemTypePrefix + p.code,
+		Title:  p.err.Error(),
+		Status: p.status,
+		Detail: problemDetails[p.code],
+		Code:   p.code,
+	}
+
+	var params invalidParams
+	if errors.As(err, &params) {
+		for _, param := range params {
+			response.InvalidParams = append(response.InvalidParams, InvalidParamResponse{
+				Name:   param.name,
+				Code:   problemFor(param.err).code,
+				Reason: param.err.Error(),
+			})
+		}
+	}
+
+	return response
+}
+
 // WriteAPIResponse takes an HTTP status code and a generic data struct
 // and writes those as an HTTP response in a structured format.
 func WriteAPIResponse(w http.ResponseWriter, code int, data interface{}) {
@@ -64,12 +112,129 @@ type HealthResponse struct {
 	Version string `json:"version"`
 }
 
+// Statuses of the health checks
+const (
+	HealthStatusPass = "pass"
+	HealthStatusFail = "fail"
+)
+
+// HealthCheckResponse represents the response for the liveness and readiness checks,
+// following the health+json format, see https://datatracker.ietf.org/doc/html/draft-inadarei-api-health-check
+type HealthCheckResponse struct {
+	Status    string                         `json:"status"`
+	Version   string                         `json:"version,omitempty"`
+	ReleaseID string                         `json:"releaseId,omitempty"`
+	Output    string                         `json:"output,omitempty"`
+	Checks    map[string][]HealthCheckDetail `json:"checks,omitempty"`
+}
+
+// HealthCheckDetail represents the outcome of a check of a component the service depends on.
+type HealthCheckDetail struct {
+	ComponentType string      `json:"componentType,omitempty"`
+	ObservedValue interface{} `json:"observedValue,omitempty"`
+	ObservedUnit  string      `json:"observedUnit,omitempty"`
+	Status        string      `json:"status"`
+	Time          time.Time   `json:"time"`
+	Output        string      `json:"output,omitempty"`
+}
+
+// WriteHealthResponse takes an HTTP status code and the health of the service
+// and writes those as an HTTP response in the health+json format.
+func WriteHealthResponse(w http.ResponseWriter, code int, health HealthCheckResponse) {
+	w.Header().Set("Content-Type", "application/health+json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+
+	bytes, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		WriteInternalError(w)
+	}
+
+	w.Write(bytes) //nolint:all
+}
+
 type GameResultResponse struct {
+	ID                int                         `json:"id"`
+	UserID            uuid.UUID                   `json:"userId"`
+	GameStatus        entity.GameStatus           `json:"state"`
+	ValidationStatus  entity.ValidationStatus     `json:"validationStatus"`
+	TransactionSource entity.TransactionSource    `json:"source"`
+	TransactionID     string                      `json:"transactionId"`
+	Amount            entity.Money                `json:"amount"`
+	CreatedAt         time.Time                   `json:"createdAt"`
+	Decision          *ValidationDecisionResponse `json:"validationDecision,omitempty"`
+}
+
+type ValidationDecisionResponse struct {
+	Decision        entity.ValidationStatus `json:"decision"`
+	Policy          string                  `json:"policy"`
+	Reason          entity.ValidationReason `json:"reasonCode"`
+	BalanceBefore   entity.Money            `json:"balanceBefore"`
+	BalanceAfter    entity.Money            `json:"balanceAfter"`
+	ValidationRunID int                     `json:"validationRunId"`
+	CreatedAt       time.Time               `json:"createdAt"`
+}
+
+type GameResultListResponse struct {
+	GameResults []GameResultResponse `json:"gameResults"`
+	NextCursor  int                  `json:"nextCursor,omitempty"`
+}
+
+type CreateGameResultsBatchResponse struct {
+	Results []GameResultBatchItemResponse `json:"results"`
+}
+
+type GameResultBatchItemResponse struct {
+	Index         int                    `json:"index"`
+	TransactionID string                 `json:"transactionId"`
+	Status        entity.BatchItemStatus `json:"status"`
+	GameResult    *GameResultResponse    `json:"gameResult,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	Code          string                 `json:"code,omitempty"` // The code of the error, as in the problem responses
+	InvalidParams []InvalidParamResponse `json:"invalidParams,omitempty"`
+}
+
+type UserResponse struct {
+	ID                   uuid.UUID    `json:"id"`
+	Email                string       `json:"email"`
+	Balance              entity.Money `json:"balance"`
+	PendingBalance       entity.Money `json:"pendingBalance"`
+	SettledBalance       entity.Money `json:"settledBalance"`
+	LastGameResultAt     *time.Time   `json:"lastGameResultAt"`
+	GamesResultValidated *bool        `json:"gamesResultValidated"`
+	Disabled             bool         `json:"disabled"`
+	CreatedAt            time.Time    `json:"createdAt"`
+}
+
+type ValidationRunResponse struct {
+	ID              int          `json:"id"`
+	StartedAt       time.Time    `json:"startedAt"`
+	FinishedAt      time.Time    `json:"finishedAt"`
+	UsersProcessed  int          `json:"usersProcessed"`
+	ResultsApproved int          `json:"resultsApproved"`
+	ResultsCanceled int          `json:"resultsCanceled"`
+	BalanceDelta    entity.Money `json:"balanceDelta"`
+	Error           *string      `json:"error"`
+}
+
+type ValidationRunListResponse struct {
+	ValidationRuns []ValidationRunResponse `json:"validationRuns"`
+	NextCursor     int                     `json:"nextCursor,omitempty"`
+}
+
+type APIKeyResponse struct {
 	ID                int                      `json:"id"`
-	UserID            uuid.UUID                `json:"userId"`
-	GameStatus        entity.GameStatus        `json:"state"`
+	Prefix            string                   `json:"prefix"`
 	TransactionSource entity.TransactionSource `json:"source"`
-	TransactionID     string                   `json:"transactionId"`
-	Amount            float64                  `json:"amount"`
+	Description       string                   `json:"description"`
+	Active            bool                     `json:"active"`
 	CreatedAt         time.Time                `json:"createdAt"`
+	ExpiresAt         *time.Time               `json:"expiresAt"`
+	RevokedAt         *time.Time               `json:"revokedAt"`
+	Key               string                   `json:"key,omitempty"`        // Only when created, it can not be read again
+	SigningKey        string                   `json:"signingKey,omitempty"` // Only when created, it can not be read again
+}
+
+type APIKeyListResponse struct {
+	APIKeys []APIKeyResponse `json:"apiKeys"`
 }
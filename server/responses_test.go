@@ -2,6 +2,8 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/ildomm/cceab/entity"
 	"github.com/stretchr/testify/assert"
@@ -22,20 +24,117 @@ func TestWriteInternalError(t *testing.T) {
 	assert.Equal(t, http.StatusText(http.StatusInternalServerError), rr.Body.String(), "unexpected body content")
 }
 
-// TestWriteErrorResponse tests the WriteErrorResponse function.
-func TestWriteErrorResponse(t *testing.T) {
+// TestWriteProblemResponse tests the WriteProblemResponse function, with the status and code of the entity error.
+func TestWriteProblemResponse(t *testing.T) {
 	rr := httptest.NewRecorder()
-	errors := []string{"error1", "error2"}
 
-	WriteErrorResponse(rr, http.StatusBadRequest, errors)
+	WriteProblemResponse(rr, entity.ErrUserNegativeBalance)
+
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code, "unexpected status code")
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var resp ProblemResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	assert.Equal(t, ProblemResponse{
+		Type:   "urn:cceab:problem:negative_balance",
+		Title:  entity.ErrUserNegativeBalance.Error(),
+		Status: http.StatusNotAcceptable,
+		Code:   "negative_balance",
+	}, resp)
+}
+
+// TestWriteProblemResponseDetail tests the WriteProblemResponse function withholds the message of the errors wrapping an entity error,
+// the detail being the same on every occurrence of the problem.
+func TestWriteProblemResponseDetail(t *testing.T) {
+	tests := []struct {
+		err            error
+		code           string
+		expectedDetail string
+	}{
+		{fmt.Errorf("%w: %q", entity.ErrInvalidTransactionSource, "arcade"), "invalid_transaction_source", ""},
+		{fmt.Errorf("inserting user: %w: pq: duplicate key value violates unique constraint", entity.ErrUserEmailExists), "user_email_exists", ""},
+		{fmt.Errorf("limiting user %q: %w", "arcade", entity.ErrRateLimited), "rate_limited", problemDetails["rate_limited"]},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+
+		WriteProblemResponse(rr, tt.err)
+
+		assert.NotContains(t, rr.Body.String(), "arcade")
+		assert.NotContains(t, rr.Body.String(), "pq:")
+
+		var resp ProblemResponse
+		err := json.Unmarshal(rr.Body.Bytes(), &resp)
+		require.NoError(t, err)
+
+		assert.Equal(t, tt.code, resp.Code)
+		assert.Equal(t, problemFor(tt.err).err.Error(), resp.Title)
+		assert.Equal(t, tt.expectedDetail, resp.Detail)
+	}
+}
+
+// TestWriteProblemResponseUnknownError tests the WriteProblemResponse function withholds the message of the unmapped errors.
+func TestWriteProblemResponseUnknownError(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	WriteProblemResponse(rr, errors.New("pq: connection refused"))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "unexpected status code")
+	assert.NotContains(t, rr.Body.String(), "connection refused")
+
+	var resp ProblemResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &resp)
+	require.NoError(t, err)
+
+	assert.Equal(t, "internal_error", resp.Code)
+	assert.Empty(t, resp.Detail)
+}
+
+// TestWriteProblemResponseInvalidParams tests the WriteProblemResponse function lists each parameter failing the validation.
+func TestWriteProblemResponseInvalidParams(t *testing.T) {
+	rr := httptest.NewRecorder()
+
+	var params invalidParams
+	params.add("amount", fmt.Errorf("%w: %w", entity.ErrInvalidAmount, entity.ErrMoneyPrecision))
+	params.add("state", entity.ErrInvalidGameStatus)
+
+	WriteProblemResponse(rr, params)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code, "unexpected status code")
 
-	var resp ErrorResponse
+	var resp ProblemResponse
 	err := json.Unmarshal(rr.Body.Bytes(), &resp)
 	require.NoError(t, err)
 
-	assert.Equal(t, errors, resp.Errors, "unexpected errors in response")
+	assert.Equal(t, "invalid_parameters", resp.Code)
+	assert.Equal(t, []InvalidParamResponse{
+		{Name: "amount", Code: "invalid_amount", Reason: "invalid amount format: more than two decimal places"},
+		{Name: "state", Code: "invalid_game_status", Reason: "invalid game status"},
+	}, resp.InvalidParams)
+}
+
+// TestWriteProblemResponseWithData tests the WriteProblemResponseWithData function.
+func TestWriteProblemResponseWithData(t *testing.T) {
+	rr := httptest.NewRecorder()
+	data := map[string]string{"key": "value"}
+
+	WriteProblemResponseWithData(rr, entity.ErrTransactionIdConflict, data)
+
+	assert.Equal(t, http.StatusConflict, rr.Code, "unexpected status code")
+
+	expectedBytes, err := json.Marshal(ProblemResponse{
+		Type:   "urn:cceab:problem:transaction_id_conflict",
+		Title:  entity.ErrTransactionIdConflict.Error(),
+		Status: http.StatusConflict,
+		Code:   "transaction_id_conflict",
+		Data:   data,
+	})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, string(expectedBytes), rr.Body.String(), "unexpected data in response")
 }
 
 // TestWriteAPIResponse tests the WriteAPIResponse function.
@@ -79,7 +178,7 @@ func TestGameResultResponse(t *testing.T) {
 		GameStatus:        entity.GameStatusWin,
 		TransactionSource: entity.TransactionSourceGame,
 		TransactionID:     "txn123",
-		Amount:            100.50,
+		Amount:            100_50,
 		CreatedAt:         time.Now(),
 	}
 
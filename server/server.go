@@ -1,10 +1,14 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/ildomm/cceab/dao"
+	"github.com/ildomm/cceab/metrics"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,16 +18,37 @@ const (
 	DefaultWriteTimeout      = time.Second * 15
 	DefaultReadTimeout       = time.Second * 15
 	DefaultIdleTimeout       = time.Second * 60
+	DefaultDrainTimeout      = time.Second * 30
+	DefaultPreDrainDelay     = time.Second * 5
 )
 
 // Server manages HTTP requests and dispatches them to the appropriate services.
 type Server struct {
-	listenAddress     int
-	gameResultManager dao.GameResultDAO
-	readHeaderTimeout time.Duration
-	writeTimeout      time.Duration
-	readTimeout       time.Duration
-	idleTimeout       time.Duration
+	listenAddress        int
+	gameResultManager    dao.GameResultDAO
+	userManager          dao.UserDAO
+	validationRunManager dao.ValidationRunDAO
+	healthManager        dao.HealthDAO
+	apiKeyManager        dao.APIKeyDAO
+	requireSignatures    bool
+	signatureMaxAge      time.Duration
+	userRateLimit        RateLimit
+	sourceRateLimit      RateLimit
+	maxInFlight          int
+	semVer               string
+	gitSha               string
+	adminToken           string
+	readHeaderTimeout    time.Duration
+	writeTimeout         time.Duration
+	readTimeout          time.Duration
+	idleTimeout          time.Duration
+	drainTimeout         time.Duration
+	preDrainDelay        time.Duration
+
+	// The running HTTP server, and whether it is draining its requests before shutting down
+	httpServerLock sync.Mutex
+	httpServer     *http.Server
+	draining       atomic.Bool
 }
 
 // NewServer is a factory to instantiate a new Server.
@@ -35,6 +60,9 @@ func NewServer() *Server {
 		writeTimeout:      DefaultWriteTimeout,
 		readTimeout:       DefaultReadTimeout,
 		idleTimeout:       DefaultIdleTimeout,
+		drainTimeout:      DefaultDrainTimeout,
+		preDrainDelay:     DefaultPreDrainDelay,
+		signatureMaxAge:   DefaultSignatureMaxAge,
 	}
 }
 
@@ -53,22 +81,111 @@ func (s *Server) Run() error {
 		Handler: s.router(),
 	}
 
+	s.httpServerLock.Lock()
+	s.httpServer = httpServer
+	s.httpServerLock.Unlock()
+
 	return httpServer.ListenAndServe()
 }
 
+// Shutdown stops accepting new requests and waits for the in-flight ones to finish, up to the drain timeout.
+// The health check fails from then on, so the load balancers stop routing requests to the server.
+// The new requests are still served during the pre-drain delay, leaving the load balancers the time to see the failing health check.
+// Run returns http.ErrServerClosed once the server is shut down.
+// It returns an error if the in-flight requests did not finish in time.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.draining.Store(true)
+
+	s.httpServerLock.Lock()
+	httpServer := s.httpServer
+	s.httpServerLock.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	select {
+	case <-time.After(s.preDrainDelay):
+	case <-ctx.Done():
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.drainTimeout)
+	defer cancel()
+
+	return httpServer.Shutdown(ctx)
+}
+
+// Draining tells if the server is shutting down, draining its in-flight requests.
+func (s *Server) Draining() bool {
+	return s.draining.Load()
+}
+
 // router registers all HandlerFunc and middleware for the existing HTTP routes.
 func (s *Server) router() *mux.Router {
 
 	r := mux.NewRouter()
 
 	// Interceptors
+	r.Use(NewRequestIDMiddleware())
 	r.Use(NewRecoverMiddleware())
+	r.Use(NewTracingMiddleware())
 	r.Use(NewLoggingMiddleware())
+	r.Use(NewMetricsMiddleware())
+
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 
 	r.HandleFunc("/api/v1/health", s.HealthHandler).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/health/live", s.LiveHandler).Methods(http.MethodGet)
+	r.HandleFunc("/api/v1/health/ready", s.ReadyHandler).Methods(http.MethodGet)
+
+	// The probes and the metrics are not shed, the server being overloaded rather than unhealthy
+	api := r.NewRoute().Subrouter()
+	api.Use(NewLoadSheddingMiddleware(s.maxInFlight))
+
+	// Game results are only recorded on behalf of the transaction source bound to the api key,
+	// within the rate limits of the source and of the user, and their signature verified before they are handled.
+	// The batches, spanning several users, are only limited by source.
+	sourceAuth := NewAPIKeyAuthMiddleware(s.apiKeyManager)
+	sourceLimit := NewSourceRateLimitMiddleware(s.sourceRateLimit)
+	userLimit := NewUserRateLimitMiddleware(s.userRateLimit)
+	signatureCheck := NewSignatureMiddleware(s.apiKeyManager, s.requireSignatures, s.signatureMaxAge)
+	sourceSigned := func(handler http.HandlerFunc) http.Handler {
+		return sourceAuth(sourceLimit(userLimit(signatureCheck(handler))))
+	}
+
+	// Reading the users and their game results is restricted to the admin token holders, not to disclose them to the sources
+	adminAuth := NewAdminAuthMiddleware(s.adminToken)
 
 	dh := NewGameResultHandler(s.gameResultManager)
-	r.HandleFunc("/api/v1/users/{id}/game_results", dh.CreateGameResultFunc).Methods(http.MethodPost)
+	api.Handle("/api/v1/users/{id}/game_results", sourceSigned(dh.CreateGameResultFunc)).Methods(http.MethodPost)
+	api.Handle("/api/v1/users/{id}/game_results", adminAuth(http.HandlerFunc(dh.ListGameResultsFunc))).Methods(http.MethodGet)
+	api.Handle("/api/v1/game_results:batch", sourceSigned(dh.CreateGameResultsBatchFunc)).Methods(http.MethodPost)
+
+	// Creating a user with a balance, or changing and disabling one, is restricted to the admin token holders
+
+	uh := NewUserHandler(s.userManager)
+	api.Handle("/api/v1/users", adminAuth(http.HandlerFunc(uh.CreateUserFunc))).Methods(http.MethodPost)
+	api.Handle("/api/v1/users/{id}", adminAuth(http.HandlerFunc(uh.GetUserFunc))).Methods(http.MethodGet)
+	api.Handle("/api/v1/users/{id}", adminAuth(http.HandlerFunc(uh.UpdateUserFunc))).Methods(http.MethodPatch)
+
+	// The validation runs are restricted to the admin token holders as well
+	vh := NewValidationRunHandler(s.validationRunManager)
+	api.Handle("/api/v1/validation_runs", adminAuth(http.HandlerFunc(vh.ListValidationRunsFunc))).Methods(http.MethodGet)
+	api.Handle("/api/v1/validation_runs/{id}", adminAuth(http.HandlerFunc(vh.GetValidationRunFunc))).Methods(http.MethodGet)
+
+	// Operations routes, restricted to the admin token holders
+	admin := api.PathPrefix("/api/v1/admin").Subrouter()
+	admin.Use(adminAuth)
+
+	ah := NewAdminHandler(s.gameResultManager)
+	admin.HandleFunc("/users/{id}/validate", ah.ValidateUserFunc).Methods(http.MethodPost)
+	admin.HandleFunc("/validations", ah.ValidateGameResultsFunc).Methods(http.MethodPost)
+
+	kh := NewAPIKeyHandler(s.apiKeyManager)
+	admin.HandleFunc("/api_keys", kh.CreateAPIKeyFunc).Methods(http.MethodPost)
+	admin.HandleFunc("/api_keys", kh.ListAPIKeysFunc).Methods(http.MethodGet)
+	admin.HandleFunc("/api_keys/{id}/rotate", kh.RotateAPIKeyFunc).Methods(http.MethodPost)
+	admin.HandleFunc("/api_keys/{id}", kh.RevokeAPIKeyFunc).Methods(http.MethodDelete)
 
 	return r
 }
@@ -85,6 +202,59 @@ func (s *Server) WithGameResultManager(gameResultManager dao.GameResultDAO) {
 	s.gameResultManager = gameResultManager
 }
 
+func (s *Server) WithUserManager(userManager dao.UserDAO) {
+	s.userManager = userManager
+}
+
+func (s *Server) WithValidationRunManager(validationRunManager dao.ValidationRunDAO) {
+	s.validationRunManager = validationRunManager
+}
+
+func (s *Server) WithHealthManager(healthManager dao.HealthDAO) {
+	s.healthManager = healthManager
+}
+
+// WithAPIKeyManager sets the api keys authenticating the transaction sources, which can not record game results without it
+func (s *Server) WithAPIKeyManager(apiKeyManager dao.APIKeyDAO) {
+	s.apiKeyManager = apiKeyManager
+}
+
+// WithRequestSignatures sets whether the game results must be signed, and how old their signature can be
+func (s *Server) WithRequestSignatures(required bool, maxAge time.Duration) {
+	s.requireSignatures = required
+	s.signatureMaxAge = maxAge
+}
+
+// WithRateLimits sets the rate limits of the game results, by user and by transaction source
+func (s *Server) WithRateLimits(userRateLimit RateLimit, sourceRateLimit RateLimit) {
+	s.userRateLimit = userRateLimit
+	s.sourceRateLimit = sourceRateLimit
+}
+
+// WithMaxInFlight sets how many requests can be in flight before the new ones are shed, zero disabling the shedding
+func (s *Server) WithMaxInFlight(maxInFlight int) {
+	s.maxInFlight = maxInFlight
+}
+
+// WithVersion sets the semantic version and the Git commit SHA reported by the health checks
+func (s *Server) WithVersion(semVer string, gitSha string) {
+	s.semVer = semVer
+	s.gitSha = gitSha
+}
+
+// WithAdminToken sets the bearer token required by the admin routes, which are disabled without it
+func (s *Server) WithAdminToken(adminToken string) {
+	s.adminToken = adminToken
+}
+
+func (s *Server) WithDrainTimeout(drainTimeout time.Duration) {
+	s.drainTimeout = drainTimeout
+}
+
+func (s *Server) WithPreDrainDelay(preDrainDelay time.Duration) {
+	s.preDrainDelay = preDrainDelay
+}
+
 func (s *Server) WithReadHeaderTimeout(readHeaderTimeout time.Duration) {
 	s.readHeaderTimeout = readHeaderTimeout
 }
@@ -1,13 +1,20 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+	"github.com/ildomm/cceab/test_helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestNewServer tests the NewServer factory function.
@@ -37,6 +44,12 @@ func TestServerConfigurationSetters(t *testing.T) {
 
 	server.WithIdleTimeout(time.Second * 20)
 	assert.Equal(t, time.Second*20, server.idleTimeout)
+
+	server.WithDrainTimeout(time.Second * 20)
+	assert.Equal(t, time.Second*20, server.drainTimeout)
+
+	server.WithPreDrainDelay(time.Second * 20)
+	assert.Equal(t, time.Second*20, server.preDrainDelay)
 }
 
 // TestServerRun tests the Run method of the server.
@@ -63,3 +76,117 @@ func TestServerRun(t *testing.T) {
 	assert.NoError(t, err, "request to server failed")
 	assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code from health check")
 }
+
+// startSlowServer starts a server whose game results listing takes the given delay.
+// It returns the URL of the listing, to get with getAsAdmin, and the channel receiving the outcome of Run.
+func startSlowServer(t *testing.T, delay time.Duration, drainTimeout time.Duration) (*Server, string, chan error) {
+	mockDAO := test_helpers.NewMockGameResultDAO()
+	mockDAO.On("ListGameResults", mock.Anything, mock.Anything).After(delay).Return(&entity.GameResultPage{}, nil)
+
+	server := NewServer()
+	port := rand.Intn(1000) + 8000
+	server.WithListenAddress(port)
+	server.WithGameResultManager(mockDAO)
+	server.WithAdminToken("admin-token")
+	server.WithDrainTimeout(drainTimeout)
+	server.WithPreDrainDelay(0)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- server.Run()
+	}()
+	time.Sleep(1 * time.Second) // Wait a moment for the server to start
+
+	return server, fmt.Sprintf("http://localhost:%d/api/v1/users/%s/game_results", port, uuid.New()), runErr
+}
+
+// getAsAdmin gets the URL with the admin token of the servers started by startSlowServer.
+func getAsAdmin(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer admin-token")
+
+	return http.DefaultClient.Do(req)
+}
+
+// TestServerShutdownDrainsInFlightRequests tests the in-flight requests finish before the server is shut down.
+func TestServerShutdownDrainsInFlightRequests(t *testing.T) {
+	server, url, runErr := startSlowServer(t, 500*time.Millisecond, 5*time.Second)
+
+	// Start a request, then shut down while it is in flight
+	statusCode := make(chan int, 1)
+	go func() {
+		resp, err := getAsAdmin(url)
+		if err != nil {
+			statusCode <- 0
+			return
+		}
+		resp.Body.Close()
+		statusCode <- resp.StatusCode
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	err := server.Shutdown(context.Background())
+	require.NoError(t, err, "in-flight requests should be drained in time")
+	assert.True(t, server.Draining())
+
+	// The request in flight was served, and Run returned
+	assert.Equal(t, http.StatusOK, <-statusCode)
+	assert.True(t, errors.Is(<-runErr, http.ErrServerClosed))
+
+	// No new requests are accepted
+	_, err = getAsAdmin(url)
+	assert.Error(t, err, "requests should be refused once shut down")
+}
+
+// TestServerShutdownPreDrainDelay tests the server still serves the new requests during the pre-drain delay, while not ready.
+func TestServerShutdownPreDrainDelay(t *testing.T) {
+	server, url, runErr := startSlowServer(t, 0, 5*time.Second)
+	server.WithPreDrainDelay(time.Second)
+	readyURL := fmt.Sprintf("http://localhost:%d/api/v1/health/ready", server.ListenAddress())
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- server.Shutdown(context.Background())
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// The load balancers see the server is not ready, the requests still routed to it being served
+	resp, err := http.Get(readyURL)
+	require.NoError(t, err, "the ready probe should be served during the pre-drain delay")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp, err = getAsAdmin(url)
+	require.NoError(t, err, "new requests should be served during the pre-drain delay")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Then the server shuts down
+	require.NoError(t, <-shutdownErr)
+	assert.True(t, errors.Is(<-runErr, http.ErrServerClosed))
+
+	_, err = getAsAdmin(url)
+	assert.Error(t, err, "requests should be refused once shut down")
+}
+
+// TestServerShutdownDrainTimeout tests the shutdown gives up on the requests not finished within the drain timeout.
+func TestServerShutdownDrainTimeout(t *testing.T) {
+	server, url, _ := startSlowServer(t, 2*time.Second, 100*time.Millisecond)
+
+	go getAsAdmin(url) //nolint:all
+	time.Sleep(100 * time.Millisecond)
+
+	err := server.Shutdown(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestServerShutdownNotRunning tests the shutdown of a server not running.
+func TestServerShutdownNotRunning(t *testing.T) {
+	server := NewServer()
+
+	assert.NoError(t, server.Shutdown(context.Background()))
+	assert.True(t, server.Draining())
+}
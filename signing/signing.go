@@ -0,0 +1,149 @@
+// Package signing signs the requests sent to the API with HMAC-SHA256, proving their integrity and freshness.
+//
+// Along with the api key, a signed request carries:
+//   - X-Signature-Timestamp, the Unix time of the signature, in seconds
+//   - X-Signature-Nonce, a random value never used again with the same api key
+//   - X-Signature, the signature of the request, as "v1=<hex>"
+//
+// The signature is the HMAC-SHA256 of the method, the escaped path and query, the timestamp, the nonce
+// and the hex SHA-256 of the body, separated by new lines.
+// It is keyed by the signing key of the api key, a random secret handed out along with the api key and never sent.
+//
+// Clients either sign their requests with SignRequest, or send them through a Transport:
+//
+//	client := &http.Client{Transport: &signing.Transport{APIKey: apiKey, SigningKey: signingKey}}
+package signing
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Headers of the signed requests
+const (
+	APIKeyHeader    = "X-API-Key"
+	TimestampHeader = "X-Signature-Timestamp"
+	NonceHeader     = "X-Signature-Nonce"
+	SignatureHeader = "X-Signature"
+)
+
+const (
+	// Version prefixes the signatures, so the signing scheme can evolve
+	Version = "v1"
+
+	// MinNonceLength and MaxNonceLength bound the length of the nonces accepted
+	MinNonceLength = 16
+	MaxNonceLength = 128
+
+	// KeySize is the number of random bytes of a signing key
+	KeySize = 32
+)
+
+// NewKey generates a random signing key.
+// It is independent of the api key, so seeing the api key of a request does not allow signing others.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Sign computes the signature of a request, as sent in the SignatureHeader.
+// The signature covers the method, the target (the escaped path and the query), the timestamp, the nonce and the body.
+func Sign(key []byte, method string, target string, timestamp string, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(method + "\n" + target + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bodyHash[:])))
+	return Version + "=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells if the signature matches the request, in constant time.
+func Verify(key []byte, method string, target string, timestamp string, nonce string, body []byte, signature string) bool {
+	expected := Sign(key, method, target, timestamp, nonce, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Target returns the part of the URL covered by the signature: the escaped path, along with the query when given.
+func Target(u *url.URL) string {
+	target := u.EscapedPath()
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+	return target
+}
+
+// NewNonce generates a random nonce, never to be used again with the same api key.
+func NewNonce() (string, error) {
+	nonce := make([]byte, MinNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(nonce), nil
+}
+
+// SignRequest authenticates the request with the api key, and signs it with the signing key of the api key.
+// The body is read, and replaced so the request can still be sent.
+func SignRequest(req *http.Request, apiKey string, signingKey []byte) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	nonce, err := NewNonce()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set(APIKeyHeader, apiKey)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(NonceHeader, nonce)
+	req.Header.Set(SignatureHeader, Sign(signingKey, req.Method, Target(req.URL), timestamp, nonce, body))
+	return nil
+}
+
+// Transport is an http.RoundTripper signing every request sent with the api key and its signing key.
+// Base sends the signed requests, http.DefaultTransport when nil.
+type Transport struct {
+	APIKey     string
+	SigningKey []byte
+	Base       http.RoundTripper
+}
+
+// RoundTrip signs a copy of the request, then sends it.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request it is given
+	signed := req.Clone(req.Context())
+	if err := SignRequest(signed, t.APIKey, t.SigningKey); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(signed)
+}
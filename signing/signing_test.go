@@ -0,0 +1,120 @@
+package signing
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+	other, err := NewKey()
+	require.NoError(t, err)
+	body := []byte(`{"state": "win"}`)
+
+	signature := Sign(key, http.MethodPost, "/api/v1/game_results:batch", "1700000000", "0123456789abcdef", body)
+	require.True(t, strings.HasPrefix(signature, Version+"="))
+
+	assert.True(t, Verify(key, http.MethodPost, "/api/v1/game_results:batch", "1700000000", "0123456789abcdef", body, signature))
+
+	// Any change to the request breaks the signature
+	assert.False(t, Verify(other, http.MethodPost, "/api/v1/game_results:batch", "1700000000", "0123456789abcdef", body, signature))
+	assert.False(t, Verify(key, http.MethodPut, "/api/v1/game_results:batch", "1700000000", "0123456789abcdef", body, signature))
+	assert.False(t, Verify(key, http.MethodPost, "/api/v1/users", "1700000000", "0123456789abcdef", body, signature))
+	assert.False(t, Verify(key, http.MethodPost, "/api/v1/game_results:batch", "1700000001", "0123456789abcdef", body, signature))
+	assert.False(t, Verify(key, http.MethodPost, "/api/v1/game_results:batch", "1700000000", "fedcba9876543210", body, signature))
+	assert.False(t, Verify(key, http.MethodPost, "/api/v1/game_results:batch", "1700000000", "0123456789abcdef", []byte(`{"state": "lost"}`), signature))
+}
+
+func TestNewKey(t *testing.T) {
+	first, err := NewKey()
+	require.NoError(t, err)
+	second, err := NewKey()
+	require.NoError(t, err)
+
+	assert.Len(t, first, KeySize)
+	assert.NotEqual(t, first, second)
+}
+
+func TestNewNonce(t *testing.T) {
+	first, err := NewNonce()
+	require.NoError(t, err)
+	second, err := NewNonce()
+	require.NoError(t, err)
+
+	assert.GreaterOrEqual(t, len(first), MinNonceLength)
+	assert.NotEqual(t, first, second)
+}
+
+func TestSignRequest(t *testing.T) {
+	body := `{"gameResults": []}`
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:8000/api/v1/game_results:batch?dry=1", strings.NewReader(body))
+	require.NoError(t, err)
+
+	key, err := NewKey()
+	require.NoError(t, err)
+
+	err = SignRequest(req, "cceab_key", key)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cceab_key", req.Header.Get(APIKeyHeader))
+	timestamp, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), time.Unix(timestamp, 0), 2*time.Second)
+
+	// The body can still be sent
+	sent, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(sent))
+
+	assert.True(t, Verify(
+		key,
+		http.MethodPost,
+		"/api/v1/game_results:batch?dry=1",
+		req.Header.Get(TimestampHeader),
+		req.Header.Get(NonceHeader),
+		[]byte(body),
+		req.Header.Get(SignatureHeader),
+	))
+}
+
+func TestTransport(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+
+	var verified bool
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = r.Header.Get(APIKeyHeader) == "cceab_key" && Verify(
+			key,
+			r.Method,
+			Target(r.URL),
+			r.Header.Get(TimestampHeader),
+			r.Header.Get(NonceHeader),
+			body,
+			r.Header.Get(SignatureHeader),
+		)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	client := &http.Client{Transport: &Transport{APIKey: "cceab_key", SigningKey: key}}
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/users/11111111-1111-1111-1111-111111111111/game_results", bytes.NewReader([]byte(`{"state": "win"}`)))
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.True(t, verified, "the server should verify the signature")
+	assert.Empty(t, req.Header.Get(SignatureHeader), "the original request should be left untouched")
+}
@@ -0,0 +1,125 @@
+package system
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Formats of the dry run report
+const (
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+)
+
+type validationReport struct {
+	StartedAt       time.Time              `json:"startedAt"`
+	FinishedAt      time.Time              `json:"finishedAt"`
+	UsersProcessed  int                    `json:"usersProcessed"`
+	ResultsApproved int                    `json:"resultsApproved"`
+	ResultsCanceled int                    `json:"resultsCanceled"`
+	BalanceDelta    entity.Money           `json:"balanceDelta"`
+	Users           []userValidationReport `json:"users"`
+}
+
+type userValidationReport struct {
+	UserID        uuid.UUID                  `json:"userId"`
+	BalanceBefore entity.Money               `json:"balanceBefore"`
+	BalanceAfter  entity.Money               `json:"balanceAfter"`
+	Decisions     []validationDecisionReport `json:"decisions"`
+}
+
+type validationDecisionReport struct {
+	GameResultID  int                     `json:"gameResultId"`
+	Decision      entity.ValidationStatus `json:"decision"`
+	Policy        string                  `json:"policy"`
+	Reason        entity.ValidationReason `json:"reasonCode"`
+	BalanceBefore entity.Money            `json:"balanceBefore"`
+	BalanceAfter  entity.Money            `json:"balanceAfter"`
+}
+
+// reportHeader is the header of the CSV report, which has a row per game result
+var reportHeader = []string{"user_id", "game_result_id", "decision", "policy", "reason_code", "balance_before", "balance_after"}
+
+// WriteValidationReport writes what a dry validation run would change, in the given format.
+// The JSON report holds the totals of the run and the decisions of each user,
+// the CSV report holds a row per game result, the last row of a user giving its resulting balance.
+func WriteValidationReport(w io.Writer, format string, run entity.ValidationRun) error {
+	switch format {
+	case ReportFormatJSON:
+		return writeJSONReport(w, run)
+	case ReportFormatCSV:
+		return writeCSVReport(w, run)
+	default:
+		return fmt.Errorf("unknown report format %q, expected %s or %s", format, ReportFormatJSON, ReportFormatCSV)
+	}
+}
+
+func writeJSONReport(w io.Writer, run entity.ValidationRun) error {
+	report := validationReport{
+		StartedAt:       run.StartedAt,
+		FinishedAt:      run.FinishedAt,
+		UsersProcessed:  run.UsersProcessed,
+		ResultsApproved: run.ResultsApproved,
+		ResultsCanceled: run.ResultsCanceled,
+		BalanceDelta:    run.BalanceDelta,
+		Users:           make([]userValidationReport, 0, len(run.Users)),
+	}
+
+	for _, user := range run.Users {
+		userReport := userValidationReport{
+			UserID:        user.UserID,
+			BalanceBefore: user.BalanceBefore,
+			BalanceAfter:  user.BalanceAfter,
+			Decisions:     make([]validationDecisionReport, 0, len(user.Decisions)),
+		}
+		for _, decision := range user.Decisions {
+			userReport.Decisions = append(userReport.Decisions, validationDecisionReport{
+				GameResultID:  decision.GameResultID,
+				Decision:      decision.Decision,
+				Policy:        decision.Policy,
+				Reason:        decision.Reason,
+				BalanceBefore: decision.BalanceBefore,
+				BalanceAfter:  decision.BalanceAfter,
+			})
+		}
+		report.Users = append(report.Users, userReport)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func writeCSVReport(w io.Writer, run entity.ValidationRun) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(reportHeader); err != nil {
+		return err
+	}
+
+	for _, user := range run.Users {
+		for _, decision := range user.Decisions {
+			err := writer.Write([]string{
+				user.UserID.String(),
+				strconv.Itoa(decision.GameResultID),
+				string(decision.Decision),
+				decision.Policy,
+				string(decision.Reason),
+				decision.BalanceBefore.String(),
+				decision.BalanceAfter.String(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
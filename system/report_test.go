@@ -0,0 +1,68 @@
+package system
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func dryValidationRun(userId uuid.UUID) entity.ValidationRun {
+	return entity.ValidationRun{
+		UsersProcessed:  1,
+		ResultsApproved: 1,
+		ResultsCanceled: 1,
+		BalanceDelta:    -10_15,
+		DryRun:          true,
+		Users: []entity.UserValidation{
+			{
+				UserID:        userId,
+				BalanceBefore: 100_00,
+				BalanceAfter:  89_85,
+				Decisions: []entity.ValidationDecision{
+					{GameResultID: 1, Decision: entity.ValidationStatusCanceled, Policy: "odd_id", Reason: entity.ValidationReasonCanceledByPolicy, BalanceBefore: 100_00, BalanceAfter: 89_85},
+					{GameResultID: 2, Decision: entity.ValidationStatusAccepted, Policy: "odd_id", Reason: entity.ValidationReasonAcceptedByPolicy, BalanceBefore: 89_85, BalanceAfter: 89_85},
+				},
+			},
+		},
+	}
+}
+
+func TestWriteValidationReportJSON(t *testing.T) {
+	userId := uuid.New()
+
+	var out bytes.Buffer
+	err := WriteValidationReport(&out, ReportFormatJSON, dryValidationRun(userId))
+	require.NoError(t, err)
+
+	var report validationReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.Equal(t, 1, report.ResultsCanceled)
+	require.Equal(t, entity.Money(-10_15), report.BalanceDelta)
+	require.Len(t, report.Users, 1)
+	require.Equal(t, userId, report.Users[0].UserID)
+	require.Equal(t, entity.Money(89_85), report.Users[0].BalanceAfter)
+	require.Len(t, report.Users[0].Decisions, 2)
+	require.Equal(t, entity.ValidationReasonCanceledByPolicy, report.Users[0].Decisions[0].Reason)
+}
+
+func TestWriteValidationReportCSV(t *testing.T) {
+	userId := uuid.New()
+
+	var out bytes.Buffer
+	err := WriteValidationReport(&out, ReportFormatCSV, dryValidationRun(userId))
+	require.NoError(t, err)
+
+	expected := "user_id,game_result_id,decision,policy,reason_code,balance_before,balance_after\n" +
+		userId.String() + ",1,canceled,odd_id,canceled_by_policy,100.00,89.85\n" +
+		userId.String() + ",2,accepted,odd_id,accepted_by_policy,89.85,89.85\n"
+	require.Equal(t, expected, out.String())
+}
+
+func TestWriteValidationReportUnknownFormat(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteValidationReport(&out, "xml", dryValidationRun(uuid.New()))
+	require.Error(t, err)
+}
@@ -12,3 +12,31 @@ func SleepWithContext(ctx context.Context, duration time.Duration) {
 	case <-time.After(duration):
 	}
 }
+
+// SleepUntilNotified sleeps for the specified duration, or until a notification is received or the context is canceled.
+// The notifications already queued are consumed as well, a single wake up covers them all.
+// A nil or closed notifications channel never wakes up the sleep.
+func SleepUntilNotified[T any](ctx context.Context, duration time.Duration, notifications <-chan T) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(duration):
+		return
+	case _, ok := <-notifications:
+		if !ok {
+			SleepWithContext(ctx, duration)
+			return
+		}
+	}
+
+	for {
+		select {
+		case _, ok := <-notifications:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
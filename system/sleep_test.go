@@ -33,3 +33,59 @@ func TestSleepWithContext(t *testing.T) {
 		}
 	})
 }
+
+func TestSleepUntilNotified(t *testing.T) {
+	t.Run("Sleeps for the specified duration without notifications", func(t *testing.T) {
+		startTime := time.Now()
+		SleepUntilNotified(context.Background(), 50*time.Millisecond, make(chan struct{}))
+		elapsedTime := time.Since(startTime)
+
+		if elapsedTime < 50*time.Millisecond {
+			t.Errorf("SleepUntilNotified didn't sleep for the expected duration")
+		}
+	})
+
+	t.Run("Returns on notification, consuming the queued ones", func(t *testing.T) {
+		notifications := make(chan int, 3)
+		notifications <- 1
+		notifications <- 2
+		notifications <- 3
+
+		startTime := time.Now()
+		SleepUntilNotified(context.Background(), time.Minute, notifications)
+		elapsedTime := time.Since(startTime)
+
+		if elapsedTime > 10*time.Millisecond {
+			t.Errorf("SleepUntilNotified should return as soon as notified")
+		}
+		if len(notifications) != 0 {
+			t.Errorf("SleepUntilNotified should consume the queued notifications, %d left", len(notifications))
+		}
+	})
+
+	t.Run("Falls back to the duration once notifications are closed", func(t *testing.T) {
+		notifications := make(chan int)
+		close(notifications)
+
+		startTime := time.Now()
+		SleepUntilNotified(context.Background(), 50*time.Millisecond, notifications)
+		elapsedTime := time.Since(startTime)
+
+		if elapsedTime < 50*time.Millisecond {
+			t.Errorf("SleepUntilNotified didn't sleep for the expected duration")
+		}
+	})
+
+	t.Run("Returns immediately if context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // Cancel the context immediately
+
+		startTime := time.Now()
+		SleepUntilNotified[int](ctx, 50*time.Millisecond, nil)
+		elapsedTime := time.Since(startTime)
+
+		if elapsedTime > 10*time.Millisecond {
+			t.Errorf("SleepUntilNotified should return immediately when the context is canceled")
+		}
+	})
+}
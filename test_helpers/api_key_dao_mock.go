@@ -0,0 +1,73 @@
+package test_helpers
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+// mockAPIKeyDAO is a mock type for the APIKeyDAO type
+type mockAPIKeyDAO struct {
+	mock.Mock
+}
+
+// NewMockAPIKeyDAO creates a new instance of mockAPIKeyDAO
+func NewMockAPIKeyDAO() *mockAPIKeyDAO {
+	return &mockAPIKeyDAO{}
+}
+
+func (m *mockAPIKeyDAO) CreateAPIKey(ctx context.Context, transactionSource entity.TransactionSource, description string) (*entity.APIKey, string, error) {
+	args := m.Called(ctx, transactionSource, description)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.APIKey), args.String(1), nil
+	}
+	return nil, "", args.Error(2)
+}
+
+func (m *mockAPIKeyDAO) AuthenticateAPIKey(ctx context.Context, key string) (*entity.APIKey, error) {
+	args := m.Called(ctx, key)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.APIKey), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockAPIKeyDAO) ListAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	args := m.Called(ctx)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.([]entity.APIKey), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockAPIKeyDAO) RotateAPIKey(ctx context.Context, apiKeyId int, gracePeriod time.Duration) (*entity.APIKey, string, error) {
+	args := m.Called(ctx, apiKeyId, gracePeriod)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.APIKey), args.String(1), nil
+	}
+	return nil, "", args.Error(2)
+}
+
+func (m *mockAPIKeyDAO) RevokeAPIKey(ctx context.Context, apiKeyId int) (*entity.APIKey, error) {
+	args := m.Called(ctx, apiKeyId)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.APIKey), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockAPIKeyDAO) RecordNonce(ctx context.Context, apiKeyId int, nonce string, ttl time.Duration) error {
+	args := m.Called(ctx, apiKeyId, nonce, ttl)
+	return args.Error(0)
+}
+
+func (m *mockAPIKeyDAO) PurgeExpiredNonces(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
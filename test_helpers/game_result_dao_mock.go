@@ -21,19 +21,47 @@ func (m *mockGameResultDAO) CreateGameResult(
 	ctx context.Context,
 	userId uuid.UUID,
 	gameStatus entity.GameStatus,
-	amount float64,
+	amount entity.Money,
 	transactionSource entity.TransactionSource,
 	transactionID string) (*entity.GameResult, error) {
 
 	args := m.Called(ctx, userId, gameStatus, amount, transactionSource, transactionID)
 
+	// Replayed transactions come with both the stored game result and an error
 	if arg := args.Get(0); arg != nil {
-		return arg.(*entity.GameResult), nil
+		return arg.(*entity.GameResult), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
-func (m *mockGameResultDAO) ValidateGameResults(ctx context.Context, totalGamesToCancel int) error {
+func (m *mockGameResultDAO) CreateGameResults(ctx context.Context, transactionSource entity.TransactionSource, submissions []entity.GameResultSubmission) []entity.GameResultOutcome {
+	args := m.Called(ctx, transactionSource, submissions)
+	return args.Get(0).([]entity.GameResultOutcome)
+}
+
+func (m *mockGameResultDAO) ListGameResults(ctx context.Context, filter entity.GameResultFilter) (*entity.GameResultPage, error) {
+	args := m.Called(ctx, filter)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.GameResultPage), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockGameResultDAO) ValidateGameResults(ctx context.Context, totalGamesToCancel int) (*entity.ValidationRun, error) {
 	args := m.Called(ctx, totalGamesToCancel)
-	return args.Error(0)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.ValidationRun), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockGameResultDAO) ValidateUserGameResults(ctx context.Context, userId uuid.UUID, totalGamesToCancel int) (*entity.ValidationRun, error) {
+	args := m.Called(ctx, userId, totalGamesToCancel)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.ValidationRun), args.Error(1)
+	}
+	return nil, args.Error(1)
 }
@@ -0,0 +1,26 @@
+package test_helpers
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockHealthDAO is a mock type for the HealthDAO type
+type mockHealthDAO struct {
+	mock.Mock
+}
+
+// NewMockHealthDAO creates a new instance of mockHealthDAO
+func NewMockHealthDAO() *mockHealthDAO {
+	return &mockHealthDAO{}
+}
+
+func (m *mockHealthDAO) CheckDatabase(ctx context.Context) (*entity.DatabaseHealth, error) {
+	args := m.Called(ctx)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.DatabaseHealth), nil
+	}
+	return nil, args.Error(1)
+}
@@ -2,15 +2,22 @@ package test_helpers
 
 import (
 	"bytes"
+	"github.com/ildomm/cceab/logging"
 	"log"
+	"log/slog"
 )
 
 // CaptureOutput redirects the log output to a buffer and returns a function to restore the original state and the buffer.
+// Both the structured logs and the standard log package are captured, as JSON, down to the debug level.
 func CaptureOutput() (*bytes.Buffer, func()) {
-	originalOutput := log.Writer() // Store the original output
+	originalOutput, originalFlags := log.Writer(), log.Flags() // Store the original output
+	originalLogger := slog.Default()
 	buf := new(bytes.Buffer)
-	log.SetOutput(buf)
+	slog.SetDefault(logging.NewLogger(buf, logging.Options{Level: slog.LevelDebug, Format: logging.FormatJSON}))
 	return buf, func() {
-		log.SetOutput(originalOutput) // Restore the original output
+		// Restore the original output
+		slog.SetDefault(originalLogger)
+		log.SetOutput(originalOutput)
+		log.SetFlags(originalFlags)
 	}
 }
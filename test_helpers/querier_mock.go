@@ -9,6 +9,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/mock"
 	"math/rand"
+	"runtime"
 	"sync"
 	"time"
 )
@@ -20,12 +21,18 @@ type MockQuerier struct {
 
 	keys       map[string]map[string]interface{}
 	game_count int
+
+	// Emulates the database row locks, held until the end of the transaction
+	rowLocks map[uuid.UUID]*sync.Mutex
+	txnLocks map[*sql.Tx]map[uuid.UUID]*sync.Mutex
 }
 
 // NewMockQuerier creates a new instance of MockQuerier
 func NewMockQuerier() *MockQuerier {
 	mocked := &MockQuerier{
-		keys: make(map[string]map[string]interface{}),
+		keys:     make(map[string]map[string]interface{}),
+		rowLocks: make(map[uuid.UUID]*sync.Mutex),
+		txnLocks: make(map[*sql.Tx]map[uuid.UUID]*sync.Mutex),
 	}
 
 	mocked.keys["game_results"] = make(map[string]interface{})
@@ -39,6 +46,21 @@ func (m *MockQuerier) Close() {
 	m.Called()
 }
 
+func (m *MockQuerier) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(uint), args.Bool(1), args.Error(2)
+}
+
+func (m *MockQuerier) Stats() sql.DBStats {
+	args := m.Called()
+	return args.Get(0).(sql.DBStats)
+}
+
 func (m *MockQuerier) GameCount() int {
 	return m.game_count
 }
@@ -46,12 +68,25 @@ func (m *MockQuerier) GameCount() int {
 func (m *MockQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx) error) (err error) {
 	m.Called(ctx, fn)
 
-	txn := new(sqlx.Tx)
+	txn := &sqlx.Tx{Tx: new(sql.Tx)}
+	defer m.releaseRowLocks(txn.Tx)
+
 	err = fn(txn)
 
 	return err
 }
 
+// releaseRowLocks releases the row locks held by the transaction
+func (m *MockQuerier) releaseRowLocks(txn *sql.Tx) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, rowLock := range m.txnLocks[txn] {
+		rowLock.Unlock()
+	}
+	delete(m.txnLocks, txn)
+}
+
 func (m *MockQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (int, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -70,14 +105,82 @@ func (m *MockQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameRes
 	return 0, args.Error(1)
 }
 
-func (m *MockQuerier) LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) error {
+func (m *MockQuerier) InsertUser(ctx context.Context, user entity.User) (uuid.UUID, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	args := m.Called(ctx, txn, userId)
+	args := m.Called(ctx, user)
+	if arg := args.Get(0); arg != nil {
+		return arg.(uuid.UUID), nil
+	}
+	return uuid.Nil, args.Error(1)
+}
+
+func (m *MockQuerier) UpdateUser(ctx context.Context, txn sqlx.Tx, user entity.User) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, user)
 	return args.Error(0)
 }
 
+func (m *MockQuerier) LockUserRow(ctx context.Context, txn sqlx.Tx, userId uuid.UUID) (*entity.User, error) {
+	args := m.lockedCall(func() mock.Arguments { return m.MethodCalled("LockUserRow", ctx, txn, userId) })
+	if len(args) > 1 && args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	// Transactions on the same user wait for each other, as the database does
+	m.acquireRowLock(txn.Tx, userId)
+
+	// Yield, as a database round trip would, so concurrent transactions interleave
+	defer runtime.Gosched()
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.User), nil
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.storedUser(userId), nil
+}
+
+// lockedCall records the mocked call while holding the mock lock
+func (m *MockQuerier) lockedCall(call func() mock.Arguments) mock.Arguments {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return call()
+}
+
+// acquireRowLock blocks until the transaction holds the user row lock
+func (m *MockQuerier) acquireRowLock(txn *sql.Tx, userId uuid.UUID) {
+	m.lock.Lock()
+	rowLock, ok := m.rowLocks[userId]
+	if !ok {
+		rowLock = &sync.Mutex{}
+		m.rowLocks[userId] = rowLock
+	}
+	_, held := m.txnLocks[txn][userId]
+	m.lock.Unlock()
+
+	if held {
+		return
+	}
+	rowLock.Lock()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.txnLocks[txn] == nil {
+		m.txnLocks[txn] = make(map[uuid.UUID]*sync.Mutex)
+	}
+	m.txnLocks[txn][userId] = rowLock
+}
+
 func (m *MockQuerier) SelectUser(ctx context.Context, userId uuid.UUID) (*entity.User, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -94,14 +197,19 @@ func (m *MockQuerier) SelectUser(ctx context.Context, userId uuid.UUID) (*entity
 		}
 	}
 
+	return m.storedUser(userId), nil
+}
+
+// storedUser returns the user recorded by the previous balance updates, if any
+func (m *MockQuerier) storedUser(userId uuid.UUID) *entity.User {
 	for _, user := range m.keys["user_balance"] {
 		if user.(entity.User).ID == userId {
 			_user := user.(entity.User)
-			return &_user, nil
+			return &_user
 		}
 	}
 
-	return nil, nil
+	return nil
 }
 
 func (m *MockQuerier) SelectUsersByValidationStatus(ctx context.Context, validationStatus bool) ([]entity.User, error) {
@@ -115,6 +223,33 @@ func (m *MockQuerier) SelectUsersByValidationStatus(ctx context.Context, validat
 	return nil, args.Error(1)
 }
 
+func (m *MockQuerier) ClaimUserForValidation(ctx context.Context, txn sqlx.Tx, excludedUserIds []uuid.UUID) (*entity.User, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, excludedUserIds)
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.User), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) ListenPendingValidations(ctx context.Context) (<-chan uuid.UUID, error) {
+	args := m.Called(ctx)
+	if arg := args.Get(0); arg != nil {
+		return arg.(chan uuid.UUID), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) SumPendingAmountByUser(ctx context.Context, userId uuid.UUID) (entity.Money, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, userId)
+	return args.Get(0).(entity.Money), args.Error(1)
+}
+
 func (m *MockQuerier) CheckTransactionID(ctx context.Context, transactionId string) (bool, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -123,6 +258,17 @@ func (m *MockQuerier) CheckTransactionID(ctx context.Context, transactionId stri
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockQuerier) SelectGameResultByTransactionID(ctx context.Context, transactionId string) (*entity.GameResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, transactionId)
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.GameResult), nil
+	}
+	return nil, args.Error(1)
+}
+
 func (m *MockQuerier) GameResultExists(ctx context.Context, transactionId string) (bool, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -152,7 +298,18 @@ func (m *MockQuerier) SelectGameResultsByUser(ctx context.Context, userId uuid.U
 
 }
 
-func (m *MockQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance float64, validationStatus bool) error {
+func (m *MockQuerier) SelectGameResultsByFilter(ctx context.Context, filter entity.GameResultFilter) ([]entity.GameResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, filter)
+	if arg := args.Get(0); arg != nil {
+		return arg.([]entity.GameResult), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userId uuid.UUID, balance entity.Money, validationStatus bool) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -176,3 +333,125 @@ func (m *MockQuerier) UpdateGameResult(ctx context.Context, txn sqlx.Tx, gameRes
 	args := m.Called(ctx, txn, gameResultId, validationStatus)
 	return args.Error(0)
 }
+
+func (m *MockQuerier) InsertValidationRun(ctx context.Context, validationRun entity.ValidationRun) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, validationRun)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockQuerier) SelectValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, validationRunId)
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.ValidationRun), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) SelectValidationRunsByFilter(ctx context.Context, filter entity.ValidationRunFilter) ([]entity.ValidationRun, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, filter)
+	if arg := args.Get(0); arg != nil {
+		return arg.([]entity.ValidationRun), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) UpdateValidationRun(ctx context.Context, validationRun entity.ValidationRun) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, validationRun)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) InsertValidationDecision(ctx context.Context, txn sqlx.Tx, validationDecision entity.ValidationDecision) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, validationDecision)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockQuerier) SelectValidationDecisionsByGameResults(ctx context.Context, gameResultIds []int) ([]entity.ValidationDecision, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, gameResultIds)
+	if arg := args.Get(0); arg != nil {
+		return arg.([]entity.ValidationDecision), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) InsertAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, apiKey)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockQuerier) UpdateAPIKey(ctx context.Context, txn sqlx.Tx, apiKey entity.APIKey) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, apiKey)
+	return args.Error(0)
+}
+
+func (m *MockQuerier) LockAPIKeyRow(ctx context.Context, txn sqlx.Tx, apiKeyId int) (*entity.APIKey, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, apiKeyId)
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.APIKey), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) SelectAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, keyHash)
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.APIKey), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) SelectAPIKeys(ctx context.Context) ([]entity.APIKey, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx)
+	if arg := args.Get(0); arg != nil {
+		return arg.([]entity.APIKey), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockQuerier) InsertRequestNonce(ctx context.Context, apiKeyId int, nonce string, expiresAt time.Time) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, apiKeyId, nonce, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockQuerier) DeleteExpiredRequestNonces(ctx context.Context, before time.Time) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,45 @@
+package test_helpers
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockUserDAO is a mock type for the UserDAO type
+type mockUserDAO struct {
+	mock.Mock
+}
+
+// NewMockUserDAO creates a new instance of mockUserDAO
+func NewMockUserDAO() *mockUserDAO {
+	return &mockUserDAO{}
+}
+
+func (m *mockUserDAO) CreateUser(ctx context.Context, email string, balance entity.Money) (*entity.User, error) {
+	args := m.Called(ctx, email, balance)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.User), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUserDAO) UpdateUser(ctx context.Context, userId uuid.UUID, update entity.UserUpdate) (*entity.User, error) {
+	args := m.Called(ctx, userId, update)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.User), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockUserDAO) GetUserAccount(ctx context.Context, userId uuid.UUID) (*entity.UserAccount, error) {
+	args := m.Called(ctx, userId)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.UserAccount), nil
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,35 @@
+package test_helpers
+
+import (
+	"context"
+	"github.com/ildomm/cceab/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockValidationRunDAO is a mock type for the ValidationRunDAO type
+type mockValidationRunDAO struct {
+	mock.Mock
+}
+
+// NewMockValidationRunDAO creates a new instance of mockValidationRunDAO
+func NewMockValidationRunDAO() *mockValidationRunDAO {
+	return &mockValidationRunDAO{}
+}
+
+func (m *mockValidationRunDAO) ListValidationRuns(ctx context.Context, filter entity.ValidationRunFilter) (*entity.ValidationRunPage, error) {
+	args := m.Called(ctx, filter)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.ValidationRunPage), nil
+	}
+	return nil, args.Error(1)
+}
+
+func (m *mockValidationRunDAO) GetValidationRun(ctx context.Context, validationRunId int) (*entity.ValidationRun, error) {
+	args := m.Called(ctx, validationRunId)
+
+	if arg := args.Get(0); arg != nil {
+		return arg.(*entity.ValidationRun), nil
+	}
+	return nil, args.Error(1)
+}